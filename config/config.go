@@ -7,12 +7,14 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/metrics"
 	"github.com/edu-cloud-api/model"
 	"github.com/gofiber/fiber/v2"
-	"github.com/joho/godotenv"
+	"go.uber.org/zap"
 )
 
 const (
@@ -24,14 +26,15 @@ const (
 	Megabyte    = 1048576    // Megabyte : 1024^2
 	Byte        = 1024       // Byte : 1024^1
 	MatchNumber = `:(\d+)`
-	WorkerNode  = `work-[-]?\d[\d,]*[\.]?[\d{2}]*`
+	WorkerNode  = `work-[-]?\d[\d,]*[\.]?[\d{2}]*` // default of NODE_SELECTOR
 	ENV_PATH    = ".env"
-	TIME_FORMAT = "2006-01-02"
+	TIME_FORMAT = "2006-01-02"       // date in campus's timezone, expiring at EXPIRY_HOUR
+	HOUR_FORMAT = "2006-01-02T15:04" // date and time in campus's timezone
 
-	// Create VM's Configuration
+	DefaultTimezone = "Asia/Bangkok" // default of CAMPUS_TIMEZONE when TZ is not set
+
+	// Create VM's Configuration, network's bridge and ISO's storage are in Settings
 	SCSIHW = "virtio-scsi-pci"
-	NET0   = "virtio,bridge=vmbr0,firewall=1"
-	ISO    = "cephfs:iso/"
 	SOCKET = 1
 	ONBOOT = 1
 
@@ -39,6 +42,42 @@ const (
 	ADMIN   = "admin"
 	STUDENT = "student"
 	FACULTY = "faculty"
+
+	// Pool's roles
+	POOL_OWNER         = "owner"
+	POOL_CO_INSTRUCTOR = "co-instructor"
+	POOL_TA            = "ta"
+	POOL_MEMBER        = "member"
+
+	// Task's statuses
+	TASK_PENDING = "pending"
+	TASK_RUNNING = "running"
+	TASK_SUCCESS = "success"
+	TASK_FAILURE = "failure"
+
+	// Task's types
+	TASK_PROVISION = "provision"
+	TASK_POWER     = "power"
+	TASK_MIGRATE   = "migrate"
+	TASK_ARCHIVE   = "archive"
+
+	// Pool's archival, member's VMs are backed up by
+	ARCHIVE_NONE     = "none"
+	ARCHIVE_SNAPSHOT = "snapshot" // snapshot is kept in VM, so VM could not be deleted
+	ARCHIVE_BACKUP   = "backup"   // vzdump into BACKUP_STORAGE
+
+	// Pool's archival, pool's templates are
+	TEMPLATE_KEEP    = "keep"
+	TEMPLATE_ARCHIVE = "archive" // backed up then removed from pool, template is kept by its owner
+
+	// Clone's modes
+	CLONE_FULL   = "full"
+	CLONE_LINKED = "linked" // only from template, disk is based on template's disk which stays on template's storage
+
+	// Notification's kinds
+	NOTIFY_POOL_EXPIRING = "pool_expiring"
+	NOTIFY_POOL_ARCHIVED = "pool_archived"
+	NOTIFY_POOL_EXTENDED = "pool_extended"
 )
 
 // GBtoByte - Converter from GB to Byte
@@ -81,33 +120,54 @@ func GreaterOrEqual(cpuA, cpuB float64, memA, memB uint64, diskA, diskB uint64)
 	}
 }
 
-// GetFromENV - get item from .env
-func GetFromENV(item string) string {
-	godotenv.Load(ENV_PATH)
-	return os.Getenv(item)
-}
-
 // GetURL - Constructing Proxmox's API URL
 func GetURL(query string) string {
-	hostURL := GetFromENV("PROXMOX_HOST")
+	hostURL := Get().ProxmoxHost
 	u, _ := url.ParseRequestURI(hostURL)
 	u.Path = query
 	return u.String()
 }
 
+// doRequest - Sending request, observing latency and error of Proxmox's API and logging it with request ID
+func doRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	observedErr := err
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		observedErr = errors.New(resp.Status)
+	}
+	metrics.ObserveProxmox(req.Method, req.URL.String(), start, observedErr)
+
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("endpoint", metrics.ProxmoxEndpoint(req.URL.String())),
+		zap.Duration("latency", time.Since(start)),
+	}
+	if resp != nil {
+		fields = append(fields, zap.Int("status", resp.StatusCode))
+	}
+	proxmoxLogger := logging.WithRequestID(req.Header.Get(logging.RequestIDHeader))
+	if observedErr != nil {
+		proxmoxLogger.Warn("proxmox request failed", append(fields, zap.Error(observedErr))...)
+	} else {
+		proxmoxLogger.Debug("proxmox request", fields...)
+	}
+	return resp, err
+}
+
 // SendRequest - Constructing HTTP client and Sending request
 func SendRequest(httpMethod, url string, data url.Values) (*http.Response, error) {
 	req, err := http.NewRequest(httpMethod, url, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Authorization", GetFromENV("PROXMOX_API_KEY"))
+	req.Header.Add("Authorization", Get().ProxmoxAPIKey)
 	if data != nil {
 		req.Header.Add("Content-Type", URL_ENCODED)
 	}
 
-	client := &http.Client{}
-	resp, sendErr := client.Do(req)
+	client := &http.Client{Timeout: Get().ProxmoxTimeout}
+	resp, sendErr := doRequest(client, req)
 	if sendErr != nil {
 		return nil, sendErr
 	}
@@ -125,9 +185,12 @@ func SendRequestWithErr(httpMethod, url string, data url.Values, cookies model.C
 	}
 	req.AddCookie(&cookies.Cookie)
 	req.Header.Add(CSRF_TOKEN, cookies.CSRFPreventionToken.Value)
+	if cookies.RequestID != "" {
+		req.Header.Add(logging.RequestIDHeader, cookies.RequestID)
+	}
 
-	client := &http.Client{}
-	resp, sendErr := client.Do(req)
+	client := &http.Client{Timeout: Get().ProxmoxTimeout}
+	resp, sendErr := doRequest(client, req)
 	if sendErr != nil {
 		return nil, sendErr
 	}
@@ -155,8 +218,8 @@ func SendRequestWithoutCookie(httpMethod, url string, data url.Values) ([]byte,
 		req.Header.Add("Content-Type", URL_ENCODED)
 	}
 
-	client := &http.Client{}
-	resp, sendErr := client.Do(req)
+	client := &http.Client{Timeout: Get().ProxmoxTimeout}
+	resp, sendErr := doRequest(client, req)
 	if sendErr != nil {
 		return nil, sendErr
 	}
@@ -180,13 +243,13 @@ func SendRequestUsingToken(httpMethod, url string, data url.Values) ([]byte, err
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Authorization", GetFromENV("PROXMOX_API_KEY"))
+	req.Header.Add("Authorization", Get().ProxmoxAPIKey)
 	if data != nil {
 		req.Header.Add("Content-Type", URL_ENCODED)
 	}
 
-	client := &http.Client{}
-	resp, sendErr := client.Do(req)
+	client := &http.Client{Timeout: Get().ProxmoxTimeout}
+	resp, sendErr := doRequest(client, req)
 	if sendErr != nil {
 		return nil, sendErr
 	}
@@ -228,6 +291,7 @@ func GetCookies(c *fiber.Ctx) model.Cookies {
 			Name:  CSRF_TOKEN,
 			Value: decodedCSRF,
 		},
+		RequestID: logging.RequestID(c),
 	}
 
 	// cookies := model.Cookies{
@@ -0,0 +1,80 @@
+// Package config - for utils function
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+)
+
+const passwordCharset = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeCharset - characters of code which is read aloud or typed by hand, e.g. pool's join code
+const codeCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Encrypt - encrypt given text with ENCRYPT_KEY using AES-GCM and return as base64
+func Encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher([]byte(Get().EncryptKey))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// Decrypt - decrypt given base64 text which encrypted by Encrypt
+func Decrypt(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher([]byte(Get().EncryptKey))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("error: encrypted text is too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// RandomPassword - generate random password from given length
+func RandomPassword(length int) (string, error) {
+	return randomString(passwordCharset, length)
+}
+
+// RandomCode - generate random uppercase code without ambiguous characters
+func RandomCode(length int) (string, error) {
+	return randomString(codeCharset, length)
+}
+
+func randomString(charset string, length int) (string, error) {
+	random := make([]byte, length)
+	for i := range random {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		random[i] = charset[n.Int64()]
+	}
+	return string(random), nil
+}
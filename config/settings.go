@@ -0,0 +1,338 @@
+// Package config - for utils function
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/joho/godotenv"
+)
+
+// GroupLimit - default instance limit of new user in group
+type GroupLimit struct {
+	MaxCPU      float64 `json:"max_cpu"`
+	MaxRAM      float64 `json:"max_ram"`
+	MaxDisk     float64 `json:"max_disk"`
+	MaxInstance uint64  `json:"max_instance"`
+}
+
+// Settings - configuration loaded once from environment variables and .env file
+// critical settings are kept until restart, others are replaced by Reload
+type Settings struct {
+	// critical settings, restart is required
+	ProxmoxHost   string `json:"proxmox_host"`
+	ProxmoxAPIKey string `json:"-"`
+	DBHost        string `json:"db_host"`
+	DBPort        string `json:"db_port"`
+	DBUser        string `json:"db_user"`
+	DBPass        string `json:"-"`
+	DBName        string `json:"db_name"`
+	EncryptKey    string `json:"-"`
+	ListenAddress string `json:"listen_address"`
+	LogFormat     string `json:"log_format"`
+	AutoMigrate   bool   `json:"auto_migrate"`
+
+	// non-critical settings, hot reloaded
+	LogLevel         string                `json:"log_level"`
+	Limits           map[string]GroupLimit `json:"limits"`
+	NetworkBridge    string                `json:"network_bridge"`
+	ISOStorage       string                `json:"iso_storage"`
+	NodeSelector     *regexp.Regexp        `json:"-"`
+	ProxmoxTimeout   time.Duration         `json:"proxmox_timeout"`
+	PowerTimeout     time.Duration         `json:"power_timeout"`
+	CloneTimeout     time.Duration         `json:"clone_timeout"`
+	MigrateTimeout   time.Duration         `json:"migrate_timeout"`
+	BackupTimeout    time.Duration         `json:"backup_timeout"`
+	BackupStorage    string                `json:"backup_storage"` // empty : Proxmox's default storage of vzdump
+	CordonedNodes    []string              `json:"cordoned_nodes"`
+	MetricsToken     string                `json:"-"`
+	ReconcileAutoFix bool                  `json:"reconcile_auto_fix"`
+	CampusTimezone   string                `json:"campus_timezone"`
+	CampusLocation   *time.Location        `json:"-"`
+	ExpiryHour       int                   `json:"expiry_hour"`       // hour of day in campus's timezone which expiry date ends
+	HotplugMaxCores  uint64                `json:"hotplug_max_cores"` // cores of VM with CPU hot-plug, its vcpus are able to be raised up to them while running
+	UsageRetention   int                   `json:"usage_retention"`   // days which usage samples are kept, older samples are rolled up by day
+}
+
+// NET0 - VM's network device on configured bridge
+func (s *Settings) NET0() string {
+	return fmt.Sprintf("virtio,bridge=%s,firewall=1", s.NetworkBridge)
+}
+
+// ISO - prefix of ISO's volume ID in configured storage
+func (s *Settings) ISO() string {
+	return s.ISOStorage + ":iso/"
+}
+
+// defaultLimits - default instance limits when LIMITS_{GROUP} is not set, in {cpu}/{ram}/{disk}/{instance}
+var defaultLimits = map[string]string{
+	STUDENT: "4/4/40/1",
+	FACULTY: "12/12/120/3",
+	ADMIN:   "120/120/1200/30",
+}
+
+var (
+	settingsMutex sync.RWMutex
+	settings      *Settings
+	fileValues    map[string]string
+)
+
+// envFile - path of .env file, CONFIG_FILE overrides default .env
+func envFile() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	return ENV_PATH
+}
+
+// readEnvFile - reading values of .env file, missing file means no value
+func readEnvFile() map[string]string {
+	values, err := godotenv.Read(envFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: could not read %s due to %s", envFile(), err)
+		}
+		return map[string]string{}
+	}
+	return values
+}
+
+// GetFromENV - get item from environment variables, then from .env file
+// .env file is read once and again only when settings are reloaded
+func GetFromENV(item string) string {
+	if value, ok := os.LookupEnv(item); ok {
+		return value
+	}
+	settingsMutex.RLock()
+	values := fileValues
+	settingsMutex.RUnlock()
+	if values == nil {
+		values = readEnvFile()
+		settingsMutex.Lock()
+		if fileValues == nil {
+			fileValues = values
+		}
+		settingsMutex.Unlock()
+	}
+	return values[item]
+}
+
+// Get - current settings, settings are loaded without validation when Load has not been called
+func Get() *Settings {
+	settingsMutex.RLock()
+	current := settings
+	settingsMutex.RUnlock()
+	if current != nil {
+		return current
+	}
+	current, _ = parseSettings()
+	settingsMutex.Lock()
+	if settings == nil {
+		settings = current
+	}
+	current = settings
+	settingsMutex.Unlock()
+	return current
+}
+
+// Load - loading and validating settings at startup
+func Load() (*Settings, error) {
+	settingsMutex.Lock()
+	fileValues = readEnvFile()
+	settingsMutex.Unlock()
+	loaded, err := parseSettings()
+	if err != nil {
+		return loaded, err
+	}
+	settingsMutex.Lock()
+	settings = loaded
+	settingsMutex.Unlock()
+	return loaded, nil
+}
+
+// Reload - reading .env file again and replacing non-critical settings
+// current settings are kept when new settings are invalid
+func Reload() (*Settings, error) {
+	current := Get()
+	values := readEnvFile()
+	settingsMutex.Lock()
+	previousValues := fileValues
+	fileValues = values
+	settingsMutex.Unlock()
+
+	reloaded, err := parseSettings()
+	if err != nil {
+		settingsMutex.Lock()
+		fileValues = previousValues
+		settingsMutex.Unlock()
+		return current, err
+	}
+	if reloaded.ProxmoxHost != current.ProxmoxHost || reloaded.ProxmoxAPIKey != current.ProxmoxAPIKey ||
+		reloaded.DBHost != current.DBHost || reloaded.DBPort != current.DBPort || reloaded.DBUser != current.DBUser ||
+		reloaded.DBPass != current.DBPass || reloaded.DBName != current.DBName || reloaded.EncryptKey != current.EncryptKey ||
+		reloaded.ListenAddress != current.ListenAddress || reloaded.LogFormat != current.LogFormat {
+		log.Println("Warning: Proxmox's, database's, encryption, listen address or log format settings have been changed, restart is required to apply them")
+	}
+	// critical settings are kept until restart
+	reloaded.ProxmoxHost, reloaded.ProxmoxAPIKey = current.ProxmoxHost, current.ProxmoxAPIKey
+	reloaded.DBHost, reloaded.DBPort, reloaded.DBUser, reloaded.DBPass, reloaded.DBName = current.DBHost, current.DBPort, current.DBUser, current.DBPass, current.DBName
+	reloaded.EncryptKey, reloaded.ListenAddress, reloaded.LogFormat = current.EncryptKey, current.ListenAddress, current.LogFormat
+	reloaded.AutoMigrate = current.AutoMigrate
+
+	if levelErr := logging.SetLevel(reloaded.LogLevel); levelErr != nil {
+		return current, levelErr
+	}
+	settingsMutex.Lock()
+	settings = reloaded
+	settingsMutex.Unlock()
+	log.Println("Reloaded settings")
+	return reloaded, nil
+}
+
+// parseSettings - parsing settings from environment variables and .env file, every invalid settings are reported together
+func parseSettings() (*Settings, error) {
+	var problems []string
+	get := func(item, fallback string) string {
+		if value := strings.TrimSpace(GetFromENV(item)); value != "" {
+			return value
+		}
+		return fallback
+	}
+	required := func(item string) string {
+		value := get(item, "")
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s is required", item))
+		}
+		return value
+	}
+	duration := func(item, fallback string) time.Duration {
+		value := get(item, fallback)
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			problems = append(problems, fmt.Sprintf("%s : %s is not positive duration, e.g. 30s, 5m", item, value))
+			parsed, _ = time.ParseDuration(fallback)
+		}
+		return parsed
+	}
+
+	s := &Settings{
+		ProxmoxHost:      required("PROXMOX_HOST"),
+		ProxmoxAPIKey:    required("PROXMOX_API_KEY"),
+		DBHost:           required("DB_HOST"),
+		DBPort:           required("DB_PORT"),
+		DBUser:           required("DB_USER"),
+		DBPass:           get("DB_PASS", ""),
+		DBName:           required("DB_NAME"),
+		EncryptKey:       required("ENCRYPT_KEY"),
+		ListenAddress:    get("LISTEN_ADDRESS", ":3002"),
+		LogFormat:        get("LOG_FORMAT", "console"),
+		AutoMigrate:      get("AUTO_MIGRATE", "true") == "true",
+		LogLevel:         get("LOG_LEVEL", "info"),
+		Limits:           map[string]GroupLimit{},
+		NetworkBridge:    get("NETWORK_BRIDGE", "vmbr0"),
+		ISOStorage:       get("ISO_STORAGE", "cephfs"),
+		ProxmoxTimeout:   duration("PROXMOX_TIMEOUT", "60s"),
+		PowerTimeout:     duration("POWER_TIMEOUT", "5m"),
+		CloneTimeout:     duration("CLONE_TIMEOUT", "10m"),
+		MigrateTimeout:   duration("MIGRATE_TIMEOUT", "30m"),
+		BackupTimeout:    duration("BACKUP_TIMEOUT", "2h"),
+		BackupStorage:    get("BACKUP_STORAGE", ""),
+		MetricsToken:     get("METRICS_TOKEN", ""),
+		ReconcileAutoFix: get("RECONCILE_AUTO_FIX", "false") == "true",
+		CampusTimezone:   get("CAMPUS_TIMEZONE", get("TZ", DefaultTimezone)),
+	}
+
+	if s.ProxmoxHost != "" {
+		if u, err := url.ParseRequestURI(s.ProxmoxHost); err != nil || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("PROXMOX_HOST : %s is not URL", s.ProxmoxHost))
+		}
+	}
+	if s.EncryptKey != "" && len(s.EncryptKey) != 16 && len(s.EncryptKey) != 24 && len(s.EncryptKey) != 32 {
+		problems = append(problems, "ENCRYPT_KEY must be 16, 24 or 32 characters")
+	}
+	if s.LogFormat != "json" && s.LogFormat != "console" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT : %s is not json or console", s.LogFormat))
+	}
+	if !logging.IsValidLevel(s.LogLevel) {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL : %s is not debug, info, warn or error", s.LogLevel))
+	}
+	for group, fallback := range defaultLimits {
+		item := "LIMITS_" + strings.ToUpper(group)
+		limit, err := parseGroupLimit(get(item, fallback))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s : %s", item, err))
+			limit, _ = parseGroupLimit(fallback)
+		}
+		s.Limits[group] = limit
+	}
+	nodeSelector, selectorErr := regexp.Compile(get("NODE_SELECTOR", WorkerNode))
+	if selectorErr != nil {
+		problems = append(problems, fmt.Sprintf("NODE_SELECTOR is invalid regex due to %s", selectorErr))
+		nodeSelector = regexp.MustCompile(WorkerNode)
+	}
+	s.NodeSelector = nodeSelector
+	location, locationErr := time.LoadLocation(s.CampusTimezone)
+	if locationErr != nil {
+		problems = append(problems, fmt.Sprintf("CAMPUS_TIMEZONE : %s is not IANA timezone, e.g. %s", s.CampusTimezone, DefaultTimezone))
+		location, _ = time.LoadLocation(DefaultTimezone)
+	}
+	s.CampusLocation = location
+	expiryHour, hourErr := strconv.Atoi(get("EXPIRY_HOUR", "0"))
+	if hourErr != nil || expiryHour < 0 || expiryHour > 23 {
+		problems = append(problems, fmt.Sprintf("EXPIRY_HOUR : %s is not hour between 0 and 23", get("EXPIRY_HOUR", "0")))
+		expiryHour = 0
+	}
+	s.ExpiryHour = expiryHour
+	hotplugMaxCores, coresErr := strconv.ParseUint(get("HOTPLUG_MAX_CORES", "8"), 10, 64)
+	if coresErr != nil || hotplugMaxCores == 0 {
+		problems = append(problems, fmt.Sprintf("HOTPLUG_MAX_CORES : %s is not positive integer", get("HOTPLUG_MAX_CORES", "8")))
+		hotplugMaxCores = 8
+	}
+	s.HotplugMaxCores = hotplugMaxCores
+	usageRetention, retentionErr := strconv.Atoi(get("USAGE_RETENTION_DAYS", "90"))
+	if retentionErr != nil || usageRetention <= 0 {
+		problems = append(problems, fmt.Sprintf("USAGE_RETENTION_DAYS : %s is not positive integer", get("USAGE_RETENTION_DAYS", "90")))
+		usageRetention = 90
+	}
+	s.UsageRetention = usageRetention
+	for _, node := range strings.Split(get("CORDONED_NODES", ""), ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			s.CordonedNodes = append(s.CordonedNodes, node)
+		}
+	}
+
+	if len(problems) > 0 {
+		return s, errors.New("error: invalid settings, " + strings.Join(problems, "; "))
+	}
+	return s, nil
+}
+
+// parseGroupLimit - parsing group's limit in {cpu}/{ram}/{disk}/{instance}, e.g. 4/4/40/1
+func parseGroupLimit(value string) (GroupLimit, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 4 {
+		return GroupLimit{}, fmt.Errorf("%s is not {cpu}/{ram}/{disk}/{instance}", value)
+	}
+	var numbers [3]float64
+	for i := 0; i < 3; i++ {
+		number, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil || number <= 0 {
+			return GroupLimit{}, fmt.Errorf("%s is not {cpu}/{ram}/{disk}/{instance} of positive numbers", value)
+		}
+		numbers[i] = number
+	}
+	instance, err := strconv.ParseUint(strings.TrimSpace(parts[3]), 10, 64)
+	if err != nil || instance == 0 {
+		return GroupLimit{}, fmt.Errorf("%s is not {cpu}/{ram}/{disk}/{instance} of positive numbers", value)
+	}
+	return GroupLimit{MaxCPU: numbers[0], MaxRAM: numbers[1], MaxDisk: numbers[2], MaxInstance: instance}, nil
+}
@@ -0,0 +1,63 @@
+// Package config - for utils function
+package config
+
+import (
+	"fmt"
+	"time"
+
+	// embedded timezone database, since container's image may not have one
+	_ "time/tzdata"
+)
+
+// Location - campus's timezone which expiry dates are counted in
+func Location() *time.Location {
+	if location := Get().CampusLocation; location != nil {
+		return location
+	}
+	return time.UTC
+}
+
+// Now - current time in campus's timezone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// ExpiryAt - end of given day in campus's timezone, at EXPIRY_HOUR
+func ExpiryAt(day time.Time) time.Time {
+	day = day.In(Location())
+	return time.Date(day.Year(), day.Month(), day.Day(), Get().ExpiryHour, 0, 0, 0, Location())
+}
+
+// ExpiryAfter - expiry of thing which is created now and lasts given years, months, days
+func ExpiryAfter(years, months, days int) *time.Time {
+	expireTime := ExpiryAt(Now().AddDate(years, months, days))
+	return &expireTime
+}
+
+// ParseTime - parsing time of request's body
+/*
+	@value : one of
+		2006-01-02T15:04:05Z07:00 (RFC3339) : exact time
+		2006-01-02T15:04 : time in campus's timezone
+		2006-01-02 : date in campus's timezone, at EXPIRY_HOUR
+*/
+func ParseTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.ParseInLocation(HOUR_FORMAT, value, Location()); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.ParseInLocation(TIME_FORMAT, value, Location()); err == nil {
+		return ExpiryAt(parsed), nil
+	}
+	return time.Time{}, fmt.Errorf("error: time : %s is not %s, %s or RFC3339", value, TIME_FORMAT, HOUR_FORMAT)
+}
+
+// FormatTime - formatting optional time in campus's timezone, empty when nil
+func FormatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(Location()).Format(time.RFC3339)
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/edu-cloud-api/config"
-	"github.com/edu-cloud-api/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -22,29 +21,23 @@ type dsn struct {
 	port     string
 }
 
-// TableToMigrate - Migrate the schema for each table
-type TableToMigrate struct {
-	Name   string
-	Schema interface{}
-}
-
 // DB - db's global variable
 var DB *gorm.DB
 
 // GetDSN - getting datasource
 func GetDSN() string {
 	datasource := dsn{
-		hostname: config.GetFromENV("DB_HOST"),
-		username: config.GetFromENV("DB_USER"),
-		password: config.GetFromENV("DB_PASS"),
-		dbname:   config.GetFromENV("DB_NAME"),
-		port:     config.GetFromENV("DB_PORT"),
+		hostname: config.Get().DBHost,
+		username: config.Get().DBUser,
+		password: config.Get().DBPass,
+		dbname:   config.Get().DBName,
+		port:     config.Get().DBPort,
 	}
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", datasource.hostname, datasource.username, datasource.password, datasource.dbname, datasource.port)
 }
 
-// Initialize - setting logger & running migrations
-func Initialize() {
+// Connect - setting logger & connecting to database without running migrations
+func Connect() error {
 	// Set up the logger
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io.Writer
@@ -54,31 +47,36 @@ func Initialize() {
 			Colorful:      true,         // Use color output
 		},
 	)
-	DB, _ = gorm.Open(postgres.Open(GetDSN()), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(GetDSN()), &gorm.Config{
 		Logger: newLogger,
 	})
-	RunMigrations()
+	if err != nil {
+		log.Println("Error: Could not connect to database :", err)
+		return fmt.Errorf("error: unable to connect to database due to %s", err)
+	}
+	DB = db
+	return nil
 }
 
-// RunMigrations - running migrations function
-func RunMigrations() {
-	tablesToMigrate := []TableToMigrate{
-		{"admin", &model.User{}},
-		{"student", &model.User{}},
-		{"faculty", &model.User{}},
-		{"instance", &model.Instance{}},
-		{"instance_limit", &model.InstanceLimit{}},
-		{"pool", &model.Pool{}},
-		{"sizing", &model.Sizing{}},
-		// {"proxy", &Proxy{}},
-		// {"proxy_key", &ProxyKey{}},
+// Initialize - connecting to database, checking schema's version & running pending migrations
+// database whose schema is newer than this build is refused
+// pending migrations are refused instead of applied when AUTO_MIGRATE is false
+func Initialize() error {
+	if err := Connect(); err != nil {
+		return err
 	}
-	log.Println("Running migrations ...")
-	for _, table := range tablesToMigrate {
-		err := DB.Table(table.Name).AutoMigrate(table.Schema)
+	if err := CheckSchema(); err != nil {
+		return err
+	}
+	if !config.Get().AutoMigrate {
+		pending, err := PendingMigrations()
 		if err != nil {
-			panic(fmt.Sprintf("migration of %s table failed: %v", table.Name, err))
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("error: database has %d pending migrations, run `app migrate up` or set AUTO_MIGRATE=true", len(pending))
 		}
+		return nil
 	}
-	log.Println("Successfully running migrations")
+	return MigrateUp(0)
 }
@@ -0,0 +1,105 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+)
+
+// GetFlavor - getting flavor from given ID
+func GetFlavor(id uint64) (model.Flavor, error) {
+	var flavor model.Flavor
+	DB.Table("flavor").Where("id = ?", id).Find(&flavor)
+	if flavor.ID == 0 {
+		log.Println("Error: Could not get flavor ID :", id)
+		return flavor, fmt.Errorf("error: unable to get flavor ID : %d", id)
+	}
+	return flavor, nil
+}
+
+// GetAvailableFlavor - getting flavor from given ID which given group is able to clone with
+func GetAvailableFlavor(id uint64, group string) (model.Flavor, error) {
+	flavor, getFlavorErr := GetFlavor(id)
+	if getFlavorErr != nil {
+		return flavor, getFlavorErr
+	}
+	if !isVisible(group, flavor.Deprecated, flavor.VisibleGroups) {
+		return flavor, fmt.Errorf("error: flavor ID : %d is not available to group : %s", id, group)
+	}
+	return flavor, nil
+}
+
+// GetFlavorCatalog - getting flavors which given group is able to clone with, smallest first
+func GetFlavorCatalog(group string) ([]model.Flavor, error) {
+	var flavors []model.Flavor
+	query := DB.Table("flavor")
+	if group != config.ADMIN {
+		query = query.Where("NOT deprecated AND (cardinality(visible_groups) = 0 OR ? = ANY(visible_groups))", group)
+	}
+	if err := query.Order("cpu, ram, disk, name").Find(&flavors).Error; err != nil {
+		log.Println("Error: Could not get flavor catalog of group :", group)
+		return flavors, fmt.Errorf("error: unable to get flavor catalog of group : %s", group)
+	}
+	return flavors, nil
+}
+
+// GetFlavors - getting every flavors including deprecated one, smallest first
+func GetFlavors() ([]model.Flavor, error) {
+	var flavors []model.Flavor
+	if err := DB.Table("flavor").Order("cpu, ram, disk, name").Find(&flavors).Error; err != nil {
+		log.Println("Error: Could not get flavors due to", err)
+		return flavors, fmt.Errorf("error: unable to get flavors due to %s", err)
+	}
+	return flavors, nil
+}
+
+// CreateFlavor - creating flavor, name is unique
+func CreateFlavor(flavor model.Flavor) (model.Flavor, error) {
+	now := time.Now().UTC()
+	flavor.CreateTime, flavor.UpdateTime = now, now
+	if flavor.Deprecated {
+		flavor.DeprecateTime = &now
+	}
+	if err := DB.Table("flavor").Create(&flavor).Error; err != nil {
+		log.Println("Error: Could not create flavor due to", err)
+		return flavor, fmt.Errorf("error: unable to create flavor due to %s", err)
+	}
+	return flavor, nil
+}
+
+// EditFlavor - replacing flavor's spec and metadata by given ID, existing clones keep their spec
+// deprecate time is kept while flavor stays deprecated
+func EditFlavor(flavor model.Flavor) (model.Flavor, error) {
+	current, getFlavorErr := GetFlavor(flavor.ID)
+	if getFlavorErr != nil {
+		return flavor, getFlavorErr
+	}
+	now := time.Now().UTC()
+	flavor.CreateTime, flavor.UpdateTime = current.CreateTime, now
+	flavor.DeprecateTime = deprecateTime(flavor.Deprecated, current.Deprecated, current.DeprecateTime, now)
+	if err := DB.Table("flavor").Where("id = ?", flavor.ID).Select("*").Updates(&flavor).Error; err != nil {
+		log.Printf("Error: Could not update flavor ID : %d due to %s", flavor.ID, err)
+		return flavor, fmt.Errorf("error: unable to update flavor ID : %d due to %s", flavor.ID, err)
+	}
+	return flavor, nil
+}
+
+// DeleteFlavor - deleting deprecated flavor, images which default to it are left without default flavor
+func DeleteFlavor(id uint64) error {
+	flavor, getFlavorErr := GetFlavor(id)
+	if getFlavorErr != nil {
+		return getFlavorErr
+	}
+	if !flavor.Deprecated {
+		return fmt.Errorf("error: flavor ID : %d has to be deprecated before deleted", id)
+	}
+	if err := DB.Table("flavor").Where("id = ?", id).Delete(&model.Flavor{}).Error; err != nil {
+		log.Printf("Error: Could not delete flavor ID : %d due to %s", id, err)
+		return fmt.Errorf("error: unable to delete flavor ID : %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Package database - database's functions
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+)
+
+// GetAllTemplates - getting all instance templates
+func GetAllTemplates() ([]model.Image, error) {
+	var templates []model.Image
+	DB.Table("image").Find(&templates)
+	if len(templates) == 0 {
+		log.Println("Error: Could not get instance templates list")
+		return templates, errors.New("error: unable to list instance templates")
+	}
+	return templates, nil
+}
+
+// GetAllTemplatesID - getting all instance templates's ID
+func GetAllTemplatesID() ([]string, error) {
+	var templates []string
+	DB.Table("image").Select("vmid").Find(&templates)
+	if len(templates) == 0 {
+		log.Println("Error: Could not get instance templates's ID list")
+		return templates, errors.New("error: unable to list instances templates's ID")
+	}
+	return templates, nil
+}
+
+// GetTemplate - getting instance template from given vmid
+func GetTemplate(vmid string) (model.Image, error) {
+	var template model.Image
+	DB.Table("image").Where("vmid = ?", vmid).Find(&template)
+	if template.VMID == "" {
+		log.Println("Error: Could not get instance template id :", vmid)
+		return template, fmt.Errorf("error: unable to get instance template id : %s", vmid)
+	}
+	return template, nil
+}
+
+// isVisible - check catalog's entry is able to be cloned by given group
+// admin is able to clone every entries, others only visible one which has not been deprecated
+func isVisible(group string, deprecated bool, visibleGroups []string) bool {
+	if group == config.ADMIN {
+		return true
+	}
+	return !deprecated && (len(visibleGroups) == 0 || config.Contains(visibleGroups, group))
+}
+
+// IsImageAvailable - check vm's ID is image which given group is able to clone
+func IsImageAvailable(vmid, group string) (bool, error) {
+	image, getImageErr := GetTemplate(vmid)
+	if getImageErr != nil {
+		return false, getImageErr
+	}
+	return isVisible(group, image.Deprecated, image.VisibleGroups), nil
+}
+
+// GetImageCatalog - getting images which given group is able to clone, ordered by name
+func GetImageCatalog(group string) ([]model.Image, error) {
+	var images []model.Image
+	query := DB.Table("image")
+	if group != config.ADMIN {
+		query = query.Where("NOT deprecated AND (cardinality(visible_groups) = 0 OR ? = ANY(visible_groups))", group)
+	}
+	if err := query.Order("name, vmid").Find(&images).Error; err != nil {
+		log.Println("Error: Could not get image catalog of group :", group)
+		return images, fmt.Errorf("error: unable to get image catalog of group : %s", group)
+	}
+	return images, nil
+}
+
+// GetImages - getting every images including deprecated one, ordered by name
+func GetImages() ([]model.Image, error) {
+	var images []model.Image
+	if err := DB.Table("image").Order("name, vmid").Find(&images).Error; err != nil {
+		log.Println("Error: Could not get images due to", err)
+		return images, fmt.Errorf("error: unable to get images due to %s", err)
+	}
+	return images, nil
+}
+
+// CreateImage - creating image
+func CreateImage(image model.Image) (model.Image, error) {
+	if _, err := GetTemplate(image.VMID); err == nil {
+		return image, fmt.Errorf("error: VMID : %s has already been image", image.VMID)
+	}
+	now := time.Now().UTC()
+	image.CreateTime, image.UpdateTime = now, now
+	if image.Deprecated {
+		image.DeprecateTime = &now
+	}
+	if err := DB.Table("image").Create(&image).Error; err != nil {
+		log.Println("Error: Could not create image due to", err)
+		return image, fmt.Errorf("error: unable to create image due to %s", err)
+	}
+	return image, nil
+}
+
+// EditImage - replacing image's default flavor and metadata by given VMID
+// deprecate time is kept while image stays deprecated
+func EditImage(image model.Image) (model.Image, error) {
+	current, getImageErr := GetTemplate(image.VMID)
+	if getImageErr != nil {
+		return image, getImageErr
+	}
+	now := time.Now().UTC()
+	image.CreateTime, image.UpdateTime = current.CreateTime, now
+	image.DeprecateTime = deprecateTime(image.Deprecated, current.Deprecated, current.DeprecateTime, now)
+	if err := DB.Table("image").Where("vmid = ?", image.VMID).Select("*").Updates(&image).Error; err != nil {
+		log.Printf("Error: Could not update image VMID : %s due to %s", image.VMID, err)
+		return image, fmt.Errorf("error: unable to update image VMID : %s", image.VMID)
+	}
+	return image, nil
+}
+
+// DeleteImage - deleting deprecated image from catalog, Proxmox's template and existing clones are kept
+func DeleteImage(vmid string) error {
+	image, getImageErr := GetTemplate(vmid)
+	if getImageErr != nil {
+		return getImageErr
+	}
+	if !image.Deprecated {
+		return fmt.Errorf("error: image VMID : %s has to be deprecated before deleted", vmid)
+	}
+	if err := DB.Table("image").Where("vmid = ?", vmid).Delete(&model.Image{}).Error; err != nil {
+		log.Printf("Error: Could not delete image VMID : %s due to %s", vmid, err)
+		return fmt.Errorf("error: unable to delete image VMID : %s", vmid)
+	}
+	return nil
+}
+
+// deprecateTime - getting deprecate time of edited catalog's entry, kept while entry stays deprecated
+func deprecateTime(deprecated, wasDeprecated bool, current *time.Time, now time.Time) *time.Time {
+	switch {
+	case !deprecated:
+		return nil
+	case wasDeprecated:
+		return current
+	default:
+		return &now
+	}
+}
@@ -40,6 +40,20 @@ func GetAllInstancesIDByOwner(ownerid string) ([]string, error) {
 	return instances, nil
 }
 
+// GetAllInstancesIDByOwners - getting all instances's ID by given owners ID
+func GetAllInstancesIDByOwners(ownerids []string) []string {
+	var instances []string
+	DB.Table("instance").Select("vmid").Where("ownerid IN ?", ownerids).Find(&instances)
+	return instances
+}
+
+// GetAllInstancesIDByPool - getting all instances's ID which are charged to given pool's ID
+func GetAllInstancesIDByPool(poolID uint64) []string {
+	var instances []string
+	DB.Table("instance").Select("vmid").Where("pool_id = ? AND is_template = ?", poolID, false).Order("vmid").Find(&instances)
+	return instances
+}
+
 // GetInstance - getting instance from given vmid
 func GetInstance(vmid string) (model.Instance, error) {
 	var instance model.Instance
@@ -87,12 +101,15 @@ func CreateInstance(vmid, ownerid, node, name string, spec model.VMSpec) (model.
 		MaxCPU:       spec.CPU,
 		MaxRAM:       config.BytetoGB(spec.Memory),
 		MaxDisk:      config.BytetoGB(spec.Disk),
-		CreateTime:   time.Now().UTC().Format(config.TIME_FORMAT),
-		ExpireTime:   time.Now().UTC().AddDate(0, 4, 0).Format(config.TIME_FORMAT),
+		BaseVMID:     spec.BaseVMID,
+		BaseDisk:     config.BytetoGB(spec.BaseDisk),
+		PoolID:       spec.PoolID,
+		CreateTime:   time.Now().UTC(),
+		ExpireTime:   config.ExpiryAfter(0, 4, 0),
 		WillBeExpire: false,
 		Expired:      false,
 	}
-	checked, err := CheckInstanceLimit(ownerid, spec)
+	checked, err := CheckInstanceLimit(ownerid, spec, true)
 	if err != nil {
 		return model.Instance{}, fmt.Errorf("error: could not create instance due to %s", err)
 	}
@@ -160,6 +177,46 @@ func ResizeDisk(vmid string, maxDisk float64) error {
 	return nil
 }
 
+// GetLinkedClones - getting VMID of linked clones which are based on given template
+func GetLinkedClones(vmid string) []string {
+	var clones []string
+	DB.Table("instance").Where("base_vmid = ?", vmid).Pluck("vmid", &clones)
+	return clones
+}
+
+// RenameInstance - update instance's name by given vmid
+func RenameInstance(vmid, name string) error {
+	if err := DB.Table("instance").Where("vmid = ?", vmid).UpdateColumn("name", name).Error; err != nil {
+		log.Printf("Error: Could not rename instance ID : %s due to %s", vmid, err)
+		return fmt.Errorf("error: unable to rename instance ID : %s", vmid)
+	}
+	return nil
+}
+
+// SetInstanceNode - update instance's node by given vmid
+func SetInstanceNode(vmid, node string) error {
+	if err := DB.Table("instance").Where("vmid = ?", vmid).UpdateColumn("node", node).Error; err != nil {
+		log.Printf("Error: Could not update node of instance ID : %s due to %s", vmid, err)
+		return fmt.Errorf("error: unable to update node of instance ID : %s", vmid)
+	}
+	return nil
+}
+
+// SyncInstance - update instance's node, spec and template flag from cluster by given vmid
+func SyncInstance(vmid, node string, maxCPU, maxRAM, maxDisk float64, isTemplate bool) error {
+	if err := DB.Table("instance").Where("vmid = ?", vmid).Updates(map[string]interface{}{
+		"node":        node,
+		"max_cpu":     maxCPU,
+		"max_ram":     maxRAM,
+		"max_disk":    maxDisk,
+		"is_template": isTemplate,
+	}).Error; err != nil {
+		log.Printf("Error: Could not sync instance ID : %s due to %s", vmid, err)
+		return fmt.Errorf("error: unable to sync instance ID : %s", vmid)
+	}
+	return nil
+}
+
 // CheckInstanceOwner - check owner of the given VMID
 func CheckInstanceOwner(username, vmid string) (bool, error) {
 	instance, getInstanceErr := GetInstance(vmid)
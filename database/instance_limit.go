@@ -12,27 +12,18 @@ import (
 
 // CreateInstanceLimit - create user's instance limit by given username, group
 func CreateInstanceLimit(username, group string) error {
-	var (
-		maxCPU, maxRAM, maxDisk float64
-		maxInstance             uint64
-	)
-	switch group {
-	case config.STUDENT:
-		maxCPU, maxRAM, maxDisk, maxInstance = 4, 4, 40, 1
-	case config.FACULTY:
-		maxCPU, maxRAM, maxDisk, maxInstance = 12, 12, 120, 3
-	case config.ADMIN:
-		maxCPU, maxRAM, maxDisk, maxInstance = 120, 120, 1200, 30
-	default:
+	// default limits of group are configured by LIMITS_{GROUP}
+	groupLimit, ok := config.Get().Limits[group]
+	if !ok {
 		log.Printf("Error: Could not create instance limit of username %s due to group %s is invalid", username, group)
 		return fmt.Errorf("error: unable to create instance limit of username %s due to group invalid", username)
 	}
 	limit := model.InstanceLimit{
 		Username:    username,
-		MaxCPU:      maxCPU,
-		MaxRAM:      maxRAM,
-		MaxDisk:     maxDisk,
-		MaxInstance: maxInstance,
+		MaxCPU:      groupLimit.MaxCPU,
+		MaxRAM:      groupLimit.MaxRAM,
+		MaxDisk:     groupLimit.MaxDisk,
+		MaxInstance: groupLimit.MaxInstance,
 	}
 	if err := DB.Model(&model.InstanceLimit{}).Table("instance_limit").Create(&limit).Error; err != nil {
 		log.Println("Error: Could not create instance limit of username :", limit.Username)
@@ -82,7 +73,8 @@ func GetInstanceLimit(username string) (model.InstanceLimit, error) {
 }
 
 // CheckInstanceLimit - check has instance limit reached already? and return boolean
-func CheckInstanceLimit(username string, vmSpec model.VMSpec) (bool, error) {
+// newInstance : false when vmSpec is delta of existing instance being resized, instance count is not checked
+func CheckInstanceLimit(username string, vmSpec model.VMSpec, newInstance bool) (bool, error) {
 	var (
 		sumCPU, remainCPU                                           float64
 		limitRAM, limitDisk, sumRAM, sumDisk, remainRAM, remainDisk uint64
@@ -100,7 +92,7 @@ func CheckInstanceLimit(username string, vmSpec model.VMSpec) (bool, error) {
 	}
 	log.Println("limit instance count :", limit.MaxInstance)
 	log.Println("own instance count :", instanceCount)
-	if instanceCount >= int64(limit.MaxInstance) {
+	if newInstance && instanceCount >= int64(limit.MaxInstance) {
 		log.Println("Error: Maximum instance has reached")
 		return false, errors.New("error: maximum instance has reached")
 	}
@@ -116,20 +108,71 @@ func CheckInstanceLimit(username string, vmSpec model.VMSpec) (bool, error) {
 		for _, instance := range instances {
 			sumCPU += instance.MaxCPU
 			sumRAM += config.GBtoByteFloat(instance.MaxRAM)
-			sumDisk += config.GBtoByteFloat(instance.MaxDisk)
+			sumDisk += config.GBtoByteFloat(instance.MaxDisk + instance.DataDisk)
 		}
 	}
 	log.Printf("limit = cpu : %f, ram : %d, disk : %d", limit.MaxCPU, limitRAM, limitDisk)
 	log.Printf("own spec = cpu : %f, ram : %d, disk : %d", sumCPU, sumRAM, sumDisk)
+	if !newInstance {
+		// delta is compared per dimension, dimension which is not grown is skipped
+		log.Printf("delta spec = cpu : %f, ram : %d, disk : %d", vmSpec.CPU, vmSpec.Memory, vmSpec.ChargedDisk())
+		if fitsLimit(sumCPU, vmSpec.CPU, limit.MaxCPU) &&
+			fitsLimit(float64(sumRAM), float64(vmSpec.Memory), float64(limitRAM)) &&
+			fitsLimit(float64(sumDisk), float64(vmSpec.ChargedDisk()), float64(limitDisk)) {
+			log.Println("able to grow VM :D")
+			return true, nil
+		}
+		return false, errors.New("error: maximum instance limit has reached")
+	}
 	if limitRAM > sumRAM && limit.MaxCPU > sumCPU && limitDisk > sumDisk {
 		log.Println("have sufficient spec for creating VM, check spec of request's vm and remaining limit")
 		remainCPU, remainRAM, remainDisk = limit.MaxCPU-sumCPU, limitRAM-sumRAM, limitDisk-sumDisk
 		log.Printf("remaining limit = cpu : %f, ram : %d, disk : %d", remainCPU, remainRAM, remainDisk)
-		log.Printf("VM spec = cpu : %f, ram : %d, disk : %d", vmSpec.CPU, vmSpec.Memory, vmSpec.Disk)
-		if remainCPU > vmSpec.CPU && remainRAM > vmSpec.Memory && remainDisk > vmSpec.Disk {
+		log.Printf("VM spec = cpu : %f, ram : %d, disk : %d", vmSpec.CPU, vmSpec.Memory, vmSpec.ChargedDisk())
+		if remainCPU > vmSpec.CPU && remainRAM > vmSpec.Memory && remainDisk > vmSpec.ChargedDisk() {
 			log.Println("able to create VM :D")
 			return true, nil
 		}
 	}
 	return false, errors.New("error: maximum instance limit has reached")
 }
+
+// GetGroupQuotaUsage - sum instance limits and instances's spec of users by group {student, faculty, admin}
+func GetGroupQuotaUsage() ([]model.GroupQuotaUsage, error) {
+	var usages []model.GroupQuotaUsage
+	if err := DB.Raw(`
+    SELECT
+        users.user_group,
+        COALESCE(SUM(used.cpu), 0) AS used_cpu,
+        COALESCE(SUM(instance_limit.max_cpu), 0) AS max_cpu,
+        COALESCE(SUM(used.ram), 0) AS used_ram,
+        COALESCE(SUM(instance_limit.max_ram), 0) AS max_ram,
+        COALESCE(SUM(used.disk), 0) AS used_disk,
+        COALESCE(SUM(instance_limit.max_disk), 0) AS max_disk,
+        COALESCE(SUM(used.instances), 0) AS used_instance,
+        COALESCE(SUM(instance_limit.max_instance), 0) AS max_instance
+    FROM
+        (
+            SELECT username, 'student' AS user_group FROM student
+            UNION ALL
+            SELECT username, 'faculty' AS user_group FROM faculty
+            UNION ALL
+            SELECT username, 'admin' AS user_group FROM admin
+        ) AS users
+        LEFT JOIN instance_limit ON instance_limit.username = users.username
+        LEFT JOIN (
+            SELECT
+                ownerid, SUM(max_cpu) AS cpu, SUM(max_ram) AS ram, SUM(max_disk + data_disk) AS disk, COUNT(*) AS instances
+            FROM
+                instance
+            GROUP BY
+                ownerid
+        ) AS used ON used.ownerid = users.username
+    GROUP BY
+        users.user_group;
+`).Scan(&usages).Error; err != nil {
+		log.Println("Error: Could not get quota usage by group due to", err)
+		return usages, fmt.Errorf("error: unable to get quota usage by group")
+	}
+	return usages, nil
+}
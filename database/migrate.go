@@ -0,0 +1,201 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// Migration - versioned change of database's schema or data
+// Down is nil when migration could not be rolled back
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// schemaMigrationsTable - table of applied migrations, it is created outside of migrations
+const schemaMigrationsTable = "schema_migrations"
+
+// validateMigrations - check migrations are ordered by unique version starting from 1
+func validateMigrations() error {
+	var previous uint
+	for _, migration := range migrations {
+		if migration.Version <= previous {
+			return fmt.Errorf("error: migration version %d (%s) is not after version %d", migration.Version, migration.Name, previous)
+		}
+		if migration.Up == nil {
+			return fmt.Errorf("error: migration version %d (%s) has no up", migration.Version, migration.Name)
+		}
+		previous = migration.Version
+	}
+	return nil
+}
+
+// LatestVersion - version of latest migration which this build knows
+func LatestVersion() uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// AppliedMigrations - getting applied migrations ordered by version
+func AppliedMigrations() ([]model.SchemaMigration, error) {
+	var applied []model.SchemaMigration
+	if err := DB.Table(schemaMigrationsTable).AutoMigrate(&model.SchemaMigration{}); err != nil {
+		log.Println("Error: Could not create schema_migrations table :", err)
+		return applied, fmt.Errorf("error: unable to create schema_migrations table due to %s", err)
+	}
+	if err := DB.Table(schemaMigrationsTable).Order("version").Find(&applied).Error; err != nil {
+		log.Println("Error: Could not get applied migrations :", err)
+		return applied, fmt.Errorf("error: unable to get applied migrations due to %s", err)
+	}
+	return applied, nil
+}
+
+// SchemaVersion - version of latest applied migration, 0 when nothing has been applied
+func SchemaVersion() (uint, error) {
+	applied, err := AppliedMigrations()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// CheckSchema - refusing database whose schema has migrations which this build does not know
+func CheckSchema() error {
+	if err := validateMigrations(); err != nil {
+		return err
+	}
+	applied, err := AppliedMigrations()
+	if err != nil {
+		return err
+	}
+	known := map[uint]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+	for _, migration := range applied {
+		if !known[migration.Version] {
+			return fmt.Errorf("error: database schema has unknown migration version %d (%s), latest known version is %d, this build is older than database", migration.Version, migration.Name, LatestVersion())
+		}
+	}
+	return nil
+}
+
+// PendingMigrations - getting migrations which have not been applied, ordered by version
+func PendingMigrations() ([]Migration, error) {
+	applied, err := AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	done := map[uint]bool{}
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+	var pending []Migration
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// MigrationStatuses - getting every known migrations with time it was applied
+func MigrationStatuses() ([]model.MigrationStatus, error) {
+	applied, err := AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := map[uint]time.Time{}
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+	var statuses []model.MigrationStatus
+	for _, migration := range migrations {
+		status := model.MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// MigrateUp - applying pending migrations until target version, 0 for latest version
+// each migration is applied in its own transaction together with its record
+func MigrateUp(target uint) error {
+	if err := CheckSchema(); err != nil {
+		return err
+	}
+	pending, err := PendingMigrations()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		log.Printf("Database schema is up to date at version %d", LatestVersion())
+		return nil
+	}
+	for _, migration := range pending {
+		if target != 0 && migration.Version > target {
+			break
+		}
+		log.Printf("Applying migration version %d (%s) ...", migration.Version, migration.Name)
+		applyErr := DB.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Table(schemaMigrationsTable).Create(&model.SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if applyErr != nil {
+			log.Printf("Error: Could not apply migration version %d (%s) : %s", migration.Version, migration.Name, applyErr)
+			return fmt.Errorf("error: unable to apply migration version %d (%s) due to %s", migration.Version, migration.Name, applyErr)
+		}
+	}
+	log.Println("Successfully running migrations")
+	return nil
+}
+
+// MigrateDown - rolling back latest applied migrations by given steps
+func MigrateDown(steps int) error {
+	if err := CheckSchema(); err != nil {
+		return err
+	}
+	applied, err := AppliedMigrations()
+	if err != nil {
+		return err
+	}
+	byVersion := map[uint]Migration{}
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+	for i := len(applied) - 1; i >= 0 && steps > 0; i, steps = i-1, steps-1 {
+		migration := byVersion[applied[i].Version]
+		if migration.Down == nil {
+			return fmt.Errorf("error: migration version %d (%s) could not be rolled back", migration.Version, migration.Name)
+		}
+		log.Printf("Rolling back migration version %d (%s) ...", migration.Version, migration.Name)
+		rollbackErr := DB.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Table(schemaMigrationsTable).Where("version = ?", migration.Version).Delete(&model.SchemaMigration{}).Error
+		})
+		if rollbackErr != nil {
+			log.Printf("Error: Could not roll back migration version %d (%s) : %s", migration.Version, migration.Name, rollbackErr)
+			return fmt.Errorf("error: unable to roll back migration version %d (%s) due to %s", migration.Version, migration.Name, rollbackErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// TableToMigrate - Migrate the schema for each table
+type TableToMigrate struct {
+	Name   string
+	Schema interface{}
+}
+
+// migrations - every migrations ordered by version, new migration is appended with next version
+// applied migration must never be edited, add new migration instead
+var migrations = []Migration{
+	{Version: 1, Name: "baseline", Up: baselineUp, Down: baselineDown},
+	{Version: 2, Name: "pool_member_and_pool_template", Up: poolRelationsUp, Down: poolRelationsDown},
+	{Version: 3, Name: "timestamptz", Up: timestampsUp, Down: timestampsDown},
+	{Version: 4, Name: "pool_lifecycle_and_notification", Up: poolLifecycleUp, Down: poolLifecycleDown},
+	{Version: 5, Name: "pool_invite", Up: poolInviteUp, Down: poolInviteDown},
+	{Version: 6, Name: "sizing_catalog", Up: sizingCatalogUp, Down: sizingCatalogDown},
+	{Version: 7, Name: "image_and_flavor", Up: imageFlavorUp, Down: imageFlavorDown},
+	{Version: 8, Name: "linked_clone", Up: linkedCloneUp, Down: linkedCloneDown},
+	{Version: 9, Name: "data_disk", Up: dataDiskUp, Down: dataDiskDown},
+	{Version: 10, Name: "pool_lifecycle_opt_in", Up: lifecycleOptInUp, Down: lifecycleOptInDown},
+	{Version: 11, Name: "usage_daily", Up: usageDailyUp, Down: usageDailyDown},
+}
+
+// baselineUser - user's schema at baseline, times were dates in text
+type baselineUser struct {
+	Username   string `gorm:"primaryKey"`
+	Password   string
+	Name       string
+	Email      string
+	Status     bool
+	CreateTime string
+	ExpireTime string
+	Salt       string
+}
+
+// baselineInstance - instance's schema at baseline, times were dates in text
+type baselineInstance struct {
+	VMID         string `gorm:"primaryKey;column:vmid"`
+	OwnerID      string `gorm:"column:ownerid"`
+	Node         string
+	Name         string
+	IsTemplate   bool
+	MaxCPU       float64
+	MaxRAM       float64
+	MaxDisk      float64
+	CreateTime   string
+	ExpireTime   string
+	WillBeExpire bool
+	Expired      bool
+	PoolID       uint64 `gorm:"column:pool_id"`
+}
+
+// baselineSizing - sizing's schema at baseline, create time was date in text
+type baselineSizing struct {
+	VMID       string `gorm:"primaryKey;column:vmid"`
+	Node       string
+	Name       string
+	MaxCPU     float64
+	MaxRAM     float64
+	MaxDisk    float64
+	CreateTime string
+}
+
+// baselinePoolManager - pool manager's schema at baseline, create time was date in text
+type baselinePoolManager struct {
+	ID         uint64 `gorm:"primaryKey;column:id"`
+	PoolID     uint64 `gorm:"column:pool_id"`
+	Username   string
+	Role       string
+	CreateTime string
+}
+
+// baselinePool - pool's schema at baseline, members and templates were text arrays
+type baselinePool struct {
+	ID         uint64 `gorm:"primaryKey;column:id"`
+	Owner      string
+	Code       string
+	Name       string
+	VMID       pq.StringArray `gorm:"column:vmid;type:text[]"`
+	Member     pq.StringArray `gorm:"type:text[]"`
+	CreateTime string
+	ExpireTime string
+	Status     bool
+}
+
+// baselineTables - tables which were created by AutoMigrate before versioned migrations
+// schema of changed table is frozen in baseline's struct, e.g. baselinePool
+var baselineTables = []TableToMigrate{
+	{"admin", &baselineUser{}},
+	{"student", &baselineUser{}},
+	{"faculty", &baselineUser{}},
+	{"instance", &baselineInstance{}},
+	{"instance_limit", &model.InstanceLimit{}},
+	{"pool", &baselinePool{}},
+	{"pool_manager", &baselinePoolManager{}},
+	{"pool_quota", &model.PoolQuota{}},
+	{"pool_allowance", &model.PoolAllowance{}},
+	{"sizing", &baselineSizing{}},
+	{"task", &model.Task{}},
+	{"task_item", &model.TaskItem{}},
+	{"power_schedule", &model.PowerSchedule{}},
+	{"node_state", &model.NodeState{}},
+	{"maintenance_window", &model.MaintenanceWindow{}},
+	{"usage_sample", &model.UsageSample{}},
+}
+
+// baselineUp - creating tables of baseline, existing tables from AutoMigrate are kept
+func baselineUp(tx *gorm.DB) error {
+	for _, table := range baselineTables {
+		if err := tx.Table(table.Name).AutoMigrate(table.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// baselineDown - dropping every tables of baseline
+func baselineDown(tx *gorm.DB) error {
+	for i := len(baselineTables) - 1; i >= 0; i-- {
+		if err := tx.Migrator().DropTable(baselineTables[i].Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolRelationsUp - moving pool's members and templates from text arrays into pool_member and pool_template
+// template which has no instance is dropped, since it could not be referenced
+func poolRelationsUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE TABLE pool_member (
+			pool_id bigint NOT NULL REFERENCES pool (id) ON DELETE CASCADE,
+			username text NOT NULL,
+			role text NOT NULL DEFAULT 'member',
+			joined_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (pool_id, username)
+		)`,
+		`CREATE INDEX idx_pool_member_username ON pool_member (username)`,
+		`CREATE TABLE pool_template (
+			pool_id bigint NOT NULL REFERENCES pool (id) ON DELETE CASCADE,
+			vmid text NOT NULL REFERENCES instance (vmid) ON DELETE CASCADE,
+			added_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (pool_id, vmid)
+		)`,
+		`CREATE INDEX idx_pool_template_vmid ON pool_template (vmid)`,
+		`INSERT INTO pool_member (pool_id, username)
+			SELECT pool.id, member.username FROM pool CROSS JOIN LATERAL unnest(pool.member) AS member (username)
+			WHERE member.username <> ''
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO pool_template (pool_id, vmid)
+			SELECT pool.id, template.vmid FROM pool CROSS JOIN LATERAL unnest(pool.vmid) AS template (vmid)
+			JOIN instance ON instance.vmid = template.vmid
+			ON CONFLICT DO NOTHING`,
+		`ALTER TABLE pool DROP COLUMN member, DROP COLUMN vmid`,
+	)
+}
+
+// poolRelationsDown - moving pool's members and templates back into text arrays, membership's metadata is lost
+func poolRelationsDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE pool ADD COLUMN vmid text[] NOT NULL DEFAULT '{}', ADD COLUMN member text[] NOT NULL DEFAULT '{}'`,
+		`UPDATE pool SET
+			member = COALESCE((SELECT array_agg(username ORDER BY joined_at, username) FROM pool_member WHERE pool_member.pool_id = pool.id), '{}'),
+			vmid = COALESCE((SELECT array_agg(vmid ORDER BY added_at, vmid) FROM pool_template WHERE pool_template.pool_id = pool.id), '{}')`,
+		`DROP TABLE pool_template`,
+		`DROP TABLE pool_member`,
+	)
+}
+
+// timestampTables - tables whose create_time, and expire_time when it has, were dates in text
+var timestampTables = []struct {
+	Name      string
+	HasExpiry bool
+}{
+	{"admin", true},
+	{"student", true},
+	{"faculty", true},
+	{"instance", true},
+	{"pool", true},
+	{"sizing", false},
+	{"pool_manager", false},
+}
+
+// quoteLiteral - quoting SQL's string literal, for statements which could not have parameters e.g. ALTER TABLE
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// timestampsUp - converting dates in text into timestamptz
+// dates are read in CAMPUS_TIMEZONE, create time at midnight and expire time at EXPIRY_HOUR
+// unparsable create time becomes now, unparsable or empty expire time becomes NULL which never expires
+func timestampsUp(tx *gorm.DB) error {
+	zone := quoteLiteral(config.Location().String())
+	hour := config.Get().ExpiryHour
+	if err := tx.Exec(`CREATE FUNCTION pg_temp.campus_time(value text, zone text, hour int) RETURNS timestamptz AS $$
+		BEGIN
+			IF value IS NULL OR btrim(value) = '' THEN
+				RETURN NULL;
+			END IF;
+			RETURN (value::date + make_interval(hours => hour))::timestamp AT TIME ZONE zone;
+		EXCEPTION WHEN others THEN
+			RETURN NULL;
+		END
+	$$ LANGUAGE plpgsql`).Error; err != nil {
+		return err
+	}
+	for _, table := range timestampTables {
+		alter := fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN create_time TYPE timestamptz USING COALESCE(pg_temp.campus_time(create_time, %s, 0), now())`, table.Name, zone)
+		if table.HasExpiry {
+			var unparsable int64
+			if err := tx.Raw(fmt.Sprintf(`SELECT count(*) FROM %s WHERE btrim(expire_time) <> '' AND pg_temp.campus_time(expire_time, %s, 0) IS NULL`, table.Name, zone)).Scan(&unparsable).Error; err != nil {
+				return err
+			}
+			if unparsable > 0 {
+				log.Printf("Warning: %d expire time in %s could not be parsed, they will never expire", unparsable, table.Name)
+			}
+			alter += fmt.Sprintf(`, ALTER COLUMN expire_time TYPE timestamptz USING pg_temp.campus_time(expire_time, %s, %d)`, zone, hour)
+		}
+		if err := tx.Exec(alter).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec(`DROP FUNCTION pg_temp.campus_time(text, text, int)`).Error
+}
+
+// timestampsDown - converting timestamptz back into dates in text of CAMPUS_TIMEZONE, time of day is lost
+func timestampsDown(tx *gorm.DB) error {
+	zone := quoteLiteral(config.Location().String())
+	for _, table := range timestampTables {
+		alter := fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN create_time TYPE text USING to_char(create_time AT TIME ZONE %s, 'YYYY-MM-DD')`, table.Name, zone)
+		if table.HasExpiry {
+			alter += fmt.Sprintf(`, ALTER COLUMN expire_time TYPE text USING COALESCE(to_char(expire_time AT TIME ZONE %s, 'YYYY-MM-DD'), '')`, zone)
+		}
+		if err := tx.Exec(alter).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolLifecycleUp - creating pool_lifecycle and notification
+// existing pools are not archived automatically, their owners have to enable it, since nothing happened at expiry before
+func poolLifecycleUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE TABLE pool_lifecycle (
+			pool_id bigint PRIMARY KEY REFERENCES pool (id) ON DELETE CASCADE,
+			auto_archive boolean NOT NULL DEFAULT true,
+			warn_days bigint NOT NULL DEFAULT 14,
+			stop_vms boolean NOT NULL DEFAULT true,
+			backup text NOT NULL DEFAULT 'backup',
+			backup_storage text NOT NULL DEFAULT '',
+			delete_vms boolean NOT NULL DEFAULT true,
+			templates text NOT NULL DEFAULT 'keep',
+			unenroll boolean NOT NULL DEFAULT true,
+			warned_at timestamptz,
+			archived_at timestamptz,
+			archive_task_id bigint NOT NULL DEFAULT 0
+		)`,
+		`INSERT INTO pool_lifecycle (pool_id, auto_archive) SELECT id, false FROM pool`,
+		`CREATE TABLE notification (
+			id bigserial PRIMARY KEY,
+			username text NOT NULL,
+			kind text NOT NULL,
+			subject text NOT NULL DEFAULT '',
+			message text NOT NULL DEFAULT '',
+			create_time timestamptz NOT NULL DEFAULT now(),
+			read_time timestamptz
+		)`,
+		`CREATE INDEX idx_notification_username ON notification (username)`,
+	)
+}
+
+// poolLifecycleDown - dropping pool_lifecycle and notification
+func poolLifecycleDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`DROP TABLE notification`,
+		`DROP TABLE pool_lifecycle`,
+	)
+}
+
+// poolInviteUp - creating pool_invite for pool's join codes
+func poolInviteUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE TABLE pool_invite (
+			id bigserial PRIMARY KEY,
+			pool_id bigint NOT NULL REFERENCES pool (id) ON DELETE CASCADE,
+			code text NOT NULL,
+			created_by text NOT NULL,
+			max_uses bigint NOT NULL DEFAULT 0,
+			uses bigint NOT NULL DEFAULT 0,
+			email_domains text[] NOT NULL DEFAULT '{}',
+			expire_time timestamptz,
+			revoke_time timestamptz,
+			create_time timestamptz NOT NULL DEFAULT now()
+		)`,
+		`CREATE UNIQUE INDEX idx_pool_invite_code ON pool_invite (code)`,
+		`CREATE INDEX idx_pool_invite_pool_id ON pool_invite (pool_id)`,
+	)
+}
+
+// poolInviteDown - dropping pool_invite
+func poolInviteDown(tx *gorm.DB) error {
+	return execAll(tx, `DROP TABLE pool_invite`)
+}
+
+// sizingCatalogUp - adding display's metadata, visibility and deprecation to sizing
+func sizingCatalogUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE sizing
+			ADD COLUMN description text NOT NULL DEFAULT '',
+			ADD COLUMN os text NOT NULL DEFAULT '',
+			ADD COLUMN icon text NOT NULL DEFAULT '',
+			ADD COLUMN tags text[] NOT NULL DEFAULT '{}',
+			ADD COLUMN visible_groups text[] NOT NULL DEFAULT '{}',
+			ADD COLUMN deprecated boolean NOT NULL DEFAULT false,
+			ADD COLUMN deprecate_time timestamptz,
+			ADD COLUMN update_time timestamptz NOT NULL DEFAULT now()`,
+	)
+}
+
+// sizingCatalogDown - dropping sizing's metadata, deprecated sizing templates become available again
+func sizingCatalogDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE sizing
+			DROP COLUMN description,
+			DROP COLUMN os,
+			DROP COLUMN icon,
+			DROP COLUMN tags,
+			DROP COLUMN visible_groups,
+			DROP COLUMN deprecated,
+			DROP COLUMN deprecate_time,
+			DROP COLUMN update_time`,
+	)
+}
+
+// imageFlavorUp - splitting sizing into image and flavor
+// every distinct spec of sizing becomes flavor which is default flavor of its images, sizing is renamed to image
+func imageFlavorUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE TABLE flavor (
+			id bigserial PRIMARY KEY,
+			name text NOT NULL,
+			cpu bigint NOT NULL,
+			ram decimal NOT NULL,
+			disk decimal NOT NULL,
+			description text NOT NULL DEFAULT '',
+			visible_groups text[] NOT NULL DEFAULT '{}',
+			deprecated boolean NOT NULL DEFAULT false,
+			deprecate_time timestamptz,
+			create_time timestamptz NOT NULL DEFAULT now(),
+			update_time timestamptz NOT NULL DEFAULT now()
+		)`,
+		`CREATE UNIQUE INDEX idx_flavor_name ON flavor (name)`,
+		`INSERT INTO flavor (name, cpu, ram, disk)
+			SELECT format('%s vCPU, %s GiB RAM, %s GiB disk', spec.cpu, spec.ram, spec.disk), spec.cpu, spec.ram, spec.disk
+			FROM (SELECT DISTINCT GREATEST(ceil(max_cpu), 1)::bigint AS cpu, max_ram AS ram, max_disk AS disk FROM sizing) AS spec
+			ORDER BY spec.cpu, spec.ram, spec.disk`,
+		`ALTER TABLE sizing RENAME TO image`,
+		`ALTER INDEX IF EXISTS sizing_pkey RENAME TO image_pkey`,
+		`ALTER TABLE image ADD COLUMN flavor_id bigint REFERENCES flavor (id) ON DELETE SET NULL`,
+		`UPDATE image SET flavor_id = flavor.id FROM flavor
+			WHERE flavor.cpu = GREATEST(ceil(image.max_cpu), 1) AND flavor.ram = image.max_ram AND flavor.disk = image.max_disk`,
+		`ALTER TABLE image DROP COLUMN max_cpu, DROP COLUMN max_ram, DROP COLUMN max_disk`,
+	)
+}
+
+// imageFlavorDown - merging image's default flavor back into sizing, image without default flavor gets zero spec
+func imageFlavorDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE image
+			ADD COLUMN max_cpu decimal NOT NULL DEFAULT 0,
+			ADD COLUMN max_ram decimal NOT NULL DEFAULT 0,
+			ADD COLUMN max_disk decimal NOT NULL DEFAULT 0`,
+		`UPDATE image SET max_cpu = flavor.cpu, max_ram = flavor.ram, max_disk = flavor.disk
+			FROM flavor WHERE flavor.id = image.flavor_id`,
+		`ALTER TABLE image DROP COLUMN flavor_id`,
+		`ALTER INDEX IF EXISTS image_pkey RENAME TO sizing_pkey`,
+		`ALTER TABLE image RENAME TO sizing`,
+		`DROP TABLE flavor`,
+	)
+}
+
+// linkedCloneUp - adding base template of linked clone and pool's default clone mode, existing VMs are full clones
+func linkedCloneUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE instance
+			ADD COLUMN base_vmid text NOT NULL DEFAULT '',
+			ADD COLUMN base_disk decimal NOT NULL DEFAULT 0`,
+		`CREATE INDEX idx_instance_base_vmid ON instance (base_vmid) WHERE base_vmid <> ''`,
+		`ALTER TABLE pool
+			ADD COLUMN clone_mode text NOT NULL DEFAULT 'full',
+			ADD COLUMN clone_storage text NOT NULL DEFAULT ''`,
+	)
+}
+
+// linkedCloneDown - dropping base template and pool's default clone mode, linked clones are charged as full clones
+func linkedCloneDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE pool DROP COLUMN clone_mode, DROP COLUMN clone_storage`,
+		`DROP INDEX idx_instance_base_vmid`,
+		`ALTER TABLE instance DROP COLUMN base_vmid, DROP COLUMN base_disk`,
+	)
+}
+
+// dataDiskUp - adding additional data disks of instance, existing VMs have only boot disk
+func dataDiskUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE instance ADD COLUMN data_disk decimal NOT NULL DEFAULT 0`,
+	)
+}
+
+// dataDiskDown - dropping additional data disks of instance, they are no longer charged to quota
+func dataDiskDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE instance DROP COLUMN data_disk`,
+	)
+}
+
+// lifecycleOptInUp - making pool's lifecycle non-destructive by default and adding it to pools which have none
+// pools created without lifecycle are never archived automatically, as if their owner had not opted in
+func lifecycleOptInUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE pool_lifecycle
+			ALTER COLUMN auto_archive SET DEFAULT false,
+			ALTER COLUMN delete_vms SET DEFAULT false,
+			ALTER COLUMN unenroll SET DEFAULT false`,
+		`INSERT INTO pool_lifecycle (pool_id) SELECT id FROM pool
+			WHERE NOT EXISTS (SELECT 1 FROM pool_lifecycle WHERE pool_lifecycle.pool_id = pool.id)`,
+	)
+}
+
+// lifecycleOptInDown - restoring destructive defaults of pool's lifecycle, existing lifecycles are kept
+func lifecycleOptInDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE pool_lifecycle
+			ALTER COLUMN auto_archive SET DEFAULT true,
+			ALTER COLUMN delete_vms SET DEFAULT true,
+			ALTER COLUMN unenroll SET DEFAULT true`,
+	)
+}
+
+// usageDailyUp - adding daily roll-up of usage samples, which samples older than retention are pruned into
+func usageDailyUp(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE TABLE usage_daily (
+			day timestamptz NOT NULL,
+			vmid text NOT NULL,
+			ownerid text NOT NULL,
+			pool_id bigint NOT NULL DEFAULT 0,
+			user_group text NOT NULL DEFAULT '',
+			samples bigint NOT NULL DEFAULT 0,
+			running_seconds double precision NOT NULL DEFAULT 0,
+			cpu_seconds double precision NOT NULL DEFAULT 0,
+			used_cpu_seconds double precision NOT NULL DEFAULT 0,
+			ram_byte_seconds double precision NOT NULL DEFAULT 0,
+			disk_byte_seconds double precision NOT NULL DEFAULT 0,
+			net_in bigint NOT NULL DEFAULT 0,
+			net_out bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, vmid, ownerid, pool_id, user_group)
+		)`,
+		`CREATE INDEX idx_usage_daily_ownerid ON usage_daily (ownerid)`,
+		`CREATE INDEX idx_usage_daily_pool_id ON usage_daily (pool_id)`,
+	)
+}
+
+// usageDailyDown - dropping daily roll-up, usage of pruned samples is lost
+func usageDailyDown(tx *gorm.DB) error {
+	return execAll(tx,
+		`DROP TABLE usage_daily`,
+	)
+}
+
+// execAll - executing SQL statements in order, stopping at first error
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm/clause"
+)
+
+// GetNodeStates - getting every node's states
+func GetNodeStates() ([]model.NodeState, error) {
+	var states []model.NodeState
+	if err := DB.Table("node_state").Order("node").Find(&states).Error; err != nil {
+		log.Println("Error: Could not get node's states due to", err)
+		return states, fmt.Errorf("error: unable to get node's states")
+	}
+	return states, nil
+}
+
+// GetNodeState - getting node's state from given node's name, uncordoned state is returned when not found
+func GetNodeState(node string) model.NodeState {
+	state := model.NodeState{Node: node}
+	DB.Table("node_state").Where("node = ?", node).Find(&state)
+	return state
+}
+
+// GetCordonedNodes - getting name of every cordoned nodes
+func GetCordonedNodes() []string {
+	var nodes []string
+	DB.Table("node_state").Select("node").Where("cordoned = ?", true).Find(&nodes)
+	return nodes
+}
+
+// SetNodeCordon - cordon or uncordon node by given node's name
+func SetNodeCordon(node string, cordoned bool, reason, updatedBy string) (model.NodeState, error) {
+	state := model.NodeState{
+		Node:       node,
+		Cordoned:   cordoned,
+		Reason:     reason,
+		UpdatedBy:  updatedBy,
+		UpdateTime: time.Now().UTC(),
+	}
+	if err := DB.Table("node_state").Clauses(clause.OnConflict{UpdateAll: true}).Create(&state).Error; err != nil {
+		log.Printf("Error: Could not set cordon of node : %s due to %s", node, err)
+		return state, fmt.Errorf("error: unable to set cordon of node : %s", node)
+	}
+	return state, nil
+}
+
+// GetMaintenanceWindows - getting every maintenance windows of given node's name
+func GetMaintenanceWindows(node string) ([]model.MaintenanceWindow, error) {
+	var windows []model.MaintenanceWindow
+	if err := DB.Table("maintenance_window").Where("node = ?", node).Order("start_time").Find(&windows).Error; err != nil {
+		log.Printf("Error: Could not get maintenance windows of node : %s due to %s", node, err)
+		return windows, fmt.Errorf("error: unable to get maintenance windows of node : %s", node)
+	}
+	return windows, nil
+}
+
+// GetUnfinishedMaintenanceWindows - getting every pending or running maintenance windows
+func GetUnfinishedMaintenanceWindows() ([]model.MaintenanceWindow, error) {
+	var windows []model.MaintenanceWindow
+	if err := DB.Table("maintenance_window").Where("status IN ?", []string{config.TASK_PENDING, config.TASK_RUNNING}).Order("start_time").Find(&windows).Error; err != nil {
+		log.Println("Error: Could not get unfinished maintenance windows due to", err)
+		return windows, fmt.Errorf("error: unable to get unfinished maintenance windows")
+	}
+	return windows, nil
+}
+
+// CreateMaintenanceWindow - create maintenance window of node
+func CreateMaintenanceWindow(window model.MaintenanceWindow) (model.MaintenanceWindow, error) {
+	window.Status = config.TASK_PENDING
+	window.CreateTime = time.Now().UTC()
+	if err := DB.Table("maintenance_window").Create(&window).Error; err != nil {
+		log.Printf("Error: Could not create maintenance window of node : %s due to %s", window.Node, err)
+		return window, fmt.Errorf("error: unable to create maintenance window of node : %s", window.Node)
+	}
+	return window, nil
+}
+
+// DeleteMaintenanceWindow - delete maintenance window from given ID and node's name
+func DeleteMaintenanceWindow(id uint64, node string) error {
+	result := DB.Table("maintenance_window").Where("id = ? AND node = ?", id, node).Delete(&model.MaintenanceWindow{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		log.Printf("Error: Could not delete maintenance window ID : %d of node : %s", id, node)
+		return fmt.Errorf("error: unable to delete maintenance window ID : %d", id)
+	}
+	return nil
+}
+
+// UpdateMaintenanceWindowStatus - update status and drain task's ID of maintenance window
+func UpdateMaintenanceWindowStatus(id uint64, status string, drainTaskID uint64) error {
+	update := map[string]interface{}{"status": status}
+	if drainTaskID != 0 {
+		update["drain_task_id"] = drainTaskID
+	}
+	if err := DB.Table("maintenance_window").Where("id = ?", id).Updates(update).Error; err != nil {
+		log.Printf("Error: Could not update maintenance window ID : %d due to %s", id, err)
+		return fmt.Errorf("error: unable to update maintenance window ID : %d", id)
+	}
+	return nil
+}
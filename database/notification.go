@@ -0,0 +1,54 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/model"
+)
+
+// CreateNotifications - sending same notification to every given usernames
+func CreateNotifications(usernames []string, kind, subject, message string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	notifications := make([]model.Notification, 0, len(usernames))
+	for _, username := range usernames {
+		notifications = append(notifications, model.Notification{Username: username, Kind: kind, Subject: subject, Message: message, CreateTime: now})
+	}
+	if err := DB.Table("notification").Create(&notifications).Error; err != nil {
+		log.Printf("Error: Could not create %s notifications due to %s", kind, err)
+		return fmt.Errorf("error: unable to create %s notifications due to %s", kind, err)
+	}
+	return nil
+}
+
+// GetNotifications - getting user's notifications, latest first
+func GetNotifications(username string, unreadOnly bool) ([]model.Notification, error) {
+	var notifications []model.Notification
+	query := DB.Table("notification").Where("username = ?", username)
+	if unreadOnly {
+		query = query.Where("read_time IS NULL")
+	}
+	if err := query.Order("create_time DESC, id DESC").Find(&notifications).Error; err != nil {
+		log.Println("Error: Could not get notifications of username :", username)
+		return notifications, fmt.Errorf("error: unable to get notifications of username : %s", username)
+	}
+	return notifications, nil
+}
+
+// ReadNotifications - mark user's notifications as read, every unread notifications when ids is empty
+func ReadNotifications(username string, ids []uint64) error {
+	query := DB.Table("notification").Where("username = ? AND read_time IS NULL", username)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if err := query.UpdateColumn("read_time", time.Now().UTC()).Error; err != nil {
+		log.Println("Error: Could not mark notifications as read of username :", username)
+		return fmt.Errorf("error: unable to mark notifications as read of username : %s", username)
+	}
+	return nil
+}
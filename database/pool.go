@@ -10,6 +10,7 @@ import (
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/model"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 // GetAllPools - getting all pools
@@ -20,7 +21,7 @@ func GetAllPools() ([]model.Pool, error) {
 		log.Println("Error: Could not get pools")
 		return pools, errors.New("error: unable to list pools")
 	}
-	return pools, nil
+	return pools, loadPoolRelations(pools)
 }
 
 // GetPoolsByOwner - getting all pools by given owner
@@ -31,18 +32,18 @@ func GetPoolsByOwner(owner string) ([]model.Pool, error) {
 		log.Printf("Error: Could not get pools by given owner : %s", owner)
 		return pools, fmt.Errorf("error: unable to list pools from given owner : %s", owner)
 	}
-	return pools, nil
+	return pools, loadPoolRelations(pools)
 }
 
 // GetPoolsByVMID - getting all pools by given VMID
 func GetPoolsByVMID(vmid string) ([]model.Pool, error) {
 	var pools []model.Pool
-	DB.Table("pool").Where("vmid @> ARRAY[?]::text[]", vmid).Find(&pools)
+	DB.Table("pool").Where("id IN (?)", DB.Table("pool_template").Select("pool_id").Where("vmid = ?", vmid)).Find(&pools)
 	if len(pools) == 0 {
 		log.Printf("Error: Could not get pools by given vmid : %s", vmid)
 		return pools, fmt.Errorf("error: unable to list pools from given vmid : %s", vmid)
 	}
-	return pools, nil
+	return pools, loadPoolRelations(pools)
 }
 
 // GetPoolByCode - getting pool by given course code, owner
@@ -52,18 +53,27 @@ func GetPoolByCode(code, owner string) (model.Pool, error) {
 		log.Printf("Error: Could not get pool by given owner : %s, code : %s", owner, code)
 		return pool, fmt.Errorf("error: unable to list pool from given owner : %s, code : %s", owner, code)
 	}
-	return pool, nil
+	return loadPoolRelation(pool)
+}
+
+// GetPoolByID - getting pool by given pool's ID
+func GetPoolByID(id uint64) (model.Pool, error) {
+	var pool model.Pool
+	if err := DB.Table("pool").Where("id = ?", id).Find(&pool).Error; err != nil || pool.ID == 0 {
+		log.Println("Error: Could not get pool by given ID :", id)
+		return pool, fmt.Errorf("error: unable to get pool from given ID : %d", id)
+	}
+	return loadPoolRelation(pool)
 }
 
 // GetAllPoolsByMember - getting all pools that user is member by given username
 func GetAllPoolsByMember(member string) ([]model.Pool, error) {
 	var pools []model.Pool
-	query := fmt.Sprintf("'%s' = ANY (\"member\")", member)
-	if err := DB.Table("pool").Where(query).Find(&pools).Error; err != nil {
+	if err := DB.Table("pool").Where("id IN (?)", DB.Table("pool_member").Select("pool_id").Where("username = ?", member)).Find(&pools).Error; err != nil {
 		log.Printf("Error: Could not get pools by given member's username : %s", member)
 		return pools, fmt.Errorf("error: unable to list pools from given member's username : %s", member)
 	}
-	return pools, nil
+	return pools, loadPoolRelations(pools)
 }
 
 // CreatePool - creating pool
@@ -72,13 +82,22 @@ func CreatePool(body *model.CreatePoolBody) (model.Pool, error) {
 		Owner:      body.Owner,
 		Code:       body.Code,
 		Name:       body.Name,
-		VMID:       []string{},
-		Member:     []string{},
+		VMID:       pq.StringArray{},
+		Member:     pq.StringArray{},
 		Status:     true,
-		CreateTime: time.Now().UTC().Format(config.TIME_FORMAT),
-		ExpireTime: time.Now().UTC().AddDate(0, 4, 0).Format(config.TIME_FORMAT),
-	}
-	if createErr := DB.Table("pool").Create(&newPool).Error; createErr != nil {
+		CloneMode:  config.CLONE_FULL,
+		CreateTime: time.Now().UTC(),
+		ExpireTime: config.ExpiryAfter(0, 4, 0),
+	}
+	// pool's lifecycle is created together, so its behavior never depends on a missing row
+	createErr := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool").Create(&newPool).Error; err != nil {
+			return err
+		}
+		lifecycle := DefaultPoolLifecycle(newPool.ID)
+		return tx.Table("pool_lifecycle").Create(&lifecycle).Error
+	})
+	if createErr != nil {
 		log.Println("Error: Could not create pool due to", createErr)
 		return model.Pool{}, fmt.Errorf("error: could not create pool due to %s", createErr)
 	}
@@ -86,6 +105,7 @@ func CreatePool(body *model.CreatePoolBody) (model.Pool, error) {
 }
 
 // DeletePool - delete pool from given code, owner
+// pool's members and templates are deleted by foreign key's cascade
 func DeletePool(code, owner string) error {
 	if err := DB.Table("pool").Where("code = ? AND owner = ?", code, owner).Delete(&model.Pool{}).Error; err != nil {
 		log.Println("Error: Could not delete pool due to", err)
@@ -103,31 +123,23 @@ func MarkPoolExpired(id uint64) error {
 	return nil
 }
 
-// AddPoolMembers - edit pool by given code, owner
-func AddPoolMembers(code, owner string, members pq.StringArray) error {
-	if err := DB.Model(&model.Instance{}).Table("pool").Where("code = ? AND owner = ?", code, owner).UpdateColumn("member", members).Error; err != nil {
-		log.Printf("Error: Could not update pool code : %s, owner : %s", code, owner)
-		return fmt.Errorf("error: unable to update pool code : %s, owner : %s", code, owner)
-	}
-	return nil
-}
-
-// AddPoolInstances - edit pool by given code, owner
-func AddPoolInstances(code, owner string, instances pq.StringArray) error {
-	if err := DB.Model(&model.Instance{}).Table("pool").Where("code = ? AND owner = ?", code, owner).UpdateColumn("vmid", instances).Error; err != nil {
-		log.Printf("Error: Could not update pool code : %s, owner : %s", code, owner)
-		return fmt.Errorf("error: unable to update pool code : %s, owner : %s", code, owner)
+// EditPoolClone - set default clone mode and storage of pool's templates by given ID
+func EditPoolClone(id uint64, mode, storage string) error {
+	if err := DB.Table("pool").Where("id = ?", id).Updates(map[string]interface{}{
+		"clone_mode":    mode,
+		"clone_storage": storage,
+	}).Error; err != nil {
+		log.Printf("Error: Could not edit clone mode of pool ID : %d due to %s", id, err)
+		return fmt.Errorf("error: unable to edit clone mode of pool ID : %d", id)
 	}
 	return nil
 }
 
 // IsPoolMember - check is given username a one of pool's member
 func IsPoolMember(code, owner, username string) bool {
-	pool, getPoolErr := GetPoolByCode(code, owner)
-	if getPoolErr != nil {
-		return false
-	}
-	if config.Contains(pool.Member, username) {
+	var count int64
+	DB.Table("pool_member").Where("username = ? AND pool_id IN (?)", username, DB.Table("pool").Select("id").Where("code = ? AND owner = ?", code, owner)).Count(&count)
+	if count > 0 {
 		log.Printf("Found user : %s in pool which owner : %s, code : %s", username, owner, code)
 		return true
 	}
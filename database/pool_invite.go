@@ -0,0 +1,135 @@
+// Package database - database's functions
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inviteCodeLength - length of pool's join code
+const inviteCodeLength = 8
+
+// ErrAlreadyMember - user who redeems join code has already been pool's member
+var ErrAlreadyMember = errors.New("error: user has already been member of pool")
+
+// normalizeDomain - lowercase email's domain without leading "@"
+func normalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+}
+
+// CreatePoolInvite - creating join code of pool by given pool's ID
+/*
+	@maxUses : 0 : unlimited
+	@domains : email's domains which are allowed to join, empty : every domains
+	@expireTime : nil : never expire
+*/
+func CreatePoolInvite(poolID uint64, createdBy string, maxUses uint64, domains []string, expireTime *time.Time) (model.PoolInvite, error) {
+	allowed := pq.StringArray{}
+	for _, domain := range domains {
+		if domain = normalizeDomain(domain); domain != "" && !config.Contains(allowed, domain) {
+			allowed = append(allowed, domain)
+		}
+	}
+	code, codeErr := config.RandomCode(inviteCodeLength)
+	if codeErr != nil {
+		return model.PoolInvite{}, fmt.Errorf("error: unable to generate join code due to %s", codeErr)
+	}
+	invite := model.PoolInvite{
+		PoolID:       poolID,
+		Code:         code,
+		CreatedBy:    createdBy,
+		MaxUses:      maxUses,
+		EmailDomains: allowed,
+		ExpireTime:   expireTime,
+		CreateTime:   time.Now().UTC(),
+	}
+	if err := DB.Table("pool_invite").Create(&invite).Error; err != nil {
+		log.Println("Error: Could not create join code of pool ID :", poolID)
+		return model.PoolInvite{}, fmt.Errorf("error: unable to create join code of pool ID : %d due to %s", poolID, err)
+	}
+	return invite, nil
+}
+
+// GetPoolInvites - getting every join codes of pool by given pool's ID, latest first
+func GetPoolInvites(poolID uint64) ([]model.PoolInvite, error) {
+	var invites []model.PoolInvite
+	if err := DB.Table("pool_invite").Where("pool_id = ?", poolID).Order("create_time DESC, id DESC").Find(&invites).Error; err != nil {
+		log.Println("Error: Could not get join codes of pool ID :", poolID)
+		return invites, fmt.Errorf("error: unable to get join codes of pool ID : %d", poolID)
+	}
+	return invites, nil
+}
+
+// RevokePoolInvite - revoking join code of pool by given pool's ID and join code's ID
+func RevokePoolInvite(poolID, id uint64) error {
+	result := DB.Table("pool_invite").Where("id = ? AND pool_id = ? AND revoke_time IS NULL", id, poolID).UpdateColumn("revoke_time", time.Now().UTC())
+	if result.Error != nil {
+		log.Printf("Error: Could not revoke join code ID : %d of pool ID : %d", id, poolID)
+		return fmt.Errorf("error: unable to revoke join code ID : %d of pool ID : %d due to %s", id, poolID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("error: join code ID : %d of pool ID : %d is not found or has already been revoked", id, poolID)
+	}
+	return nil
+}
+
+// RedeemPoolInvite - enrolling user into pool of given join code
+// join code is locked while it is redeemed, so max uses is never exceeded
+func RedeemPoolInvite(code, username, email string) (model.Pool, error) {
+	var pool model.Pool
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var invite model.PoolInvite
+		if err := tx.Table("pool_invite").Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).Find(&invite).Error; err != nil {
+			return err
+		}
+		if invite.ID == 0 {
+			return errors.New("error: join code is not found")
+		}
+		if invite.RevokeTime != nil {
+			return errors.New("error: join code has been revoked")
+		}
+		if invite.ExpireTime != nil && !time.Now().Before(*invite.ExpireTime) {
+			return errors.New("error: join code has been expired")
+		}
+		if invite.MaxUses != 0 && invite.Uses >= invite.MaxUses {
+			return errors.New("error: join code has been used up")
+		}
+		if len(invite.EmailDomains) > 0 {
+			at := strings.LastIndex(email, "@")
+			if at < 0 || !config.Contains(invite.EmailDomains, normalizeDomain(email[at+1:])) {
+				return fmt.Errorf("error: email's domain is not one of %s", strings.Join(invite.EmailDomains, ", "))
+			}
+		}
+		if err := tx.Table("pool").Where("id = ?", invite.PoolID).Find(&pool).Error; err != nil {
+			return err
+		}
+		if pool.ID == 0 || !pool.Status {
+			return errors.New("error: pool has been expired")
+		}
+		member := model.PoolMember{PoolID: pool.ID, Username: username, Role: config.POOL_MEMBER, JoinedAt: time.Now().UTC()}
+		created := tx.Table("pool_member").Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			return ErrAlreadyMember
+		}
+		return tx.Table("pool_invite").Where("id = ?", invite.ID).UpdateColumn("uses", gorm.Expr("uses + 1")).Error
+	})
+	if err != nil {
+		if !errors.Is(err, ErrAlreadyMember) {
+			log.Printf("Error: Could not redeem join code for username : %s due to %s", username, err)
+		}
+		return pool, err
+	}
+	return loadPoolRelation(pool)
+}
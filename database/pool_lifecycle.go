@@ -0,0 +1,150 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// DefaultPoolLifecycle - lifecycle of pool which has not been configured
+// nothing is archived or deleted until pool's owner opts in
+func DefaultPoolLifecycle(poolID uint64) model.PoolLifecycle {
+	return model.PoolLifecycle{
+		PoolID:      poolID,
+		AutoArchive: false,
+		WarnDays:    14,
+		StopVMs:     true,
+		Backup:      config.ARCHIVE_BACKUP,
+		DeleteVMs:   false,
+		Templates:   config.TEMPLATE_KEEP,
+		Unenroll:    false,
+	}
+}
+
+// GetPoolLifecycle - getting pool's lifecycle from given pool's ID, default lifecycle when it has not been configured
+func GetPoolLifecycle(poolID uint64) (model.PoolLifecycle, error) {
+	var lifecycles []model.PoolLifecycle
+	if err := DB.Table("pool_lifecycle").Where("pool_id = ?", poolID).Find(&lifecycles).Error; err != nil {
+		log.Println("Error: Could not get lifecycle of pool ID :", poolID)
+		return model.PoolLifecycle{}, fmt.Errorf("error: unable to get lifecycle of pool ID : %d", poolID)
+	}
+	if len(lifecycles) == 0 {
+		return DefaultPoolLifecycle(poolID), nil
+	}
+	return lifecycles[0], nil
+}
+
+// ValidatePoolLifecycle - check lifecycle's options are valid together
+func ValidatePoolLifecycle(lifecycle model.PoolLifecycle) error {
+	if lifecycle.WarnDays < 0 {
+		return fmt.Errorf("error: warn days : %d is negative", lifecycle.WarnDays)
+	}
+	if !config.Contains([]string{config.ARCHIVE_NONE, config.ARCHIVE_SNAPSHOT, config.ARCHIVE_BACKUP}, lifecycle.Backup) {
+		return fmt.Errorf("error: backup : %s is not none, snapshot or backup", lifecycle.Backup)
+	}
+	if lifecycle.Backup == config.ARCHIVE_SNAPSHOT && lifecycle.DeleteVMs {
+		return fmt.Errorf("error: snapshot is deleted together with VM, use backup or keep VMs")
+	}
+	if !config.Contains([]string{config.TEMPLATE_KEEP, config.TEMPLATE_ARCHIVE}, lifecycle.Templates) {
+		return fmt.Errorf("error: templates : %s is not keep or archive", lifecycle.Templates)
+	}
+	return nil
+}
+
+// EditPoolLifecycle - create or update pool's lifecycle by given pool's ID, archival's state is kept
+func EditPoolLifecycle(poolID uint64, body *model.PoolLifecycle) error {
+	if err := ValidatePoolLifecycle(*body); err != nil {
+		return err
+	}
+	current, getErr := GetPoolLifecycle(poolID)
+	if getErr != nil {
+		return getErr
+	}
+	lifecycle := *body
+	lifecycle.PoolID = poolID
+	lifecycle.WarnedAt, lifecycle.ArchivedAt, lifecycle.ArchiveTaskID = current.WarnedAt, current.ArchivedAt, current.ArchiveTaskID
+	if err := DB.Table("pool_lifecycle").Save(&lifecycle).Error; err != nil {
+		log.Printf("Error: Could not update lifecycle of pool ID : %d due to %s", poolID, err)
+		return fmt.Errorf("error: unable to update lifecycle of pool ID : %d", poolID)
+	}
+	return nil
+}
+
+// MarkPoolWarned - mark pool's members as warned about pool's expiry
+func MarkPoolWarned(poolID uint64) error {
+	lifecycle, getErr := GetPoolLifecycle(poolID)
+	if getErr != nil {
+		return getErr
+	}
+	now := time.Now().UTC()
+	lifecycle.WarnedAt = &now
+	if err := DB.Table("pool_lifecycle").Save(&lifecycle).Error; err != nil {
+		log.Printf("Error: Could not mark pool ID : %d as warned due to %s", poolID, err)
+		return fmt.Errorf("error: unable to mark pool ID : %d as warned", poolID)
+	}
+	return nil
+}
+
+// SetPoolArchiveTask - keep ID of pool's running archive task, which prevents pool from being archived twice
+func SetPoolArchiveTask(poolID, taskID uint64) error {
+	lifecycle, getErr := GetPoolLifecycle(poolID)
+	if getErr != nil {
+		return getErr
+	}
+	lifecycle.ArchiveTaskID = taskID
+	if err := DB.Table("pool_lifecycle").Save(&lifecycle).Error; err != nil {
+		log.Printf("Error: Could not set archive task of pool ID : %d due to %s", poolID, err)
+		return fmt.Errorf("error: unable to set archive task of pool ID : %d", poolID)
+	}
+	return nil
+}
+
+// MarkPoolArchived - mark pool as archived, pool is expired together
+func MarkPoolArchived(poolID uint64) error {
+	lifecycle, getErr := GetPoolLifecycle(poolID)
+	if getErr != nil {
+		return getErr
+	}
+	now := time.Now().UTC()
+	lifecycle.ArchivedAt = &now
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool_lifecycle").Save(&lifecycle).Error; err != nil {
+			return err
+		}
+		return tx.Table("pool").Where("id = ?", poolID).UpdateColumn("status", false).Error
+	})
+	if err != nil {
+		log.Printf("Error: Could not mark pool ID : %d as archived due to %s", poolID, err)
+		return fmt.Errorf("error: unable to mark pool ID : %d as archived", poolID)
+	}
+	return nil
+}
+
+// ExtendPool - moving pool's expire time later, pool is active again and members will be warned again
+// archived pool could not be extended, since its VMs might have been deleted
+func ExtendPool(poolID uint64, expireTime time.Time) error {
+	lifecycle, getErr := GetPoolLifecycle(poolID)
+	if getErr != nil {
+		return getErr
+	}
+	if lifecycle.ArchivedAt != nil {
+		return fmt.Errorf("error: pool ID : %d has been archived", poolID)
+	}
+	lifecycle.WarnedAt = nil
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool").Where("id = ?", poolID).Updates(map[string]interface{}{"expire_time": expireTime, "status": true}).Error; err != nil {
+			return err
+		}
+		return tx.Table("pool_lifecycle").Save(&lifecycle).Error
+	})
+	if err != nil {
+		log.Printf("Error: Could not extend pool ID : %d due to %s", poolID, err)
+		return fmt.Errorf("error: unable to extend pool ID : %d", poolID)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// GetPoolManagers - getting all managers of pool by given pool's ID
+func GetPoolManagers(poolID uint64) ([]model.PoolManager, error) {
+	var managers []model.PoolManager
+	if err := DB.Table("pool_manager").Where("pool_id = ?", poolID).Find(&managers).Error; err != nil {
+		log.Printf("Error: Could not get managers of pool ID : %d", poolID)
+		return managers, fmt.Errorf("error: unable to list managers of pool ID : %d", poolID)
+	}
+	return managers, nil
+}
+
+// GetPoolManager - getting pool's manager by given pool's ID, username
+func GetPoolManager(poolID uint64, username string) (model.PoolManager, error) {
+	var manager model.PoolManager
+	if err := DB.Table("pool_manager").Where("pool_id = ? AND username = ?", poolID, username).Find(&manager).Error; err != nil || manager.ID == 0 {
+		return manager, fmt.Errorf("error: unable to get manager : %s of pool ID : %d", username, poolID)
+	}
+	return manager, nil
+}
+
+// GetPoolsByManager - getting all pools that user is manager by given username
+func GetPoolsByManager(username string) ([]model.Pool, error) {
+	var pools []model.Pool
+	if err := DB.Table("pool").Where("id IN (?)", DB.Table("pool_manager").Select("pool_id").Where("username = ?", username)).Find(&pools).Error; err != nil {
+		log.Printf("Error: Could not get pools by given manager's username : %s", username)
+		return pools, fmt.Errorf("error: unable to list pools from given manager's username : %s", username)
+	}
+	return pools, loadPoolRelations(pools)
+}
+
+// AddPoolManager - add manager to pool by given pool's ID, username, role
+func AddPoolManager(poolID uint64, username, role string) (model.PoolManager, error) {
+	if role != config.POOL_CO_INSTRUCTOR && role != config.POOL_TA {
+		return model.PoolManager{}, fmt.Errorf("error: unable to add manager : %s due to role %s is invalid", username, role)
+	}
+	if _, err := GetPoolManager(poolID, username); err == nil {
+		return model.PoolManager{}, fmt.Errorf("error: unable to add manager : %s due to user is already manager of pool ID : %d", username, poolID)
+	}
+	newManager := model.PoolManager{
+		PoolID:     poolID,
+		Username:   username,
+		Role:       role,
+		CreateTime: time.Now().UTC(),
+	}
+	if createErr := DB.Table("pool_manager").Create(&newManager).Error; createErr != nil {
+		log.Println("Error: Could not add pool's manager due to", createErr)
+		return model.PoolManager{}, fmt.Errorf("error: could not add pool's manager due to %s", createErr)
+	}
+	return newManager, nil
+}
+
+// RemovePoolManager - remove manager from pool by given pool's ID, username
+func RemovePoolManager(poolID uint64, username string) error {
+	if err := DB.Table("pool_manager").Where("pool_id = ? AND username = ?", poolID, username).Delete(&model.PoolManager{}).Error; err != nil {
+		log.Println("Error: Could not remove pool's manager due to", err)
+		return fmt.Errorf("error: could not remove pool's manager due to %s", err)
+	}
+	return nil
+}
+
+// DeletePoolManagers - remove all managers of pool by given code, owner
+func DeletePoolManagers(code, owner string) error {
+	if err := DB.Table("pool_manager").Where("pool_id IN (?)", DB.Table("pool").Select("id").Where("code = ? AND owner = ?", code, owner)).Delete(&model.PoolManager{}).Error; err != nil {
+		log.Println("Error: Could not delete pool's managers due to", err)
+		return fmt.Errorf("error: could not delete pool's managers due to %s", err)
+	}
+	return nil
+}
+
+// GetPoolRole - getting role of given username in pool {owner, co-instructor, ta}, empty if user is not manager
+func GetPoolRole(pool model.Pool, username string) string {
+	if pool.Owner == username {
+		return config.POOL_OWNER
+	}
+	manager, err := GetPoolManager(pool.ID, username)
+	if err != nil {
+		return ""
+	}
+	return manager.Role
+}
+
+// IsPoolManager - check is given username a one of pool's owner, co-instructor or ta
+func IsPoolManager(code, owner, username, group string) bool {
+	pool, getPoolErr := GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return false
+	}
+	if group == config.ADMIN || GetPoolRole(pool, username) != "" {
+		log.Printf("Found user : %s is manager of pool which owner : %s, code : %s", username, owner, code)
+		return true
+	}
+	log.Printf("Not found user : %s is manager of pool which owner : %s, code : %s", username, owner, code)
+	return false
+}
+
+// TransferPoolOwner - transfer pool's ownership to new owner, previous owner will be kept as manager with given role
+func TransferPoolOwner(pool model.Pool, newOwner, previousRole string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool").Where("id = ?", pool.ID).UpdateColumn("owner", newOwner).Error; err != nil {
+			log.Printf("Error: Could not transfer pool ID : %d to %s due to %s", pool.ID, newOwner, err)
+			return fmt.Errorf("error: unable to transfer pool ID : %d due to %s", pool.ID, err)
+		}
+		if err := tx.Table("pool_manager").Where("pool_id = ? AND username IN ?", pool.ID, []string{newOwner, pool.Owner}).Delete(&model.PoolManager{}).Error; err != nil {
+			log.Printf("Error: Could not clean up managers of pool ID : %d due to %s", pool.ID, err)
+			return fmt.Errorf("error: unable to clean up managers of pool ID : %d due to %s", pool.ID, err)
+		}
+		if previousRole == "" {
+			return nil
+		}
+		previousOwner := model.PoolManager{
+			PoolID:     pool.ID,
+			Username:   pool.Owner,
+			Role:       previousRole,
+			CreateTime: time.Now().UTC(),
+		}
+		if err := tx.Table("pool_manager").Create(&previousOwner).Error; err != nil {
+			log.Printf("Error: Could not keep previous owner : %s of pool ID : %d due to %s", pool.Owner, pool.ID, err)
+			return fmt.Errorf("error: unable to keep previous owner of pool ID : %d due to %s", pool.ID, err)
+		}
+		return nil
+	})
+}
+
+// IsPoolInstructor - check is given username a one of pool's owner or co-instructor, TA is excluded
+func IsPoolInstructor(code, owner, username, group string) bool {
+	pool, getPoolErr := GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return false
+	}
+	if group == config.ADMIN || isInstructor(pool, username) {
+		log.Printf("Found user : %s is instructor of pool which owner : %s, code : %s", username, owner, code)
+		return true
+	}
+	log.Printf("Not found user : %s is instructor of pool which owner : %s, code : %s", username, owner, code)
+	return false
+}
+
+// isInstructor - check role of given username in pool is owner or co-instructor
+func isInstructor(pool model.Pool, username string) bool {
+	role := GetPoolRole(pool, username)
+	return role == config.POOL_OWNER || role == config.POOL_CO_INSTRUCTOR
+}
+
+// CheckInstanceOwnerOrManager - check that given username is owner of VMID or manager {owner, co-instructor, ta} of pool which VM is charged to
+// used for viewing VM, TA is allowed
+func CheckInstanceOwnerOrManager(username, vmid string) (bool, error) {
+	return checkInstanceOwnerOr(username, vmid, func(pool model.Pool) bool {
+		return GetPoolRole(pool, username) != ""
+	})
+}
+
+// CheckInstanceOwnerOrInstructor - check that given username is owner of VMID or instructor {owner, co-instructor} of pool which VM is charged to
+// used for acting on VM e.g. power actions, TA who is also student is not allowed
+func CheckInstanceOwnerOrInstructor(username, vmid string) (bool, error) {
+	return checkInstanceOwnerOr(username, vmid, func(pool model.Pool) bool {
+		return isInstructor(pool, username)
+	})
+}
+
+// checkInstanceOwnerOr - check that given username is owner of VMID or passes given check on pool which VM is charged to
+// personal VM (pool ID : 0) is only accessible by its owner
+func checkInstanceOwnerOr(username, vmid string, isManager func(model.Pool) bool) (bool, error) {
+	if owner, _ := CheckInstanceOwner(username, vmid); owner {
+		return true, nil
+	}
+	instance, getInstanceErr := GetInstance(vmid)
+	if getInstanceErr != nil {
+		return false, getInstanceErr
+	}
+	if instance.PoolID != 0 {
+		pool, getPoolErr := GetPoolByID(instance.PoolID)
+		if getPoolErr != nil {
+			return false, getPoolErr
+		}
+		if isManager(pool) {
+			log.Printf("Found user : %s is manager of pool code : %s which VMID : %s is charged to", username, pool.Code, vmid)
+			return true, nil
+		}
+	}
+	log.Printf("Error: user is not owner of VM or manager of VM's pool : %s", vmid)
+	return false, fmt.Errorf("user is not owner of the given VM or manager of VM's pool : %s", vmid)
+}
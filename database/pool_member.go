@@ -0,0 +1,121 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadPoolRelations - filling members and templates of given pools from pool_member and pool_template
+func loadPoolRelations(pools []model.Pool) error {
+	if len(pools) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(pools))
+	index := map[uint64]int{}
+	for i := range pools {
+		ids[i] = pools[i].ID
+		index[pools[i].ID] = i
+		pools[i].Member = pq.StringArray{}
+		pools[i].VMID = pq.StringArray{}
+	}
+	var members []model.PoolMember
+	if err := DB.Table("pool_member").Where("pool_id IN ?", ids).Order("joined_at, username").Find(&members).Error; err != nil {
+		log.Println("Error: Could not get pool's members due to", err)
+		return fmt.Errorf("error: unable to get pool's members due to %s", err)
+	}
+	for _, member := range members {
+		pool := &pools[index[member.PoolID]]
+		pool.Member = append(pool.Member, member.Username)
+	}
+	var templates []model.PoolTemplate
+	if err := DB.Table("pool_template").Where("pool_id IN ?", ids).Order("added_at, vmid").Find(&templates).Error; err != nil {
+		log.Println("Error: Could not get pool's templates due to", err)
+		return fmt.Errorf("error: unable to get pool's templates due to %s", err)
+	}
+	for _, template := range templates {
+		pool := &pools[index[template.PoolID]]
+		pool.VMID = append(pool.VMID, template.VMID)
+	}
+	return nil
+}
+
+// loadPoolRelation - filling members and templates of given pool
+func loadPoolRelation(pool model.Pool) (model.Pool, error) {
+	pools := []model.Pool{pool}
+	err := loadPoolRelations(pools)
+	return pools[0], err
+}
+
+// GetPoolMembers - getting pool's members with membership's metadata by given pool's ID
+func GetPoolMembers(poolID uint64) ([]model.PoolMember, error) {
+	var members []model.PoolMember
+	if err := DB.Table("pool_member").Where("pool_id = ?", poolID).Order("joined_at, username").Find(&members).Error; err != nil {
+		log.Println("Error: Could not get members of pool ID :", poolID)
+		return members, fmt.Errorf("error: unable to get members of pool ID : %d", poolID)
+	}
+	return members, nil
+}
+
+// AddPoolMembers - add members to pool by given pool's ID, existing members are kept as they are
+func AddPoolMembers(poolID uint64, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	members := make([]model.PoolMember, 0, len(usernames))
+	for _, username := range usernames {
+		members = append(members, model.PoolMember{PoolID: poolID, Username: username, Role: config.POOL_MEMBER, JoinedAt: now})
+	}
+	if err := DB.Table("pool_member").Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error; err != nil {
+		log.Println("Error: Could not add members to pool ID :", poolID)
+		return fmt.Errorf("error: unable to add members to pool ID : %d due to %s", poolID, err)
+	}
+	return nil
+}
+
+// RemovePoolMembers - remove members and their allowances from pool by given pool's ID
+// members's VMs which are charged to pool are kept
+func RemovePoolMembers(poolID uint64, usernames []string) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool_allowance").Where("pool_id = ? AND username IN ?", poolID, usernames).Delete(&model.PoolAllowance{}).Error; err != nil {
+			return err
+		}
+		return tx.Table("pool_member").Where("pool_id = ? AND username IN ?", poolID, usernames).Delete(&model.PoolMember{}).Error
+	})
+	if err != nil {
+		log.Println("Error: Could not remove members from pool ID :", poolID)
+		return fmt.Errorf("error: unable to remove members from pool ID : %d due to %s", poolID, err)
+	}
+	return nil
+}
+
+// AddPoolTemplate - add template to pool by given pool's ID, adding existing template does nothing
+func AddPoolTemplate(poolID uint64, vmid string) error {
+	template := model.PoolTemplate{PoolID: poolID, VMID: vmid, AddedAt: time.Now().UTC()}
+	if err := DB.Table("pool_template").Clauses(clause.OnConflict{DoNothing: true}).Create(&template).Error; err != nil {
+		log.Printf("Error: Could not add template VMID : %s to pool ID : %d", vmid, poolID)
+		return fmt.Errorf("error: unable to add template VMID : %s to pool ID : %d due to %s", vmid, poolID, err)
+	}
+	return nil
+}
+
+// RemovePoolTemplates - remove templates from pool by given pool's ID, every templates are removed when vmids is empty
+func RemovePoolTemplates(poolID uint64, vmids []string) error {
+	query := DB.Table("pool_template").Where("pool_id = ?", poolID)
+	if len(vmids) > 0 {
+		query = query.Where("vmid IN ?", vmids)
+	}
+	if err := query.Delete(&model.PoolTemplate{}).Error; err != nil {
+		log.Println("Error: Could not remove templates from pool ID :", poolID)
+		return fmt.Errorf("error: unable to remove templates from pool ID : %d due to %s", poolID, err)
+	}
+	return nil
+}
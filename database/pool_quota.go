@@ -0,0 +1,217 @@
+// Package database - database's functions
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// GetPoolQuota - getting pool's quota from given pool's ID
+func GetPoolQuota(poolID uint64) (model.PoolQuota, error) {
+	var quota model.PoolQuota
+	DB.Table("pool_quota").Where("pool_id = ?", poolID).Find(&quota)
+	if quota == (model.PoolQuota{}) {
+		return quota, fmt.Errorf("error: unable to get quota of pool ID : %d", poolID)
+	}
+	return quota, nil
+}
+
+// EditPoolQuota - create or update pool's quota by given pool's ID
+func EditPoolQuota(poolID uint64, body *model.EditInstanceLimit) error {
+	if body.MaxCPU <= 0 || body.MaxRAM <= 0 || body.MaxDisk <= 0 || body.MaxInstance <= 0 {
+		return fmt.Errorf("error: unable to update quota of pool ID : %d due to invalid quota", poolID)
+	}
+	quota := model.PoolQuota{
+		PoolID:      poolID,
+		MaxCPU:      body.MaxCPU,
+		MaxRAM:      body.MaxRAM,
+		MaxDisk:     body.MaxDisk,
+		MaxInstance: body.MaxInstance,
+	}
+	if err := DB.Table("pool_quota").Save(&quota).Error; err != nil {
+		log.Printf("Error: Could not update quota of pool ID : %d due to %s", poolID, err)
+		return fmt.Errorf("error: unable to update quota of pool ID : %d", poolID)
+	}
+	return nil
+}
+
+// DeletePoolQuota - delete pool's quota and member's allowances by given code, owner
+// instances which are charged to the pool become personal instances
+func DeletePoolQuota(code, owner string) error {
+	poolID := DB.Table("pool").Select("id").Where("code = ? AND owner = ?", code, owner)
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("instance").Where("pool_id IN (?)", poolID).UpdateColumn("pool_id", 0).Error; err != nil {
+			log.Println("Error: Could not release pool's instances due to", err)
+			return fmt.Errorf("error: could not release pool's instances due to %s", err)
+		}
+		if err := tx.Table("pool_quota").Where("pool_id IN (?)", poolID).Delete(&model.PoolQuota{}).Error; err != nil {
+			log.Println("Error: Could not delete pool's quota due to", err)
+			return fmt.Errorf("error: could not delete pool's quota due to %s", err)
+		}
+		if err := tx.Table("pool_allowance").Where("pool_id IN (?)", poolID).Delete(&model.PoolAllowance{}).Error; err != nil {
+			log.Println("Error: Could not delete pool's allowances due to", err)
+			return fmt.Errorf("error: could not delete pool's allowances due to %s", err)
+		}
+		return nil
+	})
+}
+
+// GetPoolUsage - sum spec of all instances which are charged to given pool's ID
+func GetPoolUsage(poolID uint64) (model.PoolUsage, error) {
+	var usage model.PoolUsage
+	if err := DB.Table("instance").
+		Select("COALESCE(SUM(max_cpu), 0) AS cpu, COALESCE(SUM(max_ram), 0) AS ram, COALESCE(SUM(max_disk + data_disk), 0) AS disk, COUNT(*) AS instance").
+		Where("pool_id = ?", poolID).Scan(&usage).Error; err != nil {
+		log.Printf("Error: Could not get usage of pool ID : %d due to %s", poolID, err)
+		return usage, fmt.Errorf("error: unable to get usage of pool ID : %d", poolID)
+	}
+	return usage, nil
+}
+
+// GetPoolMembersUsage - sum spec of instances which are charged to given pool's ID group by member
+func GetPoolMembersUsage(poolID uint64) ([]model.PoolUsage, error) {
+	var usages []model.PoolUsage
+	if err := DB.Table("instance").
+		Select("ownerid AS username, COALESCE(SUM(max_cpu), 0) AS cpu, COALESCE(SUM(max_ram), 0) AS ram, COALESCE(SUM(max_disk + data_disk), 0) AS disk, COUNT(*) AS instance").
+		Where("pool_id = ?", poolID).Group("ownerid").Scan(&usages).Error; err != nil {
+		log.Printf("Error: Could not get members's usage of pool ID : %d due to %s", poolID, err)
+		return usages, fmt.Errorf("error: unable to get members's usage of pool ID : %d", poolID)
+	}
+	return usages, nil
+}
+
+// GetPoolAllowances - getting all member's allowances of given pool's ID
+func GetPoolAllowances(poolID uint64) ([]model.PoolAllowance, error) {
+	var allowances []model.PoolAllowance
+	if err := DB.Table("pool_allowance").Where("pool_id = ?", poolID).Find(&allowances).Error; err != nil {
+		log.Printf("Error: Could not get allowances of pool ID : %d due to %s", poolID, err)
+		return allowances, fmt.Errorf("error: unable to get allowances of pool ID : %d", poolID)
+	}
+	return allowances, nil
+}
+
+// GetPoolAllowance - getting member's allowance of given pool's ID, username
+func GetPoolAllowance(poolID uint64, username string) (model.PoolAllowance, error) {
+	var allowance model.PoolAllowance
+	DB.Table("pool_allowance").Where("pool_id = ? AND username = ?", poolID, username).Find(&allowance)
+	if allowance == (model.PoolAllowance{}) {
+		return allowance, fmt.Errorf("error: unable to get allowance of username : %s in pool ID : %d", username, poolID)
+	}
+	return allowance, nil
+}
+
+// EvenPoolAllowances - split pool's quota equally to every given members
+func EvenPoolAllowances(quota model.PoolQuota, members []string) []model.PoolAllowance {
+	var allowances []model.PoolAllowance
+	if len(members) == 0 {
+		return allowances
+	}
+	count := float64(len(members))
+	for _, member := range members {
+		allowances = append(allowances, model.PoolAllowance{
+			PoolID:      quota.PoolID,
+			Username:    member,
+			MaxCPU:      quota.MaxCPU / count,
+			MaxRAM:      quota.MaxRAM / count,
+			MaxDisk:     quota.MaxDisk / count,
+			MaxInstance: uint64(math.Ceil(float64(quota.MaxInstance) / count)),
+		})
+	}
+	return allowances
+}
+
+// SetPoolAllowances - replace all member's allowances of given pool's ID
+func SetPoolAllowances(poolID uint64, allowances []model.PoolAllowance) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("pool_allowance").Where("pool_id = ?", poolID).Delete(&model.PoolAllowance{}).Error; err != nil {
+			log.Printf("Error: Could not clear allowances of pool ID : %d due to %s", poolID, err)
+			return fmt.Errorf("error: unable to clear allowances of pool ID : %d", poolID)
+		}
+		if len(allowances) == 0 {
+			return nil
+		}
+		for i := range allowances {
+			allowances[i].PoolID = poolID
+		}
+		if err := tx.Table("pool_allowance").Create(&allowances).Error; err != nil {
+			log.Printf("Error: Could not set allowances of pool ID : %d due to %s", poolID, err)
+			return fmt.Errorf("error: unable to set allowances of pool ID : %d", poolID)
+		}
+		return nil
+	})
+}
+
+// withinQuota - check that usage plus given amount of requested spec and instances does not exceed given limit
+// instances is 0 when spec is delta of existing instance being resized
+func withinQuota(maxCPU, maxRAM, maxDisk float64, maxInstance uint64, usage model.PoolUsage, spec model.VMSpec, count, instances uint64) bool {
+	return (instances == 0 || usage.Instance+instances <= maxInstance) &&
+		fitsLimit(usage.CPU, spec.CPU*float64(count), maxCPU) &&
+		fitsLimit(usage.RAM, config.BytetoGB(spec.Memory)*float64(count), maxRAM) &&
+		fitsLimit(usage.Disk, config.BytetoGB(spec.ChargedDisk())*float64(count), maxDisk)
+}
+
+// fitsLimit - check used + requested is within limit, dimension which is not requested always fits
+// so member who is already at one dimension's limit is still able to grow another
+func fitsLimit(used, requested, limit float64) bool {
+	return requested == 0 || used+requested <= limit
+}
+
+// CheckPoolQuota - check has pool's quota or member's allowance reached already? and return boolean
+// newInstance : false when vmSpec is delta of existing instance being resized, instance count is not checked
+func CheckPoolQuota(poolID uint64, username string, vmSpec model.VMSpec, newInstance bool) (bool, error) {
+	var instances uint64
+	if newInstance {
+		instances = 1
+	}
+	if quota, err := GetPoolQuota(poolID); err == nil {
+		usage, usageErr := GetPoolUsage(poolID)
+		if usageErr != nil {
+			return false, usageErr
+		}
+		log.Printf("pool quota = cpu : %f, ram : %f, disk : %f, instance : %d", quota.MaxCPU, quota.MaxRAM, quota.MaxDisk, quota.MaxInstance)
+		log.Printf("pool usage = cpu : %f, ram : %f, disk : %f, instance : %d", usage.CPU, usage.RAM, usage.Disk, usage.Instance)
+		if !withinQuota(quota.MaxCPU, quota.MaxRAM, quota.MaxDisk, quota.MaxInstance, usage, vmSpec, 1, instances) {
+			log.Printf("Error: Quota of pool ID : %d has reached", poolID)
+			return false, errors.New("error: pool's quota has reached")
+		}
+	}
+	if allowance, err := GetPoolAllowance(poolID, username); err == nil {
+		var usage model.PoolUsage
+		if usageErr := DB.Table("instance").
+			Select("COALESCE(SUM(max_cpu), 0) AS cpu, COALESCE(SUM(max_ram), 0) AS ram, COALESCE(SUM(max_disk + data_disk), 0) AS disk, COUNT(*) AS instance").
+			Where("pool_id = ? AND ownerid = ?", poolID, username).Scan(&usage).Error; usageErr != nil {
+			return false, fmt.Errorf("error: unable to get usage of username : %s in pool ID : %d", username, poolID)
+		}
+		if !withinQuota(allowance.MaxCPU, allowance.MaxRAM, allowance.MaxDisk, allowance.MaxInstance, usage, vmSpec, 1, instances) {
+			log.Printf("Error: Allowance of username : %s in pool ID : %d has reached", username, poolID)
+			return false, errors.New("error: member's allowance in pool has reached")
+		}
+	}
+	return true, nil
+}
+
+// CheckPoolQuotaForMembers - check that pool's quota and each member's allowance are enough for every given members to have a VM of given spec
+func CheckPoolQuotaForMembers(poolID uint64, members []string, vmSpec model.VMSpec) (bool, error) {
+	if quota, err := GetPoolQuota(poolID); err == nil {
+		usage, usageErr := GetPoolUsage(poolID)
+		if usageErr != nil {
+			return false, usageErr
+		}
+		if !withinQuota(quota.MaxCPU, quota.MaxRAM, quota.MaxDisk, quota.MaxInstance, usage, vmSpec, uint64(len(members)), uint64(len(members))) {
+			log.Printf("Error: Quota of pool ID : %d is not enough for %d members", poolID, len(members))
+			return false, fmt.Errorf("error: pool's quota is not enough for %d members", len(members))
+		}
+	}
+	for _, member := range members {
+		if _, err := CheckPoolQuota(poolID, member, vmSpec, true); err != nil {
+			return false, fmt.Errorf("error: member : %s %s", member, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/model"
+)
+
+// GetPowerSchedules - getting all power schedules of given pool's ID
+func GetPowerSchedules(poolID uint64) ([]model.PowerSchedule, error) {
+	var schedules []model.PowerSchedule
+	if err := DB.Table("power_schedule").Where("pool_id = ?", poolID).Order("id").Find(&schedules).Error; err != nil {
+		log.Printf("Error: Could not get power schedules of pool ID : %d due to %s", poolID, err)
+		return schedules, fmt.Errorf("error: unable to get power schedules of pool ID : %d", poolID)
+	}
+	return schedules, nil
+}
+
+// GetEnabledPowerSchedules - getting every enabled power schedules
+func GetEnabledPowerSchedules() ([]model.PowerSchedule, error) {
+	var schedules []model.PowerSchedule
+	if err := DB.Table("power_schedule").Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		log.Println("Error: Could not get enabled power schedules due to", err)
+		return schedules, fmt.Errorf("error: unable to get enabled power schedules")
+	}
+	return schedules, nil
+}
+
+// CreatePowerSchedule - create power schedule of given pool's ID
+func CreatePowerSchedule(schedule model.PowerSchedule) (model.PowerSchedule, error) {
+	schedule.Enabled = true
+	schedule.CreateTime = time.Now().UTC()
+	if err := DB.Table("power_schedule").Create(&schedule).Error; err != nil {
+		log.Printf("Error: Could not create power schedule of pool ID : %d due to %s", schedule.PoolID, err)
+		return schedule, fmt.Errorf("error: unable to create power schedule of pool ID : %d", schedule.PoolID)
+	}
+	return schedule, nil
+}
+
+// DeletePowerSchedule - delete power schedule from given ID and pool's ID
+func DeletePowerSchedule(id, poolID uint64) error {
+	result := DB.Table("power_schedule").Where("id = ? AND pool_id = ?", id, poolID).Delete(&model.PowerSchedule{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		log.Printf("Error: Could not delete power schedule ID : %d of pool ID : %d", id, poolID)
+		return fmt.Errorf("error: unable to delete power schedule ID : %d", id)
+	}
+	return nil
+}
+
+// DeletePowerSchedules - delete every power schedules of pool from given code, owner
+func DeletePowerSchedules(code, owner string) error {
+	poolID := DB.Table("pool").Select("id").Where("code = ? AND owner = ?", code, owner)
+	if err := DB.Table("power_schedule").Where("pool_id IN (?)", poolID).Delete(&model.PowerSchedule{}).Error; err != nil {
+		log.Println("Error: Could not delete pool's power schedules due to", err)
+		return fmt.Errorf("error: could not delete pool's power schedules due to %s", err)
+	}
+	return nil
+}
+
+// MarkPowerScheduleRun - save last run of power schedule, one-time schedule is disabled after run
+func MarkPowerScheduleRun(schedule model.PowerSchedule, runTime time.Time, taskID uint64) error {
+	update := map[string]interface{}{"last_run": runTime, "last_task_id": taskID}
+	if schedule.Cron == "" {
+		update["enabled"] = false
+	}
+	if err := DB.Table("power_schedule").Where("id = ?", schedule.ID).Updates(update).Error; err != nil {
+		log.Printf("Error: Could not mark power schedule ID : %d due to %s", schedule.ID, err)
+		return fmt.Errorf("error: unable to mark power schedule ID : %d", schedule.ID)
+	}
+	return nil
+}
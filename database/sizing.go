@@ -2,51 +2,65 @@
 package database
 
 import (
-	"errors"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/edu-cloud-api/model"
 )
 
-// GetAllTemplates - getting all instance templates
-func GetAllTemplates() ([]model.Sizing, error) {
-	var templates []model.Sizing
-	DB.Table("sizing").Find(&templates)
-	if len(templates) == 0 {
-		log.Println("Error: Could not get instance templates list")
-		return templates, errors.New("error: unable to list instance templates")
+// ToSizing - converting image into deprecated sizing template, spec is taken from image's default flavor
+func ToSizing(image model.Image) model.Sizing {
+	sizing := model.Sizing{
+		VMID:          image.VMID,
+		Node:          image.Node,
+		Name:          image.Name,
+		Description:   image.Description,
+		OS:            image.OS,
+		Icon:          image.Icon,
+		Tags:          image.Tags,
+		VisibleGroups: image.VisibleGroups,
+		Deprecated:    image.Deprecated,
+		DeprecateTime: image.DeprecateTime,
+		CreateTime:    image.CreateTime,
+		UpdateTime:    image.UpdateTime,
 	}
-	return templates, nil
-}
-
-// GetAllTemplatesID - getting all instance templates's ID
-func GetAllTemplatesID() ([]string, error) {
-	var templates []string
-	DB.Table("sizing").Select("vmid").Find(&templates)
-	if len(templates) == 0 {
-		log.Println("Error: Could not get instance templates's ID list")
-		return templates, errors.New("error: unable to list instances templates's ID")
+	if image.FlavorID == nil {
+		return sizing
 	}
-	return templates, nil
+	if flavor, err := GetFlavor(*image.FlavorID); err == nil {
+		sizing.MaxCPU, sizing.MaxRAM, sizing.MaxDisk = float64(flavor.CPU), flavor.RAM, flavor.Disk
+	}
+	return sizing
 }
 
-// GetTemplate - getting instance template from given vmid
-func GetTemplate(vmid string) (model.Sizing, error) {
-	var template model.Sizing
-	DB.Table("sizing").Where("vmid = ?", vmid).Find(&template)
-	if template == (model.Sizing{}) {
-		log.Println("Error: Could not get instance template id :", vmid)
-		return template, fmt.Errorf("error: unable to get instance template id : %s", vmid)
+// ToSizings - converting images into deprecated sizing templates
+func ToSizings(images []model.Image) []model.Sizing {
+	sizings := make([]model.Sizing, 0, len(images))
+	for _, image := range images {
+		sizings = append(sizings, ToSizing(image))
 	}
-	return template, nil
+	return sizings
 }
 
-// IsSizingTemplate - check vm's ID that are in templates preset
-func IsSizingTemplate(vmid string) (bool, error) {
-	_, getTemplateErr := GetTemplate(vmid)
-	if getTemplateErr != nil {
-		return false, getTemplateErr
+// FlavorOfSizing - getting flavor which has the same spec as given sizing template, creating it when there is none
+// cores are rounded up as splitting sizing into image and flavor did, deprecated flavor is not reused
+func FlavorOfSizing(maxCPU, maxRAM, maxDisk float64) (model.Flavor, error) {
+	cpu := uint64(math.Max(math.Ceil(maxCPU), 1))
+	var flavor model.Flavor
+	DB.Table("flavor").Where("cpu = ? AND ram = ? AND disk = ? AND NOT deprecated", cpu, maxRAM, maxDisk).Order("id").Limit(1).Find(&flavor)
+	if flavor.ID != 0 {
+		return flavor, nil
+	}
+	log.Printf("Creating flavor for sizing template's spec : {cpu: %d, ram: %v, disk: %v}", cpu, maxRAM, maxDisk)
+	created, createErr := CreateFlavor(model.Flavor{
+		Name: fmt.Sprintf("%d vCPU, %v GiB RAM, %v GiB disk", cpu, maxRAM, maxDisk),
+		CPU:  cpu,
+		RAM:  maxRAM,
+		Disk: maxDisk,
+	})
+	if createErr != nil {
+		return created, fmt.Errorf("error: unable to create flavor of sizing template due to %s", createErr)
 	}
-	return true, nil
+	return created, nil
 }
@@ -0,0 +1,140 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// CreateTask - creating new task with pending items from given targets
+func CreateTask(taskType, owner, target, params, requestID string, targets []string) (model.Task, error) {
+	now := time.Now().UTC()
+	newTask := model.Task{
+		Type:       taskType,
+		Owner:      owner,
+		Target:     target,
+		Status:     config.TASK_PENDING,
+		Total:      uint64(len(targets)),
+		Params:     params,
+		RequestID:  requestID,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("task").Create(&newTask).Error; err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+		var items []model.TaskItem
+		for _, item := range targets {
+			items = append(items, model.TaskItem{
+				TaskID:     newTask.ID,
+				Target:     item,
+				Status:     config.TASK_PENDING,
+				UpdateTime: now,
+			})
+		}
+		return tx.Table("task_item").Create(&items).Error
+	})
+	if err != nil {
+		log.Println("Error: Could not create task due to", err)
+		return model.Task{}, fmt.Errorf("error: could not create task due to %s", err)
+	}
+	return newTask, nil
+}
+
+// GetTask - getting task from given ID
+func GetTask(id uint64) (model.Task, error) {
+	var task model.Task
+	if err := DB.Table("task").Where("id = ?", id).Find(&task).Error; err != nil || task.ID == 0 {
+		log.Println("Error: Could not get task ID :", id)
+		return task, fmt.Errorf("error: unable to get task ID : %d", id)
+	}
+	return task, nil
+}
+
+// GetTaskItems - getting all items of given task's ID
+func GetTaskItems(taskID uint64) ([]model.TaskItem, error) {
+	var items []model.TaskItem
+	if err := DB.Table("task_item").Where("task_id = ?", taskID).Order("id").Find(&items).Error; err != nil {
+		log.Println("Error: Could not get items of task ID :", taskID)
+		return items, fmt.Errorf("error: unable to get items of task ID : %d", taskID)
+	}
+	return items, nil
+}
+
+// UpdateTaskItem - update item of task
+func UpdateTaskItem(item model.TaskItem) error {
+	item.UpdateTime = time.Now().UTC()
+	if err := DB.Table("task_item").Where("id = ?", item.ID).Select("*").Omit("id", "task_id", "target").Updates(&item).Error; err != nil {
+		log.Printf("Error: Could not update item ID : %d of task ID : %d due to %s", item.ID, item.TaskID, err)
+		return fmt.Errorf("error: unable to update item ID : %d of task ID : %d", item.ID, item.TaskID)
+	}
+	return nil
+}
+
+// UpdateTaskStatus - update task's status
+func UpdateTaskStatus(id uint64, status string) error {
+	if err := DB.Table("task").Where("id = ?", id).Updates(map[string]interface{}{"status": status, "update_time": time.Now().UTC()}).Error; err != nil {
+		log.Printf("Error: Could not update status of task ID : %d due to %s", id, err)
+		return fmt.Errorf("error: unable to update status of task ID : %d", id)
+	}
+	return nil
+}
+
+// RefreshTask - recount done, failed items of task and mark task as finished when every items have been finished
+func RefreshTask(id uint64) (model.Task, error) {
+	var done, failed, unfinished int64
+	DB.Table("task_item").Where("task_id = ? AND status = ?", id, config.TASK_SUCCESS).Count(&done)
+	DB.Table("task_item").Where("task_id = ? AND status = ?", id, config.TASK_FAILURE).Count(&failed)
+	DB.Table("task_item").Where("task_id = ? AND status IN ?", id, []string{config.TASK_PENDING, config.TASK_RUNNING}).Count(&unfinished)
+	update := map[string]interface{}{"done": done, "failed": failed, "update_time": time.Now().UTC()}
+	if unfinished == 0 {
+		update["status"] = config.TASK_SUCCESS
+		if failed > 0 {
+			update["status"] = config.TASK_FAILURE
+		}
+	}
+	if err := DB.Table("task").Where("id = ?", id).Updates(update).Error; err != nil {
+		log.Printf("Error: Could not refresh task ID : %d due to %s", id, err)
+		return model.Task{}, fmt.Errorf("error: unable to refresh task ID : %d", id)
+	}
+	return GetTask(id)
+}
+
+// ResetFailedTaskItems - mark every failed items of task as pending again for retrying
+func ResetFailedTaskItems(id uint64) ([]model.TaskItem, error) {
+	var items []model.TaskItem
+	if err := DB.Table("task_item").Where("task_id = ? AND status = ?", id, config.TASK_FAILURE).Find(&items).Error; err != nil {
+		return items, fmt.Errorf("error: unable to get failed items of task ID : %d", id)
+	}
+	if len(items) == 0 {
+		return items, fmt.Errorf("error: task ID : %d has no failed item", id)
+	}
+	if err := DB.Table("task_item").Where("task_id = ? AND status = ?", id, config.TASK_FAILURE).Updates(map[string]interface{}{"status": config.TASK_PENDING, "message": "", "update_time": time.Now().UTC()}).Error; err != nil {
+		return items, fmt.Errorf("error: unable to reset failed items of task ID : %d", id)
+	}
+	if err := UpdateTaskStatus(id, config.TASK_PENDING); err != nil {
+		return items, err
+	}
+	for i := range items {
+		items[i].Status = config.TASK_PENDING
+		items[i].Message = ""
+	}
+	return items, nil
+}
+
+// CountUnfinishedTaskItems - count pending and running items of every tasks
+func CountUnfinishedTaskItems() (int64, int64) {
+	var pending, running int64
+	DB.Table("task_item").Where("status = ?", config.TASK_PENDING).Count(&pending)
+	DB.Table("task_item").Where("status = ?", config.TASK_RUNNING).Count(&running)
+	return pending, running
+}
@@ -0,0 +1,123 @@
+// Package database - database's functions
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"gorm.io/gorm"
+)
+
+// usageKeys - column which usage rows are grouped by for each report
+var usageKeys = map[string]string{
+	"user":  "usage_row.ownerid",
+	"pool":  "COALESCE(pool.code || '/' || pool.owner, 'personal')",
+	"group": "usage_row.user_group",
+}
+
+// sampleRow - usage sample as seconds of allocated resources which it represents, the same columns as usage_daily
+const sampleRow = `vmid, ownerid, pool_id, user_group, sample_time AS sampled_at,
+	CASE WHEN status = 'running' THEN sample_interval ELSE 0 END::float8 AS running_seconds,
+	CASE WHEN status = 'running' THEN max_cpu * sample_interval ELSE 0 END::float8 AS cpu_seconds,
+	CASE WHEN status = 'running' THEN cpu * max_cpu * sample_interval ELSE 0 END::float8 AS used_cpu_seconds,
+	CASE WHEN status = 'running' THEN max_mem::float8 * sample_interval ELSE 0 END AS ram_byte_seconds,
+	max_disk::float8 * sample_interval AS disk_byte_seconds,
+	net_in, net_out`
+
+// usageRows - usage samples together with daily roll-up of samples which have been pruned
+const usageRows = `(SELECT ` + sampleRow + ` FROM usage_sample
+	UNION ALL
+	SELECT vmid, ownerid, pool_id, user_group, day AS sampled_at, running_seconds, cpu_seconds, used_cpu_seconds, ram_byte_seconds, disk_byte_seconds, net_in, net_out
+	FROM usage_daily) AS usage_row`
+
+// IsValidUsageKey - check is given report's key supported {user, pool, group}
+func IsValidUsageKey(by string) bool {
+	_, ok := usageKeys[by]
+	return ok
+}
+
+// CreateUsageSamples - insert usage samples of every VMs from one sampling
+func CreateUsageSamples(samples []model.UsageSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	if err := DB.Table("usage_sample").CreateInBatches(&samples, 500).Error; err != nil {
+		log.Println("Error: Could not create usage samples due to", err)
+		return fmt.Errorf("error: unable to create usage samples due to %s", err)
+	}
+	return nil
+}
+
+// GetUsageReports - roll-up usage samples in period by given key {user, pool, group}
+// samples which have been pruned into usage_daily are counted in whole campus's days, by day which they were sampled
+/*
+	poolID : only samples which are charged to given pool, 0 : every pools
+	ownerid : only samples which are owned by given username, empty : every users
+*/
+func GetUsageReports(by string, from, to time.Time, poolID uint64, ownerid string) ([]model.UsageReport, error) {
+	var reports []model.UsageReport
+	key, ok := usageKeys[by]
+	if !ok {
+		return reports, fmt.Errorf("error: report by : %s is invalid", by)
+	}
+	query := DB.Table(usageRows).
+		Select(fmt.Sprintf(`%s AS key,
+			COUNT(DISTINCT usage_row.vmid) AS vms,
+			COALESCE(SUM(usage_row.running_seconds), 0) / 3600.0 AS running_hours,
+			COALESCE(SUM(usage_row.cpu_seconds), 0) / 3600.0 AS cpu_hours,
+			COALESCE(SUM(usage_row.used_cpu_seconds), 0) / 3600.0 AS used_cpu_hours,
+			COALESCE(SUM(usage_row.ram_byte_seconds), 0) / 3600.0 / %d AS ram_gb_hours,
+			COALESCE(SUM(usage_row.disk_byte_seconds), 0) / 3600.0 / %d AS disk_gb_hours,
+			COALESCE(SUM(usage_row.net_in), 0) AS net_in,
+			COALESCE(SUM(usage_row.net_out), 0) AS net_out`, key, config.Gigabyte, config.Gigabyte)).
+		Joins("LEFT JOIN pool ON pool.id = usage_row.pool_id").
+		Where("usage_row.sampled_at >= ? AND usage_row.sampled_at < ?", from, to)
+	if poolID != 0 {
+		query = query.Where("usage_row.pool_id = ?", poolID)
+	}
+	if ownerid != "" {
+		query = query.Where("usage_row.ownerid = ?", ownerid)
+	}
+	if err := query.Group("1").Order("1").Scan(&reports).Error; err != nil {
+		log.Printf("Error: Could not get usage reports by %s due to %s", by, err)
+		return reports, fmt.Errorf("error: unable to get usage reports by %s", by)
+	}
+	return reports, nil
+}
+
+// PruneUsageSamples - rolling up usage samples before given time into usage_daily by day in given timezone, then deleting them
+// samples of a day which has been rolled up already (e.g. sampled late) are added to its roll-up
+func PruneUsageSamples(before time.Time, timezone string) (int64, error) {
+	var pruned int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO usage_daily (day, vmid, ownerid, pool_id, user_group, samples,
+				running_seconds, cpu_seconds, used_cpu_seconds, ram_byte_seconds, disk_byte_seconds, net_in, net_out)
+			SELECT date_trunc('day', sampled_at, ?), vmid, ownerid, pool_id, user_group, COUNT(*),
+				SUM(running_seconds), SUM(cpu_seconds), SUM(used_cpu_seconds), SUM(ram_byte_seconds), SUM(disk_byte_seconds), SUM(net_in), SUM(net_out)
+			FROM (SELECT `+sampleRow+` FROM usage_sample WHERE sample_time < ?) AS usage_row
+			GROUP BY 1, 2, 3, 4, 5
+			ON CONFLICT (day, vmid, ownerid, pool_id, user_group) DO UPDATE SET
+				samples = usage_daily.samples + EXCLUDED.samples,
+				running_seconds = usage_daily.running_seconds + EXCLUDED.running_seconds,
+				cpu_seconds = usage_daily.cpu_seconds + EXCLUDED.cpu_seconds,
+				used_cpu_seconds = usage_daily.used_cpu_seconds + EXCLUDED.used_cpu_seconds,
+				ram_byte_seconds = usage_daily.ram_byte_seconds + EXCLUDED.ram_byte_seconds,
+				disk_byte_seconds = usage_daily.disk_byte_seconds + EXCLUDED.disk_byte_seconds,
+				net_in = usage_daily.net_in + EXCLUDED.net_in,
+				net_out = usage_daily.net_out + EXCLUDED.net_out`, timezone, before).Error; err != nil {
+			log.Println("Error: Could not roll up usage samples due to", err)
+			return fmt.Errorf("error: unable to roll up usage samples due to %s", err)
+		}
+		result := tx.Table("usage_sample").Where("sample_time < ?", before).Delete(&model.UsageSample{})
+		if result.Error != nil {
+			log.Println("Error: Could not delete usage samples due to", result.Error)
+			return fmt.Errorf("error: unable to delete usage samples due to %s", result.Error)
+		}
+		pruned = result.RowsAffected
+		return nil
+	})
+	return pruned, err
+}
@@ -8,7 +8,10 @@ import (
 	"time"
 
 	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/internal/logging"
 	"github.com/edu-cloud-api/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // GetAllUsersByGroup - getting all users from given group
@@ -31,7 +34,8 @@ func GetUser(username, group string) (model.User, error) {
 		log.Printf("Error: Could not get %s username : %s", group, username)
 		return user, fmt.Errorf("error: unable to get %s username : %s", group, username)
 	}
-	log.Println("Got user from db :", user)
+	// user's row has password, only username is logged
+	logging.L().Debug("got user from db", zap.String("username", user.Username), zap.String("group", group))
 	return user, nil
 }
 
@@ -96,9 +100,10 @@ func CreateUserDB(body *model.CreateUserDB) (model.User, error) {
 		Username:   body.Username,
 		Password:   body.Password, // need to see best's approach to encrypt password
 		Name:       body.Name,
+		Email:      body.Email,
 		Status:     true,
-		CreateTime: time.Now().UTC().Format(config.TIME_FORMAT),
-		ExpireTime: time.Now().UTC().AddDate(4, 0, 0).Format(config.TIME_FORMAT),
+		CreateTime: time.Now().UTC(),
+		ExpireTime: config.ExpiryAfter(4, 0, 0),
 	}
 	if createErr := DB.Table(body.Group).Create(&newUser).Error; createErr != nil {
 		log.Println("Error: Could not create user due to", createErr)
@@ -108,8 +113,15 @@ func CreateUserDB(body *model.CreateUserDB) (model.User, error) {
 }
 
 // DeleteUserDB - delete user and user's instance limit by given username
+// user's memberships are deleted together, since users are in many tables and could not be referenced by foreign key
 func DeleteUserDB(username, group string) error {
-	if err := DB.Table(group).Where("username = ?", username).Delete(&model.User{}).Error; err != nil {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(group).Where("username = ?", username).Delete(&model.User{}).Error; err != nil {
+			return err
+		}
+		return deleteMemberships(tx, username)
+	})
+	if err != nil {
 		log.Println("Error: Could not delete user due to", err)
 		return fmt.Errorf("error: could not delete user due to %s", err)
 	}
@@ -117,14 +129,22 @@ func DeleteUserDB(username, group string) error {
 }
 
 // EditUser - edit user by given username, group
+// empty fields are kept, expire time is parsed by config.ParseTime
 func EditUser(username, group string, body *model.EditUserDB) error {
 	modifiedUser := model.User{
-		Username:   username,
-		Password:   body.Password, // need to see best's approach to encrypt password
-		Name:       body.Name,
-		Status:     body.Status,
-		CreateTime: time.Now().UTC().Format(config.TIME_FORMAT),
-		ExpireTime: body.ExpireTime,
+		Username: username,
+		Password: body.Password, // need to see best's approach to encrypt password
+		Name:     body.Name,
+		Email:    body.Email,
+		Status:   body.Status,
+	}
+	if body.ExpireTime != "" {
+		expireTime, parseErr := config.ParseTime(body.ExpireTime)
+		if parseErr != nil {
+			log.Printf("Error: Could not parse expire time of username : %s", username)
+			return fmt.Errorf("error: unable to update username : %s due to %s", username, parseErr)
+		}
+		modifiedUser.ExpireTime = &expireTime
 	}
 	if err := DB.Model(&model.User{}).Table(group).Where("username = ?", username).Updates(&modifiedUser).Error; err != nil {
 		log.Println("Error: Could not update username :", username)
@@ -141,3 +161,93 @@ func MarkUserExpired(username, group string) error {
 	}
 	return nil
 }
+
+// SetUserStatus - set user's status by given username, group
+func SetUserStatus(username, group string, status bool) error {
+	if err := DB.Model(&model.User{}).Table(group).Where("username = ?", username).UpdateColumn("status", status).Error; err != nil {
+		log.Printf("Error: Could not set status of username : %s due to %s", username, err)
+		return fmt.Errorf("error: unable to set status of username : %s", username)
+	}
+	return nil
+}
+
+// GetUserMemberships - getting user's memberships, allowances and managing roles in every pools
+func GetUserMemberships(username string) (model.UserMemberships, error) {
+	var memberships model.UserMemberships
+	if err := DB.Table("pool_member").Where("username = ?", username).Find(&memberships.Members).Error; err != nil {
+		return memberships, fmt.Errorf("error: unable to get memberships of username : %s", username)
+	}
+	if err := DB.Table("pool_allowance").Where("username = ?", username).Find(&memberships.Allowances).Error; err != nil {
+		return memberships, fmt.Errorf("error: unable to get allowances of username : %s", username)
+	}
+	if err := DB.Table("pool_manager").Where("username = ?", username).Find(&memberships.Managers).Error; err != nil {
+		return memberships, fmt.Errorf("error: unable to get managing roles of username : %s", username)
+	}
+	return memberships, nil
+}
+
+// deleteMemberships - delete user's memberships, allowances and managing roles in every pools
+// users are in many tables and could not be referenced by foreign key
+func deleteMemberships(tx *gorm.DB, username string) error {
+	if err := tx.Table("pool_member").Where("username = ?", username).Delete(&model.PoolMember{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Table("pool_allowance").Where("username = ?", username).Delete(&model.PoolAllowance{}).Error; err != nil {
+		return err
+	}
+	return tx.Table("pool_manager").Where("username = ?", username).Delete(&model.PoolManager{}).Error
+}
+
+// DeleteUserWithLimit - delete user, user's instance limit and memberships by given username, group in one transaction
+func DeleteUserWithLimit(username, group string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(group).Where("username = ?", username).Delete(&model.User{}).Error; err != nil {
+			log.Println("Error: Could not delete user due to", err)
+			return fmt.Errorf("error: could not delete user due to %s", err)
+		}
+		if err := tx.Table("instance_limit").Where("username = ?", username).Delete(&model.InstanceLimit{}).Error; err != nil {
+			log.Println("Error: Could not delete user's instance limit due to", err)
+			return fmt.Errorf("error: could not delete user's instance limit due to %s", err)
+		}
+		if err := deleteMemberships(tx, username); err != nil {
+			log.Println("Error: Could not delete user's memberships due to", err)
+			return fmt.Errorf("error: could not delete user's memberships due to %s", err)
+		}
+		return nil
+	})
+}
+
+// RestoreUser - insert user, user's instance limit and memberships back which were deleted by DeleteUserWithLimit
+func RestoreUser(user model.User, group string, limit model.InstanceLimit, memberships model.UserMemberships) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(group).Create(&user).Error; err != nil {
+			log.Println("Error: Could not restore user due to", err)
+			return fmt.Errorf("error: could not restore user due to %s", err)
+		}
+		if limit != (model.InstanceLimit{}) {
+			if err := tx.Table("instance_limit").Create(&limit).Error; err != nil {
+				log.Println("Error: Could not restore user's instance limit due to", err)
+				return fmt.Errorf("error: could not restore user's instance limit due to %s", err)
+			}
+		}
+		if len(memberships.Members) > 0 {
+			if err := tx.Table("pool_member").Create(&memberships.Members).Error; err != nil {
+				log.Println("Error: Could not restore user's memberships due to", err)
+				return fmt.Errorf("error: could not restore user's memberships due to %s", err)
+			}
+		}
+		if len(memberships.Allowances) > 0 {
+			if err := tx.Table("pool_allowance").Create(&memberships.Allowances).Error; err != nil {
+				log.Println("Error: Could not restore user's allowances due to", err)
+				return fmt.Errorf("error: could not restore user's allowances due to %s", err)
+			}
+		}
+		if len(memberships.Managers) > 0 {
+			if err := tx.Table("pool_manager").Create(&memberships.Managers).Error; err != nil {
+				log.Println("Error: Could not restore user's managing roles due to", err)
+				return fmt.Errorf("error: could not restore user's managing roles due to %s", err)
+			}
+		}
+		return nil
+	})
+}
@@ -9,10 +9,12 @@ import (
 	"time"
 
 	"github.com/edu-cloud-api/config"
-	"github.com/edu-cloud-api/database"
 	"github.com/edu-cloud-api/internal/access"
+	"github.com/edu-cloud-api/internal/lifecycle"
+	"github.com/edu-cloud-api/internal/logging"
 	"github.com/edu-cloud-api/model"
 	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
 )
 
 // GetTicket - handler GetTicket function
@@ -37,10 +39,10 @@ func GetTicket(c *fiber.Ctx) error {
 	data.Set("realm", "pve")
 
 	// Getting Ticket
-	log.Printf("Getting ticket from user : %s", body.Username)
+	logging.Ctx(c).Info("getting ticket", zap.String("username", body.Username))
 	ticket, ticketErr := access.GetTicket(data)
 	if ticketErr != nil {
-		log.Println("Error: Could not get ticket :", ticketErr)
+		logging.Ctx(c).Error("could not get ticket", zap.String("username", body.Username), zap.Error(ticketErr))
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting ticket from user : %s due to %s", body.Username, ticketErr)})
 	}
 
@@ -63,37 +65,36 @@ func GetTicket(c *fiber.Ctx) error {
 		CSRFPreventionToken: ticket.Token.CSRFPreventionToken,
 	}
 
-	log.Printf("Finished getting ticket by user : %s", body.Username)
+	logging.Ctx(c).Info("finished getting ticket", zap.String("username", body.Username))
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": response})
 }
 
-// CreateUser - Create new user in Proxmox
+// CreateUser - Create new user in Proxmox, DB and default user's limit, same as CreateUserDB
+// user used to be created in Proxmox only, leaving DB and Proxmox out of sync, ! deprecated by /user/create
 // POST /api2/json/access/users
 /*
+	using Query
+	@username : sender, only admin
+
 	using Request's Body
 	@userid
-	@groups
-	@expire : set default to 4 years
+	@password
+	@groups : one of {student, faculty, admin}
 */
 func CreateUser(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
 	// Getting request's body
 	body := new(model.CreateUserBody)
 	if err := c.BodyParser(body); err != nil {
 		log.Println("Error: Could not parse body parser to creating user's body")
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to creating user's body"})
 	}
-	userid := fmt.Sprintf("%s%s", body.UserID, config.REALM)
 
-	// Mapping values
-	data := url.Values{}
-	data.Set("userid", userid)
-	data.Set("password", body.Password)
-	data.Set("groups", body.Groups)
-
-	// Creating User
+	// Creating User in Proxmox, DB and User's limit
 	log.Printf("Creating user : %s", body.UserID)
-	_, createErr := access.CreateUser(data)
-	if createErr != nil {
+	if createErr := lifecycle.CreateUser(&model.CreateUserDB{Username: body.UserID, Password: body.Password, Group: body.Groups}); createErr != nil {
 		log.Println("Error: Could not create user :", createErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed creating user : %s due to %s", body.UserID, createErr)})
 	}
@@ -137,7 +138,7 @@ func UpdateUser(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Updating user %s successfully", username)})
 }
 
-// DeleteUser - Delete user in Proxmox
+// DeleteUser - Delete user in Proxmox and DB
 // DELETE /api2/json/access/users/{userid}
 /*
 	using Params
@@ -147,38 +148,12 @@ func DeleteUser(c *fiber.Ctx) error {
 	// Getting params from URL
 	username := c.Params("username")
 	cookies := config.GetCookies(c)
-	userid := fmt.Sprintf("%s%s", username, config.REALM)
-	deleteURL := config.GetURL(fmt.Sprintf("/api2/json/access/users/%s", userid))
-
-	// Getting user's group
-	group, getGroupErr := database.GetUserGroup(username)
-	if getGroupErr != nil {
-		log.Println("Error: Could not get user's group due to :", getGroupErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting user's group due to %s", getGroupErr)})
-	}
 
-	// Deleting User in Proxmox
+	// Deleting User, User's limit in DB and Proxmox
 	log.Printf("Deleting user : %s", username)
-	_, deleteErr := access.DeleteUser(deleteURL, cookies)
-	if deleteErr != nil {
+	if deleteErr := lifecycle.DeleteUser(username, &cookies); deleteErr != nil {
 		log.Println("Error: Could not delete user :", deleteErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting user : %s due to %s", username, deleteErr)})
 	}
-
-	// Deleting User in DB
-	log.Printf("Deleting user : %s", username)
-	err := database.DeleteUserDB(username, group)
-	if err != nil {
-		log.Println("Error: Could not delete user in DB due to :", err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting user : %s due to %s", username, err)})
-	}
-
-	// Deleting instance limit
-	log.Printf("Deleting user's instance limit : %s", username)
-	deleteLimitErr := database.DeleteInstanceLimit(username)
-	if deleteLimitErr != nil {
-		log.Println("Error: Could not delete user's instance limit in DB due to :", deleteLimitErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting user's instance limit : %s due to %s", username, deleteLimitErr)})
-	}
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Deleting user %s successfully", username)})
 }
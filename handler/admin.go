@@ -0,0 +1,91 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/reconcile"
+	"github.com/gofiber/fiber/v2"
+)
+
+// isAdmin - check is sender admin, return error response when sender is not admin
+func isAdmin(c *fiber.Ctx) (bool, error) {
+	sender := c.Query("username")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return false, c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if group != config.ADMIN {
+		log.Println("Error: user's group is not allowed to access admin's endpoint")
+		return false, c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed due to user's group is not allowed"})
+	}
+	return true, nil
+}
+
+// ReconcileUsers - Report users which exist only in DB or Proxmox and optionally fix them
+/*
+	using Query
+	@username : sender
+	@fix : true for fixing differences
+*/
+func ReconcileUsers(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	report, reconcileErr := reconcile.Users(c.QueryBool("fix", false))
+	if reconcileErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed reconciling users due to %s", reconcileErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": report})
+}
+
+// ReconcileInstances - Report orphan VMs, ghost instances and node or spec mismatches and optionally fix them
+/*
+	using Query
+	@username : sender
+	@fix : true for deleting ghost instances and updating mismatched instances
+*/
+func ReconcileInstances(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	report, reconcileErr := reconcile.Instances(c.QueryBool("fix", false))
+	if reconcileErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed reconciling instances due to %s", reconcileErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": report})
+}
+
+// ReloadSettings - Reload non-critical settings (e.g. default limits, network's bridge, timeouts) from environment variables and .env file
+/*
+	using Query
+	@username : sender, only admin
+*/
+func ReloadSettings(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	settings, reloadErr := config.Reload()
+	if reloadErr != nil {
+		log.Println("Error: reloading settings due to", reloadErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed reloading settings due to %s", reloadErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{
+		"log_level":          settings.LogLevel,
+		"limits":             settings.Limits,
+		"network_bridge":     settings.NetworkBridge,
+		"iso_storage":        settings.ISOStorage,
+		"node_selector":      settings.NodeSelector.String(),
+		"proxmox_timeout":    settings.ProxmoxTimeout.String(),
+		"power_timeout":      settings.PowerTimeout.String(),
+		"clone_timeout":      settings.CloneTimeout.String(),
+		"migrate_timeout":    settings.MigrateTimeout.String(),
+		"cordoned_nodes":     settings.CordonedNodes,
+		"reconcile_auto_fix": settings.ReconcileAutoFix,
+	}})
+}
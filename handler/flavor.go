@@ -0,0 +1,190 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// flavorFromBody - validating body and converting it into flavor
+func flavorFromBody(body *model.FlavorBody) (model.Flavor, error) {
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return model.Flavor{}, fmt.Errorf("error: flavor's name is empty")
+	}
+	if body.CPU == 0 || body.RAM <= 0 || body.Disk <= 0 {
+		return model.Flavor{}, fmt.Errorf("error: cpu, ram and disk must be positive")
+	}
+	groups, groupsErr := visibleGroups(body.VisibleGroups)
+	if groupsErr != nil {
+		return model.Flavor{}, groupsErr
+	}
+	return model.Flavor{
+		Name:          name,
+		CPU:           body.CPU,
+		RAM:           body.RAM,
+		Disk:          body.Disk,
+		Description:   body.Description,
+		VisibleGroups: groups,
+		Deprecated:    body.Deprecated,
+	}, nil
+}
+
+// GetFlavorCatalog - Get flavors which sender is able to clone with
+/*
+	using Query
+	@username : sender
+*/
+func GetFlavorCatalog(c *fiber.Ctx) error {
+	group, getGroupErr := database.GetUserGroup(c.Query("username"))
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	catalog, getCatalogErr := database.GetFlavorCatalog(group)
+	if getCatalogErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting flavors due to %s", getCatalogErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": catalog})
+}
+
+// GetFlavorsDB - Get every flavors including deprecated one
+/*
+	using Query
+	@username : sender, only admin
+*/
+func GetFlavorsDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	flavors, getFlavorsErr := database.GetFlavors()
+	if getFlavorsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting flavors due to %s", getFlavorsErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": flavors})
+}
+
+// GetFlavorDB - Get flavor
+/*
+	using Params
+	@id : flavor's ID
+
+	using Query
+	@username : sender, only admin
+*/
+func GetFlavorDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting flavor due to flavor's ID : %s is invalid", c.Params("id"))})
+	}
+	flavor, getFlavorErr := database.GetFlavor(id)
+	if getFlavorErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting flavor due to %s", getFlavorErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": flavor})
+}
+
+// CreateFlavorDB - Create flavor which cloned VMs are configured to
+/*
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	@name : unique
+	@cpu : cores
+	@ram : in GiB
+	@disk : in GiB
+	@description
+	@visible_groups : {student, faculty, admin}, empty : every groups
+	@deprecated
+*/
+func CreateFlavorDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.FlavorBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to create flavor's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to create flavor's body"})
+	}
+	flavor, validateErr := flavorFromBody(body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating flavor due to %s", validateErr)})
+	}
+	created, createErr := database.CreateFlavor(flavor)
+	if createErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating flavor due to %s", createErr)})
+	}
+	log.Printf("Created flavor ID : %d, name : %s", created.ID, created.Name)
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "Success", "message": created})
+}
+
+// UpdateFlavorDB - Replace flavor's spec and metadata, VMs which have been cloned keep their spec
+/*
+	using Params
+	@id : flavor's ID
+
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	same as CreateFlavorDB
+*/
+func UpdateFlavorDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.FlavorBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit flavor's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit flavor's body"})
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing flavor due to flavor's ID : %s is invalid", c.Params("id"))})
+	}
+	flavor, validateErr := flavorFromBody(body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing flavor due to %s", validateErr)})
+	}
+	flavor.ID = id
+	edited, editErr := database.EditFlavor(flavor)
+	if editErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing flavor due to %s", editErr)})
+	}
+	log.Printf("Edited flavor ID : %d", id)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": edited})
+}
+
+// DeleteFlavorDB - Remove deprecated flavor, VMs which have been cloned keep their spec
+/*
+	using Params
+	@id : flavor's ID
+
+	using Query
+	@username : sender, only admin
+*/
+func DeleteFlavorDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting flavor due to flavor's ID : %s is invalid", c.Params("id"))})
+	}
+	if deleteErr := database.DeleteFlavor(id); deleteErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting flavor due to %s", deleteErr)})
+	}
+	log.Printf("Deleted flavor ID : %d", id)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Deleting flavor ID : %d successfully", id)})
+}
@@ -0,0 +1,229 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+// visibleGroups - validating visible groups of catalog's entry and removing duplicates
+func visibleGroups(input []string) (pq.StringArray, error) {
+	groups := pq.StringArray{}
+	for _, group := range input {
+		if group != config.STUDENT && group != config.FACULTY && group != config.ADMIN {
+			return groups, fmt.Errorf("error: visible group : %s is not student, faculty or admin", group)
+		}
+		if !config.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// imageFromBody - validating body against Proxmox's template of given VMID and converting it into image
+func imageFromBody(vmid string, body *model.ImageBody) (model.Image, error) {
+	tags := pq.StringArray{}
+	for _, tag := range body.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !config.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	groups, groupsErr := visibleGroups(body.VisibleGroups)
+	if groupsErr != nil {
+		return model.Image{}, groupsErr
+	}
+	resources, getResourcesErr := qemu.GetResourcesUsingToken()
+	if getResourcesErr != nil {
+		return model.Image{}, fmt.Errorf("error: unable to get VMs from cluster due to %s", getResourcesErr)
+	}
+	for _, resource := range resources {
+		if fmt.Sprint(resource.VMID) != vmid {
+			continue
+		}
+		if resource.Template != 1 {
+			return model.Image{}, fmt.Errorf("error: VMID : %s is not template", vmid)
+		}
+		// cloned VM's disk is grown from template's disk, which could not be shrunk
+		if body.FlavorID != nil {
+			flavor, getFlavorErr := database.GetFlavor(*body.FlavorID)
+			if getFlavorErr != nil {
+				return model.Image{}, getFlavorErr
+			}
+			if config.GBtoByteFloat(flavor.Disk) < resource.MaxDisk {
+				return model.Image{}, fmt.Errorf("error: flavor's disk : %v GiB is less than template's disk : %.2f GiB", flavor.Disk, config.BytetoGB(resource.MaxDisk))
+			}
+		}
+		name := body.Name
+		if name == "" {
+			name = resource.Name
+		}
+		return model.Image{
+			VMID:          vmid,
+			Node:          resource.Node,
+			Name:          name,
+			FlavorID:      body.FlavorID,
+			Description:   body.Description,
+			OS:            body.OS,
+			Icon:          body.Icon,
+			Tags:          tags,
+			VisibleGroups: groups,
+			Deprecated:    body.Deprecated,
+		}, nil
+	}
+	return model.Image{}, fmt.Errorf("error: VMID : %s is not found in cluster", vmid)
+}
+
+// GetImageCatalog - Get images which sender is able to clone
+/*
+	using Query
+	@username : sender
+*/
+func GetImageCatalog(c *fiber.Ctx) error {
+	group, getGroupErr := database.GetUserGroup(c.Query("username"))
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	catalog, getCatalogErr := database.GetImageCatalog(group)
+	if getCatalogErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting images due to %s", getCatalogErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": catalog})
+}
+
+// GetImagesDB - Get every images including deprecated one
+/*
+	using Query
+	@username : sender, only admin
+*/
+func GetImagesDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	images, getImagesErr := database.GetImages()
+	if getImagesErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting images due to %s", getImagesErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": images})
+}
+
+// GetImageDB - Get image
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+*/
+func GetImageDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	image, getImageErr := database.GetTemplate(c.Params("vmid"))
+	if getImageErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting image due to %s", getImageErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": image})
+}
+
+// CreateImageDB - Add Proxmox's template into image catalog
+/*
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	@vmid : Proxmox's template
+	@name : empty : template's name
+	@flavor_id : default flavor, its disk is not less than template's disk
+	@description
+	@os
+	@icon
+	@tags
+	@visible_groups : {student, faculty, admin}, empty : every groups
+	@deprecated
+*/
+func CreateImageDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.ImageBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to create image's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to create image's body"})
+	}
+	image, validateErr := imageFromBody(body.VMID, body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating image due to %s", validateErr)})
+	}
+	created, createErr := database.CreateImage(image)
+	if createErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating image due to %s", createErr)})
+	}
+	log.Printf("Created image VMID : %s", created.VMID)
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "Success", "message": created})
+}
+
+// UpdateImageDB - Replace image's default flavor and metadata, deprecated image is hidden without breaking existing clones
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	same as CreateImageDB except vmid
+*/
+func UpdateImageDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.ImageBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit image's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit image's body"})
+	}
+	vmid := c.Params("vmid")
+	if _, getImageErr := database.GetTemplate(vmid); getImageErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing image due to %s", getImageErr)})
+	}
+	image, validateErr := imageFromBody(vmid, body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing image due to %s", validateErr)})
+	}
+	edited, editErr := database.EditImage(image)
+	if editErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing image due to %s", editErr)})
+	}
+	log.Printf("Edited image VMID : %s", vmid)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": edited})
+}
+
+// DeleteImageDB - Remove deprecated image from catalog, Proxmox's template and existing clones are kept
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+*/
+func DeleteImageDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	vmid := c.Params("vmid")
+	if deleteErr := database.DeleteImage(vmid); deleteErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting image due to %s", deleteErr)})
+	}
+	log.Printf("Deleted image VMID : %s", vmid)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Deleting image VMID : %s successfully", vmid)})
+}
@@ -0,0 +1,182 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/directory"
+	"github.com/edu-cloud-api/internal/lifecycle"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// readImportUsers - reading users from uploaded CSV file or LDAP directory
+func readImportUsers(c *fiber.Ctx) ([]model.ImportUser, error) {
+	if file, formErr := c.FormFile("file"); formErr == nil {
+		reader, openErr := file.Open()
+		if openErr != nil {
+			return nil, fmt.Errorf("error: unable to open uploaded file due to %s", openErr)
+		}
+		defer reader.Close()
+		return directory.ReadCSV(reader)
+	}
+	body := new(model.ImportBody)
+	if err := c.BodyParser(body); err != nil {
+		return nil, fmt.Errorf("error: unable to parse import's body due to %s", err)
+	}
+	if body.Source != "ldap" {
+		return nil, fmt.Errorf("error: source : %s is invalid, upload CSV as file or use ldap", body.Source)
+	}
+	source, sourceErr := directory.NewFromENV()
+	if sourceErr != nil {
+		return nil, sourceErr
+	}
+	return source.Search(body.Filter)
+}
+
+// diffImportUsers - comparing imported users with DB and pool's members
+func diffImportUsers(users []model.ImportUser, pool model.Pool, senderGroup string) model.ImportDiff {
+	diff := model.ImportDiff{
+		Create:        []model.ImportUser{},
+		Existing:      []string{},
+		Enroll:        []string{},
+		AlreadyMember: []string{},
+		Invalid:       []model.ImportError{},
+	}
+	seen := map[string]bool{}
+	for _, user := range users {
+		normalized, normalizeErr := directory.Normalize(user)
+		if normalizeErr != nil {
+			diff.Invalid = append(diff.Invalid, model.ImportError{Username: user.Username, Message: normalizeErr.Error()})
+			continue
+		}
+		if seen[normalized.Username] {
+			continue
+		}
+		seen[normalized.Username] = true
+		if normalized.Group != config.STUDENT && senderGroup != config.ADMIN {
+			diff.Invalid = append(diff.Invalid, model.ImportError{Username: normalized.Username, Message: "error: only admin is allowed to import faculty"})
+			continue
+		}
+		if group, getGroupErr := database.GetUserGroup(normalized.Username); getGroupErr == nil {
+			if group != config.STUDENT {
+				diff.Invalid = append(diff.Invalid, model.ImportError{Username: normalized.Username, Message: fmt.Sprintf("error: user is %s, not allowed to be pool's member", group)})
+				continue
+			}
+			diff.Existing = append(diff.Existing, normalized.Username)
+		} else {
+			diff.Create = append(diff.Create, normalized)
+			// faculty is created but not enrolled as pool's member
+			if normalized.Group != config.STUDENT {
+				continue
+			}
+		}
+		if config.Contains(pool.Member, normalized.Username) {
+			diff.AlreadyMember = append(diff.AlreadyMember, normalized.Username)
+		} else {
+			diff.Enroll = append(diff.Enroll, normalized.Username)
+		}
+	}
+	return diff
+}
+
+// createImportUser - creating user in Proxmox and DB with default instance limit and random password
+func createImportUser(user *model.ImportUser) error {
+	password, passwordErr := config.RandomPassword(12)
+	if passwordErr != nil {
+		return fmt.Errorf("error: unable to generate password due to %s", passwordErr)
+	}
+	if createErr := lifecycle.CreateUser(&model.CreateUserDB{
+		Username: user.Username,
+		Password: password,
+		Name:     user.Name,
+		Email:    user.Email,
+		Group:    user.Group,
+	}); createErr != nil {
+		return createErr
+	}
+	user.Password = password
+	return nil
+}
+
+// ImportPoolMembers - Import users from class roster or LDAP into pool's members
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+	@dry_run : true for returning diff without any change
+
+	using Form
+	@file : class roster in CSV, columns : username, name, email, group
+
+	or using Request body
+	@source : ldap
+	@filter : LDAP search filter
+*/
+func ImportPoolMembers(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	dryRun := c.QueryBool("dry_run", false)
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	// only owner, co-instructor and admin
+	role := database.GetPoolRole(pool, sender)
+	if role != config.POOL_OWNER && role != config.POOL_CO_INSTRUCTOR && group != config.ADMIN {
+		log.Println("Error: user is not owner or co-instructor of pool to import members")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to import pool's members due to user is not owner or co-instructor"})
+	}
+
+	users, readErr := readImportUsers(c)
+	if readErr != nil {
+		log.Printf("Error: reading users to import into pool code : %s due to %s", code, readErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to import pool's members due to %s", readErr)})
+	}
+	diff := diffImportUsers(users, pool, group)
+	if dryRun {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": diff})
+	}
+
+	// Creating missing users
+	var created []model.ImportUser
+	failed := diff.Invalid
+	for _, user := range diff.Create {
+		log.Printf("Importing user : %s", user.Username)
+		if createErr := createImportUser(&user); createErr != nil {
+			log.Printf("Error: Could not import user : %s due to %s", user.Username, createErr)
+			failed = append(failed, model.ImportError{Username: user.Username, Message: createErr.Error()})
+			continue
+		}
+		created = append(created, user)
+	}
+
+	// Enrolling into pool, skip users which could not be created
+	var enrolled []string
+	for _, username := range diff.Enroll {
+		if _, getGroupErr := database.GetUserGroup(username); getGroupErr != nil {
+			continue
+		}
+		enrolled = append(enrolled, username)
+	}
+	if len(enrolled) > 0 {
+		if addErr := database.AddPoolMembers(pool.ID, enrolled); addErr != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to enroll imported users due to %s", addErr)})
+		}
+	}
+	log.Printf("Finished importing %d users, enrolling %d users into pool code : %s, owner : %s", len(created), len(enrolled), code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"created": created, "enrolled": enrolled, "failed": failed}})
+}
@@ -0,0 +1,94 @@
+// Package handler - handling context
+package handler
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/metrics"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/common/expfmt"
+)
+
+// refreshGauges - setting task's, cluster's and quota's gauges before scraping
+// cluster's gauges are kept from previous scraping when Proxmox could not be reached
+func refreshGauges() {
+	pending, running := database.CountUnfinishedTaskItems()
+	metrics.TaskItems.WithLabelValues(config.TASK_PENDING).Set(float64(pending))
+	metrics.TaskItems.WithLabelValues(config.TASK_RUNNING).Set(float64(running))
+
+	// empty cookies, getting nodes using api token
+	if nodes, err := cluster.GetNodes(model.Cookies{}); err == nil {
+		metrics.NodeFreeCPU.Reset()
+		metrics.NodeFreeMemory.Reset()
+		metrics.NodeCordoned.Reset()
+		for _, node := range nodes {
+			metrics.NodeFreeCPU.WithLabelValues(node.Node).Set(node.MaxCPU - node.CPU)
+			metrics.NodeFreeMemory.WithLabelValues(node.Node).Set(float64(node.MaxMem - node.Mem))
+			cordoned := 0.0
+			if node.Cordoned {
+				cordoned = 1
+			}
+			metrics.NodeCordoned.WithLabelValues(node.Node).Set(cordoned)
+		}
+	} else {
+		log.Println("Error: getting nodes for metrics due to", err)
+	}
+
+	if resources, err := qemu.GetResourcesUsingToken(); err == nil {
+		metrics.VMs.Reset()
+		for _, resource := range resources {
+			if resource.Template == 0 {
+				metrics.VMs.WithLabelValues(resource.Status).Inc()
+			}
+		}
+	} else {
+		log.Println("Error: getting VMs for metrics due to", err)
+	}
+
+	if usages, err := database.GetGroupQuotaUsage(); err == nil {
+		ratio := func(used, max float64) float64 {
+			if max == 0 {
+				return 0
+			}
+			return used / max
+		}
+		for _, usage := range usages {
+			metrics.QuotaUtilization.WithLabelValues(usage.UserGroup, "cpu").Set(ratio(usage.UsedCPU, usage.MaxCPU))
+			metrics.QuotaUtilization.WithLabelValues(usage.UserGroup, "ram").Set(ratio(usage.UsedRAM, usage.MaxRAM))
+			metrics.QuotaUtilization.WithLabelValues(usage.UserGroup, "disk").Set(ratio(usage.UsedDisk, usage.MaxDisk))
+			metrics.QuotaUtilization.WithLabelValues(usage.UserGroup, "instance").Set(ratio(usage.UsedInstance, usage.MaxInstance))
+		}
+	}
+}
+
+// Metrics - Exposing Prometheus's metrics in text format
+/*
+	using Header
+	@Authorization : Bearer {METRICS_TOKEN}, required only when METRICS_TOKEN is set
+*/
+func Metrics(c *fiber.Ctx) error {
+	if token := config.Get().MetricsToken; token != "" && c.Get(fiber.HeaderAuthorization) != "Bearer "+token {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"status": "Unauthorized", "message": "Failed getting metrics due to invalid token"})
+	}
+	refreshGauges()
+	families, gatherErr := metrics.Registry.Gather()
+	if gatherErr != nil {
+		log.Println("Error: gathering metrics due to", gatherErr)
+	}
+	var buffer bytes.Buffer
+	encoder := expfmt.NewEncoder(&buffer, expfmt.FmtText)
+	for _, family := range families {
+		if encodeErr := encoder.Encode(family); encodeErr != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed encoding metrics"})
+		}
+	}
+	c.Set(fiber.HeaderContentType, string(expfmt.FmtText))
+	return c.Status(http.StatusOK).Send(buffer.Bytes())
+}
@@ -0,0 +1,89 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/migrate"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MigrateVM - Migrating VM into another node as task, running VM is migrated online
+// POST /api2/json/nodes/{node}/qemu/{vmid}/migrate
+/*
+	using Params
+	@vmid : VM's ID
+
+	using Query
+	@username : sender, only admin
+
+	using Request's Body
+	@target : target node's name, empty for allocating node by cluster's resources
+*/
+func MigrateVM(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.MigrateBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to migrate VM's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to migrate VM's body"})
+	}
+	vmid := c.Params("vmid")
+	cookies := config.GetCookies(c)
+	if _, locateErr := cluster.LocateVM(vmid); locateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed migrating VMID : %s due to %s", vmid, locateErr)})
+	}
+	if body.Target != "" {
+		targetNode, getNodeErr := cluster.GetNode(body.Target, cookies)
+		if getNodeErr != nil || targetNode.Node == "" || targetNode.Status == "offline" {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed migrating VMID : %s due to target node : %s is not available", vmid, body.Target)})
+		}
+	}
+	migrateTask, startErr := migrate.Start(c.Query("username"), vmid, []string{vmid}, *body, nil, cookies)
+	if startErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed migrating VMID : %s due to %s", vmid, startErr)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": migrateTask})
+}
+
+// DrainNode - Cordon node then migrate every VMs and templates off node as task before maintenance
+/*
+	using Params
+	@name : node's name
+
+	using Query
+	@username : sender, only admin
+
+	using Request's Body
+	@concurrency : amount of VMs migrating at the same time, default : 1
+*/
+func DrainNode(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.MigrateBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to drain node's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to drain node's body"})
+	}
+	name := c.Params("name")
+	cookies := config.GetCookies(c)
+	node, getNodeErr := cluster.GetNode(name, cookies)
+	if getNodeErr != nil || node.Node == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed draining node : %s due to node is not found", name)})
+	}
+	drainTask, drainErr := migrate.Drain(c.Query("username"), name, body.Concurrency, cookies)
+	if drainErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed draining node : %s due to %s", name, drainErr)})
+	}
+	if drainTask.ID == 0 {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Node : %s has been cordoned and has no VM to migrate", name)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": drainTask})
+}
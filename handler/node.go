@@ -0,0 +1,169 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/migrate"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CordonNode - Cordon node so no new VMs are placed there while existing ones keep running
+/*
+	using Params
+	@name : node's name
+
+	using Query
+	@username : sender, only admin
+
+	using Request's Body
+	@reason : reason of cordoning
+	@drain : true for migrating every VMs off node after cordoned
+	@concurrency : amount of VMs migrating at the same time when draining, default : 1
+*/
+func CordonNode(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.CordonBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to cordon node's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to cordon node's body"})
+	}
+	sender := c.Query("username")
+	name := c.Params("name")
+	cookies := config.GetCookies(c)
+	node, getNodeErr := cluster.GetNode(name, cookies)
+	if getNodeErr != nil || node.Node == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cordoning node : %s due to node is not found", name)})
+	}
+	state, cordonErr := database.SetNodeCordon(name, true, body.Reason, sender)
+	if cordonErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed cordoning node : %s due to %s", name, cordonErr)})
+	}
+	log.Printf("Node : %s has been cordoned by %s", name, sender)
+	if !body.Drain {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": state})
+	}
+	drainTask, drainErr := migrate.Drain(sender, name, body.Concurrency, cookies)
+	if drainErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Node : %s has been cordoned but failed draining due to %s", name, drainErr)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"state": state, "task": drainTask}})
+}
+
+// UncordonNode - Uncordon node so new VMs are able to be placed there again
+/*
+	using Params
+	@name : node's name
+
+	using Query
+	@username : sender, only admin
+*/
+func UncordonNode(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	sender := c.Query("username")
+	name := c.Params("name")
+	state, uncordonErr := database.SetNodeCordon(name, false, "", sender)
+	if uncordonErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed uncordoning node : %s due to %s", name, uncordonErr)})
+	}
+	log.Printf("Node : %s has been uncordoned by %s", name, sender)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": state})
+}
+
+// GetMaintenanceWindowsDB - Get maintenance windows of node
+/*
+	using Params
+	@name : node's name
+
+	using Query
+	@username : sender, only admin
+*/
+func GetMaintenanceWindowsDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	windows, getWindowsErr := database.GetMaintenanceWindows(c.Params("name"))
+	if getWindowsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting maintenance windows due to %s", getWindowsErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": windows})
+}
+
+// CreateMaintenanceWindowDB - Schedule maintenance window, node is cordoned (and drained) from start time until end time
+/*
+	using Params
+	@name : node's name
+
+	using Query
+	@username : sender, only admin
+
+	using Request's Body
+	@start_time : start of maintenance in RFC3339
+	@end_time : end of maintenance in RFC3339
+	@reason : reason of maintenance
+	@drain : true for migrating every VMs off node at start time
+*/
+func CreateMaintenanceWindowDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.MaintenanceWindowBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to maintenance window's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to maintenance window's body"})
+	}
+	name := c.Params("name")
+	if body.StartTime.IsZero() || !body.EndTime.After(body.StartTime) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to schedule maintenance window due to end time must be after start time"})
+	}
+	node, getNodeErr := cluster.GetNode(name, config.GetCookies(c))
+	if getNodeErr != nil || node.Node == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to schedule maintenance window due to node : %s is not found", name)})
+	}
+	window, createErr := database.CreateMaintenanceWindow(model.MaintenanceWindow{
+		Node:      name,
+		StartTime: body.StartTime.UTC(),
+		EndTime:   body.EndTime.UTC(),
+		Reason:    body.Reason,
+		Drain:     body.Drain,
+		Owner:     c.Query("username"),
+	})
+	if createErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to schedule maintenance window due to %s", createErr)})
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "Success", "message": window})
+}
+
+// DeleteMaintenanceWindowDB - Delete maintenance window of node, node which has been cordoned by window is not uncordoned
+/*
+	using Params
+	@name : node's name
+	@id : maintenance window's ID
+
+	using Query
+	@username : sender, only admin
+*/
+func DeleteMaintenanceWindowDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to delete maintenance window due to ID : %s is invalid", c.Params("id"))})
+	}
+	if deleteErr := database.DeleteMaintenanceWindow(id, c.Params("name")); deleteErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to delete maintenance window due to %s", deleteErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Maintenance window ID : %d has been deleted", id)})
+}
@@ -0,0 +1,48 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetNotificationsDB - Get sender's notifications, latest first
+/*
+	using Query
+	@username : sender
+	@unread : "true" for unread notifications only
+*/
+func GetNotificationsDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	notifications, getErr := database.GetNotifications(sender, c.Query("unread") == "true")
+	if getErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting notifications due to %s", getErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": notifications})
+}
+
+// ReadNotificationsDB - Mark sender's notifications as read
+/*
+	using Query
+	@username : sender
+
+	using Request body
+	@id : list of notification's ID, empty : every notifications
+*/
+func ReadNotificationsDB(c *fiber.Ctx) error {
+	body := new(model.ReadNotificationBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to read notifications's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to read notifications's body"})
+	}
+	sender := c.Query("username")
+	if readErr := database.ReadNotifications(sender, body.ID); readErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed marking notifications as read due to %s", readErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": "Marking notifications as read successfully"})
+}
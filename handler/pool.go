@@ -8,6 +8,7 @@ import (
 
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
 	"github.com/edu-cloud-api/model"
 	"github.com/gofiber/fiber/v2"
 )
@@ -38,8 +39,11 @@ func GetPoolsDB(c *fiber.Ctx) error {
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": pools})
 	}
 
+	// pools that owner is co-instructor or ta are included
 	pools, getPoolsErr := database.GetPoolsByOwner(owner)
-	if getPoolsErr != nil {
+	managedPools, _ := database.GetPoolsByManager(owner)
+	pools = append(pools, managedPools...)
+	if len(pools) == 0 && getPoolsErr != nil {
 		log.Printf("Error: getting pools by given owner : %s due to %s", owner, getPoolsErr)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting pools due to %s", getPoolsErr)})
 	}
@@ -93,9 +97,9 @@ func GetPoolDB(c *fiber.Ctx) error {
 		log.Printf("Error: getting pool by given owner : %s, code : %s due to %s", owner, code, getPoolErr)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting pool due to %s", getPoolErr)})
 	}
-	isOwner := database.IsPoolOwner(code, owner, sender, group)
+	isManager := database.IsPoolManager(code, owner, sender, group)
 	isMember := database.IsPoolMember(code, owner, sender)
-	if isMember || group == config.ADMIN || isOwner {
+	if isMember || group == config.ADMIN || isManager {
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": pool})
 	}
 	return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to getting pool due to user is not member or owner"})
@@ -181,6 +185,18 @@ func DeletePoolDB(c *fiber.Ctx) error {
 	}
 	isOwner := database.IsPoolOwner(code, owner, sender, group)
 	if isOwner || group == config.ADMIN {
+		if deleteQuotaErr := database.DeletePoolQuota(code, owner); deleteQuotaErr != nil {
+			log.Printf("Error: deleting quota of pool by given owner : %s, code : %s due to %s", owner, code, deleteQuotaErr)
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to deleting pool due to %s", deleteQuotaErr)})
+		}
+		if deleteManagersErr := database.DeletePoolManagers(code, owner); deleteManagersErr != nil {
+			log.Printf("Error: deleting managers of pool by given owner : %s, code : %s due to %s", owner, code, deleteManagersErr)
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to deleting pool due to %s", deleteManagersErr)})
+		}
+		if deleteSchedulesErr := database.DeletePowerSchedules(code, owner); deleteSchedulesErr != nil {
+			log.Printf("Error: deleting power schedules of pool by given owner : %s, code : %s due to %s", owner, code, deleteSchedulesErr)
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to deleting pool due to %s", deleteSchedulesErr)})
+		}
 		deletePoolErr := database.DeletePool(code, owner)
 		if deletePoolErr != nil {
 			log.Printf("Error: deleting pool by given owner : %s, code : %s due to %s", owner, code, deletePoolErr)
@@ -209,7 +225,7 @@ func GetRemainStudents(c *fiber.Ctx) error {
 		log.Println("Error: while getting user's group due to :", getGroupErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
 	}
-	if group == config.ADMIN || sender == owner {
+	if database.IsPoolManager(code, owner, sender, group) {
 		students, getStudentErr := database.GetAllStudentsUsername()
 		if getStudentErr != nil {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting student list due to %s", getStudentErr)})
@@ -225,6 +241,39 @@ func GetRemainStudents(c *fiber.Ctx) error {
 	return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pools due to user's group is not allowed"})
 }
 
+// GetPoolMembersDB - Get members of specific pool with role and time they joined
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolMembersDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get members")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's members due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	members, getMembersErr := database.GetPoolMembers(pool.ID)
+	if getMembersErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's members due to %s", getMembersErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": members})
+}
+
 // AddMembersPoolDB - Add members to specific pool
 /*
 	using Request Body
@@ -260,20 +309,13 @@ func AddMembersPoolDB(c *fiber.Ctx) error {
 		log.Println("Error: while getting user's group due to :", getGroupErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
 	}
-	if group == config.ADMIN || sender == owner {
+	if database.IsPoolManager(code, owner, sender, group) {
 		pool, getPoolErr := database.GetPoolByCode(code, owner)
 		if getPoolErr != nil {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
 		}
-		// for _, member := range addMembersBody.Member {
-		// 	if config.Contains(pool.Member, member) {
-		// 		log.Printf("Error: Found duplicate username: %s in given pool", member)
-		// 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to add pool's username: %s due to found duplicate user", member)})
-		// 	}
-		// }
-		// update pool member in DB
-		// addMembersBody.Member = append(addMembersBody.Member, pool.Member...)
-		updateErr := database.AddPoolMembers(pool.Code, pool.Owner, addMembersBody.Member)
+		// existing members are kept, so concurrent adding never drops members
+		updateErr := database.AddPoolMembers(pool.ID, addMembersBody.Member)
 		if updateErr != nil {
 			log.Printf("Error: updating member of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating member of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)})
@@ -311,7 +353,7 @@ func AddInstancesPoolDB(c *fiber.Ctx) error {
 		log.Println("Error: while getting user's group due to :", getGroupErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
 	}
-	if group == config.ADMIN || sender == owner {
+	if database.IsPoolManager(code, owner, sender, group) {
 		// Check that user is owner of given VM
 		instanceTemplateOwner, _ := database.CheckInstanceTemplateOwner(sender, addInstanceBody.VMID)
 		if !instanceTemplateOwner && group != config.ADMIN {
@@ -327,9 +369,7 @@ func AddInstancesPoolDB(c *fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to add pool's instance ID: %s due to instance is not template", addInstanceBody.VMID)})
 		}
 		if !config.Contains(pool.VMID, addInstanceBody.VMID) && template {
-			// update pool member in DB
-			pool.VMID = append(pool.VMID, addInstanceBody.VMID)
-			updateErr := database.AddPoolInstances(pool.Code, pool.Owner, pool.VMID)
+			updateErr := database.AddPoolTemplate(pool.ID, addInstanceBody.VMID)
 			if updateErr != nil {
 				log.Printf("Error: updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)
 				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)})
@@ -376,21 +416,8 @@ func RemoveInstancesPoolDB(c *fiber.Ctx) error {
 		if getPoolErr != nil {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
 		}
-		if len(removeInstanceBody.VMID) == 0 {
-			pool.VMID = []string{}
-		}
-		// } else {
-		// 	for _, vmid := range removeInstanceBody.VMID {
-		// 		log.Println("pool vm", pool.VMID)
-		// 		if !config.Contains(pool.VMID, vmid) {
-		// 			log.Printf("Error: Not found VMID: %s in given pool", vmid)
-		// 		}
-		// 		// update pool member in DB
-		// 		log.Println("vmid", vmid)
-		// 		pool.VMID = config.FilterString(pool.VMID, vmid)
-		// 	}
-		// }
-		updateErr := database.AddPoolInstances(pool.Code, pool.Owner, pool.VMID)
+		// every templates are removed when vmid is empty
+		updateErr := database.RemovePoolTemplates(pool.ID, removeInstanceBody.VMID)
 		if updateErr != nil {
 			log.Printf("Error: updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)})
@@ -401,3 +428,63 @@ func RemoveInstancesPoolDB(c *fiber.Ctx) error {
 	log.Println("Error: user's group is not allowed to get pools")
 	return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pools due to user's group is not allowed"})
 }
+
+// cloneDefaults - filling empty clone mode and storage with pool's defaults, pool's storage is only for full clone
+func cloneDefaults(pool model.Pool, mode, storage string) (string, string) {
+	if mode == "" {
+		mode = pool.CloneMode
+	}
+	if storage == "" && mode != config.CLONE_LINKED {
+		storage = pool.CloneStorage
+	}
+	return mode, storage
+}
+
+// UpdatePoolCloneDB - Set default clone mode and storage of pool's templates, used by clone and provisioning
+/*
+	using Request Body
+	@mode : {full, linked}
+	@storage : only for full clone, empty : template's storage
+
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func UpdatePoolCloneDB(c *fiber.Ctx) error {
+	body := new(model.PoolCloneBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit pool's clone mode body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit pool's clone mode body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to edit clone mode")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to edit pool's clone mode due to user is not manager"})
+	}
+	if body.Mode == "" {
+		body.Mode = config.CLONE_FULL
+	}
+	// pool's templates are always templates, so only mode and storage are validated
+	if _, optionsErr := qemu.CloneOptions(body.Mode, body.Storage, true); optionsErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing pool's clone mode due to %s", optionsErr)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if editErr := database.EditPoolClone(pool.ID, body.Mode, body.Storage); editErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing pool's clone mode due to %s", editErr)})
+	}
+	log.Printf("Set clone mode of pool code : %s, owner : %s to %s", code, owner, body.Mode)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Editing clone mode of pool code : %s, owner : %s to %s successfully", code, owner, body.Mode)})
+}
@@ -0,0 +1,237 @@
+// Package handler - handling context
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPoolInvitesDB - Get join codes of pool
+// only pool's owner, co-instructor and admin, TA is not allowed
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolInvitesDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to get join codes")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's join codes due to user is not instructor"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	invites, getInvitesErr := database.GetPoolInvites(pool.ID)
+	if getInvitesErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's join codes due to %s", getInvitesErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": invites})
+}
+
+// CreatePoolInviteDB - Create join code which students redeem to enroll themselves into pool
+// only pool's owner, co-instructor and admin, TA is not allowed
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@expire_time : {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339, empty : never expire
+	@max_uses : 0 : unlimited
+	@email_domains : e.g. ["kmitl.ac.th"], empty : every domains
+*/
+func CreatePoolInviteDB(c *fiber.Ctx) error {
+	body := new(model.CreatePoolInviteBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to create pool's join code body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to create pool's join code body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to create join code")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to create pool's join code due to user is not instructor"})
+	}
+	var expireTime *time.Time
+	if body.ExpireTime != "" {
+		parsed, parseErr := config.ParseTime(body.ExpireTime)
+		if parseErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating pool's join code due to %s", parseErr)})
+		}
+		if !parsed.After(config.Now()) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating pool's join code due to expire time : %s has passed", body.ExpireTime)})
+		}
+		expireTime = &parsed
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if !pool.Status {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating join code due to pool code : %s, owner : %s has been expired", code, owner)})
+	}
+	invite, createErr := database.CreatePoolInvite(pool.ID, sender, body.MaxUses, body.EmailDomains, expireTime)
+	if createErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed creating pool's join code due to %s", createErr)})
+	}
+	log.Printf("Created join code ID : %d of pool code : %s, owner : %s", invite.ID, code, owner)
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "Success", "message": invite})
+}
+
+// RevokePoolInviteDB - Revoke join code of pool, members who have joined are kept
+// only pool's owner, co-instructor and admin, TA is not allowed
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+	@id : join code's ID
+
+	using Query
+	@username : sender
+*/
+func RevokePoolInviteDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to revoke join code")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to revoke pool's join code due to user is not instructor"})
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to revoke join code due to join code's ID : %s is invalid", c.Params("id"))})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if revokeErr := database.RevokePoolInvite(pool.ID, id); revokeErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed revoking join code due to %s", revokeErr)})
+	}
+	log.Printf("Revoked join code ID : %d of pool code : %s, owner : %s", id, code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Revoking join code ID : %d of pool code : %s, owner : %s successfully", id, code, owner)})
+}
+
+// JoinPoolDB - Enroll sender into pool by redeeming join code, disabled or expired student is rejected
+/*
+	using Query
+	@username : sender
+
+	using Request body
+	@code : join code
+*/
+func JoinPoolDB(c *fiber.Ctx) error {
+	body := new(model.JoinPoolBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to join pool's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to join pool's body"})
+	}
+	sender := c.Query("username")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if group != config.STUDENT {
+		log.Println("Error: user's group is not allowed to join pool")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to join pool due to only students could join pool"})
+	}
+	user, getUserErr := database.GetUser(sender, group)
+	if getUserErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user due to %s", getUserErr)})
+	}
+	if !user.Status || (user.ExpireTime != nil && !config.Now().Before(*user.ExpireTime)) {
+		log.Printf("Error: user : %s has been disabled or expired to join pool", sender)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to join pool due to user has been disabled or expired"})
+	}
+	pool, redeemErr := database.RedeemPoolInvite(body.Code, sender, user.Email)
+	if errors.Is(redeemErr, database.ErrAlreadyMember) {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("User : %s has already been member of pool code : %s, owner : %s", sender, pool.Code, pool.Owner)})
+	}
+	if redeemErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed joining pool due to %s", redeemErr)})
+	}
+	log.Printf("User : %s joined pool code : %s, owner : %s", sender, pool.Code, pool.Owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": model.JoinedPool{Code: pool.Code, Name: pool.Name, Owner: pool.Owner}})
+}
+
+// RemoveMembersPoolDB - Remove members and their allowances from pool, members's VMs are kept
+// only pool's owner, co-instructor and admin, TA is not allowed
+/*
+	using Request Body
+	@members : removing members
+
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func RemoveMembersPoolDB(c *fiber.Ctx) error {
+	body := new(model.AddPoolMemberBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to remove pool's members body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to remove pool's members body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to remove members")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to remove pool's members due to user is not instructor"})
+	}
+	if len(body.Member) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to remove pool's members due to members are empty"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if removeErr := database.RemovePoolMembers(pool.ID, body.Member); removeErr != nil {
+		log.Printf("Error: removing members of pool code : %s, owner : %s due to %s", code, owner, removeErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed removing members of pool code : %s, owner : %s due to %s", code, owner, removeErr)})
+	}
+	log.Printf("Successfully removed members : %v from pool code : %s, owner : %s", body.Member, code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Removed members : %v from pool code : %s, owner : %s successfully", body.Member, code, owner)})
+}
@@ -0,0 +1,217 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/archive"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPoolLifecycleDB - Get what happens to pool's members, VMs and templates when pool has been expired
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolLifecycleDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get lifecycle")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's lifecycle due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	lifecycle, getLifecycleErr := database.GetPoolLifecycle(pool.ID)
+	if getLifecycleErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's lifecycle due to %s", getLifecycleErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": lifecycle})
+}
+
+// UpdatePoolLifecycleDB - Configure what happens to pool's members, VMs and templates when pool has been expired
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@auto_archive : archive automatically at pool's expire time
+	@warn_days : days before pool's expire time which members are warned
+	@stop_vms
+	@backup : {none, snapshot, backup}, snapshot could not be used with delete_vms
+	@backup_storage : empty : BACKUP_STORAGE
+	@delete_vms
+	@templates : {keep, archive}
+	@unenroll : remove pool's members after archived
+*/
+func UpdatePoolLifecycleDB(c *fiber.Ctx) error {
+	body := new(model.PoolLifecycle)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit pool's lifecycle body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit pool's lifecycle body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to edit lifecycle")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to edit pool's lifecycle due to user is not owner"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if editErr := database.EditPoolLifecycle(pool.ID, body); editErr != nil {
+		log.Printf("Error: Could not edit lifecycle of pool code : %s, owner : %s due to %s", code, owner, editErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing pool's lifecycle due to %s", editErr)})
+	}
+	log.Printf("Finished editing lifecycle of pool code : %s, owner : %s", code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Editing lifecycle of pool code : %s, owner : %s successfully", code, owner)})
+}
+
+// PreviewPoolArchive - Get report of what archiving pool would do to pool's VMs, templates and members
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func PreviewPoolArchive(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to preview archive")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to preview pool's archive due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	lifecycle, getLifecycleErr := database.GetPoolLifecycle(pool.ID)
+	if getLifecycleErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's lifecycle due to %s", getLifecycleErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": archive.Preview(pool, lifecycle)})
+}
+
+// ArchivePoolDB - Archive pool now as configured by pool's lifecycle, without waiting for pool's expire time
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func ArchivePoolDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to archive")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to archive pool due to user is not owner"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	archiveTask, startErr := archive.Start(sender, pool, logging.RequestID(c))
+	if startErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed archiving pool due to %s", startErr)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": archiveTask})
+}
+
+// ExtendPoolDB - Extend pool's expire time, pool's members are notified and will be warned again before new expire time
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@expire_time : {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339, date expires at EXPIRY_HOUR
+*/
+func ExtendPoolDB(c *fiber.Ctx) error {
+	body := new(model.ExtendPoolBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to extend pool's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to extend pool's body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to extend")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to extend pool due to user is not owner"})
+	}
+	expireTime, parseErr := config.ParseTime(body.ExpireTime)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed extending pool due to %s", parseErr)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if !expireTime.After(config.Now()) || (pool.ExpireTime != nil && !expireTime.After(*pool.ExpireTime)) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed extending pool due to expire time : %s is not after current expire time and now", body.ExpireTime)})
+	}
+	if extendErr := database.ExtendPool(pool.ID, expireTime); extendErr != nil {
+		log.Printf("Error: Could not extend pool code : %s, owner : %s due to %s", code, owner, extendErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed extending pool due to %s", extendErr)})
+	}
+	subject := fmt.Sprintf("Pool %s has been extended", pool.Code)
+	message := fmt.Sprintf("Pool %s of %s has been extended until %s.", pool.Code, pool.Owner, config.FormatTime(&expireTime))
+	if notifyErr := database.CreateNotifications(pool.Member, config.NOTIFY_POOL_EXTENDED, subject, message); notifyErr != nil {
+		log.Printf("Error: Could not notify members of pool code : %s, owner : %s due to %s", code, owner, notifyErr)
+	}
+	log.Printf("Finished extending pool code : %s, owner : %s until %s", code, owner, config.FormatTime(&expireTime))
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Extending pool code : %s, owner : %s until %s successfully", code, owner, config.FormatTime(&expireTime))})
+}
@@ -0,0 +1,273 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPoolsByManagerDB - Get pools that sender is co-instructor or ta
+/*
+	using Query
+	@username : sender
+*/
+func GetPoolsByManagerDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	if _, getGroupErr := database.GetUserGroup(sender); getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	pools, getPoolsErr := database.GetPoolsByManager(sender)
+	if getPoolsErr != nil {
+		log.Printf("Error: getting pools by given manager : %s due to %s", sender, getPoolsErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting pools due to %s", getPoolsErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": pools})
+}
+
+// GetPoolManagersDB - Get managers of specific pool
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolManagersDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get managers")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's managers due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	managers, getManagersErr := database.GetPoolManagers(pool.ID)
+	if getManagersErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's managers due to %s", getManagersErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": managers})
+}
+
+// AddPoolManagerDB - Add co-instructor or ta to specific pool
+/*
+	using Request Body
+	@username : adding manager
+	@role : {co-instructor, ta}
+
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func AddPoolManagerDB(c *fiber.Ctx) error {
+	body := new(model.PoolManagerBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to add pool's manager body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to add pool's manager body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to add manager")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to add pool's manager due to user is not owner"})
+	}
+
+	// co-instructor must be faculty or admin, ta could be any user
+	managerGroup, getManagerGroupErr := database.GetUserGroup(body.Username)
+	if getManagerGroupErr != nil {
+		log.Println("Error: while getting manager's group due to :", getManagerGroupErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting manager's group due to %s", getManagerGroupErr)})
+	}
+	if body.Role == config.POOL_CO_INSTRUCTOR && managerGroup == config.STUDENT {
+		log.Printf("Error: student : %s is not allowed to be co-instructor", body.Username)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to add pool's manager due to student is not allowed to be co-instructor"})
+	}
+	if body.Username == owner {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to add pool's manager due to user is already owner"})
+	}
+
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	manager, addErr := database.AddPoolManager(pool.ID, body.Username, body.Role)
+	if addErr != nil {
+		log.Printf("Error: adding manager of pool code : %s, owner : %s due to %s", code, owner, addErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed adding manager of pool code : %s, owner : %s due to %s", code, owner, addErr)})
+	}
+	log.Printf("Successfully added %s : %s to pool code : %s, owner : %s", body.Role, body.Username, code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": manager})
+}
+
+// RemovePoolManagerDB - Remove co-instructor or ta from specific pool
+/*
+	using Request Body
+	@username : removing manager
+
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func RemovePoolManagerDB(c *fiber.Ctx) error {
+	body := new(model.PoolManagerBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to remove pool's manager body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to remove pool's manager body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to remove manager")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to remove pool's manager due to user is not owner"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if _, getManagerErr := database.GetPoolManager(pool.ID, body.Username); getManagerErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed removing manager due to %s", getManagerErr)})
+	}
+	if removeErr := database.RemovePoolManager(pool.ID, body.Username); removeErr != nil {
+		log.Printf("Error: removing manager of pool code : %s, owner : %s due to %s", code, owner, removeErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed removing manager of pool code : %s, owner : %s due to %s", code, owner, removeErr)})
+	}
+	log.Printf("Successfully removed manager : %s from pool code : %s, owner : %s", body.Username, code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Removed manager : %s from pool code : %s, owner : %s successfully", body.Username, code, owner)})
+}
+
+// TransferPoolDB - Transfer ownership of specific pool
+/*
+	using Request Body
+	@owner : new owner
+	@previous_role : role of previous owner after transfer {co-instructor, ta}, empty to remove
+
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func TransferPoolDB(c *fiber.Ctx) error {
+	body := new(model.TransferPoolBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to transfer pool's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to transfer pool's body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to transfer")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to transfer pool due to user is not owner"})
+	}
+	if body.PreviousRole != "" && body.PreviousRole != config.POOL_CO_INSTRUCTOR && body.PreviousRole != config.POOL_TA {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to transfer pool due to previous role %s is invalid", body.PreviousRole)})
+	}
+
+	// new owner must be faculty or admin
+	newOwnerGroup, getNewOwnerGroupErr := database.GetUserGroup(body.Owner)
+	if getNewOwnerGroupErr != nil {
+		log.Println("Error: while getting new owner's group due to :", getNewOwnerGroupErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting new owner's group due to %s", getNewOwnerGroupErr)})
+	}
+	if newOwnerGroup == config.STUDENT || body.Owner == owner {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to transfer pool due to new owner's group is not allowed or already owner"})
+	}
+	if _, duplicateErr := database.GetPoolByCode(code, body.Owner); duplicateErr == nil {
+		log.Printf("Error: found pool code : %s, owner : %s exists", code, body.Owner)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to transfer pool due to found pool code : %s, owner : %s exists", code, body.Owner)})
+	}
+
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if transferErr := database.TransferPoolOwner(pool, body.Owner, body.PreviousRole); transferErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed transferring pool code : %s, owner : %s due to %s", code, owner, transferErr)})
+	}
+	log.Printf("Successfully transferred pool code : %s from owner : %s to %s", code, owner, body.Owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Transferred pool code : %s from owner : %s to %s successfully", code, owner, body.Owner)})
+}
+
+// GetPoolMembersVMList - Getting VM list of every pool's members
+// GET /api2/json/cluster/resources
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolMembersVMList(c *fiber.Ctx) error {
+	var returnList []model.VMsInfo
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get members's VM list")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get members's VM list due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	cookies := config.GetCookies(c)
+	vmList, err := qemu.GetVMList(cookies)
+	if err != nil {
+		log.Println("Error: from getting VM list :", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting VM list due to %s", err)})
+	}
+	list := database.GetAllInstancesIDByOwners(pool.Member)
+	for _, vm := range vmList {
+		if config.Contains(list, fmt.Sprint(vm.VMID)) {
+			returnList = append(returnList, vm)
+		}
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": returnList})
+}
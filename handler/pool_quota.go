@@ -0,0 +1,200 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPoolQuotaDB - Get pool's quota and usage
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolQuotaDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) && !database.IsPoolMember(code, owner, sender) {
+		log.Println("Error: user is not member or manager of pool to get quota")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's quota due to user is not member or manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	quota, getQuotaErr := database.GetPoolQuota(pool.ID)
+	if getQuotaErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting pool's quota due to %s", getQuotaErr)})
+	}
+	usage, getUsageErr := database.GetPoolUsage(pool.ID)
+	if getUsageErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's usage due to %s", getUsageErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"quota": quota, "usage": usage}})
+}
+
+// UpdatePoolQuotaDB - Create or update pool's quota
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@max_cpu
+	@max_ram
+	@max_disk
+	@max_instance
+*/
+func UpdatePoolQuotaDB(c *fiber.Ctx) error {
+	body := new(model.EditInstanceLimit)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit pool's quota body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit pool's quota body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolOwner(code, owner, sender, group) {
+		log.Println("Error: user is not owner of pool to edit quota")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to edit pool's quota due to user is not owner"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if editErr := database.EditPoolQuota(pool.ID, body); editErr != nil {
+		log.Printf("Error: Could not edit quota of pool code : %s, owner : %s due to %s", code, owner, editErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing pool's quota due to %s", editErr)})
+	}
+	log.Printf("Finished editing quota of pool code : %s, owner : %s", code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Editing quota of pool code : %s, owner : %s successfully", code, owner)})
+}
+
+// GetPoolUsageDB - Get usage roll-ups of pool and every pool's members
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPoolUsageDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get usage")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's usage due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	usage, getUsageErr := database.GetPoolUsage(pool.ID)
+	if getUsageErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's usage due to %s", getUsageErr)})
+	}
+	members, getMembersErr := database.GetPoolMembersUsage(pool.ID)
+	if getMembersErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting members's usage due to %s", getMembersErr)})
+	}
+	allowances, getAllowancesErr := database.GetPoolAllowances(pool.ID)
+	if getAllowancesErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting members's allowances due to %s", getAllowancesErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"pool": usage, "members": members, "allowances": allowances}})
+}
+
+// UpdatePoolAllowanceDB - Rebalance member's allowances inside pool's quota
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@mode : {even, custom}, even : split pool's quota equally to every members
+	@allowances : member's allowances for custom mode, empty to remove all allowances
+*/
+func UpdatePoolAllowanceDB(c *fiber.Ctx) error {
+	body := new(model.PoolAllowanceBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit pool's allowances body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit pool's allowances body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	// only owner, co-instructor and admin
+	role := database.GetPoolRole(pool, sender)
+	if role != config.POOL_OWNER && role != config.POOL_CO_INSTRUCTOR && group != config.ADMIN {
+		log.Println("Error: user is not owner or co-instructor of pool to edit allowances")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to edit pool's allowances due to user is not owner or co-instructor"})
+	}
+
+	var allowances []model.PoolAllowance
+	switch body.Mode {
+	case "even":
+		quota, getQuotaErr := database.GetPoolQuota(pool.ID)
+		if getQuotaErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to split pool's quota due to %s", getQuotaErr)})
+		}
+		allowances = database.EvenPoolAllowances(quota, pool.Member)
+	case "custom":
+		for _, allowance := range body.Allowances {
+			if !config.Contains(pool.Member, allowance.Username) {
+				log.Printf("Error: username : %s is not member of pool code : %s, owner : %s", allowance.Username, code, owner)
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to edit pool's allowances due to username : %s is not member", allowance.Username)})
+			}
+		}
+		allowances = body.Allowances
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to edit pool's allowances due to mode %s is invalid", body.Mode)})
+	}
+	if setErr := database.SetPoolAllowances(pool.ID, allowances); setErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing pool's allowances due to %s", setErr)})
+	}
+	log.Printf("Finished editing allowances of pool code : %s, owner : %s", code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": allowances})
+}
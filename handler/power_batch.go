@@ -0,0 +1,274 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/power"
+	"github.com/edu-cloud-api/model"
+	"github.com/edu-cloud-api/schedule"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PowerBatchVM - Acting power action on many VMs with bounded concurrency
+// sender must be owner of each VM or owner, co-instructor of pool which VM is charged to
+/*
+	using Request's Body
+	@action : {start, stop, shutdown, suspend, resume, reset}
+	@vmid : list of VM's ID
+	@concurrency : amount of VMs acting at the same time, default : 5
+
+	using Query
+	@username : account's username
+*/
+func PowerBatchVM(c *fiber.Ctx) error {
+	body := new(model.PowerBatchBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to batch power VMs's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to batch power VMs's body"})
+	}
+	username := c.Query("username")
+	if !power.IsValidAction(body.Action) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed acting on VMs due to action : %s is invalid", body.Action)})
+	}
+	var vmids []string
+	for _, vmid := range body.VMID {
+		if config.Contains(vmids, vmid) {
+			continue
+		}
+		owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
+		if checkOwnerErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
+		}
+		if !owner {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to user is not owner of VM", vmid)})
+		}
+		vmids = append(vmids, vmid)
+	}
+	powerTask, startErr := power.Start(username, username, body.Action, logging.RequestID(c), vmids, body.Concurrency)
+	if startErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed acting on VMs due to %s", startErr)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": powerTask})
+}
+
+// PowerPoolDB - Acting power action on every VMs which are charged to pool, only pool's owner, co-instructor and admin
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request's Body
+	@action : {start, stop, shutdown, suspend, resume, reset}
+	@concurrency : amount of VMs acting at the same time, default : 5
+*/
+func PowerPoolDB(c *fiber.Ctx) error {
+	body := new(model.PowerBatchBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to power pool's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to power pool's body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to act on pool's VMs")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to act on pool's VMs due to user is not instructor"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	vmids := database.GetAllInstancesIDByPool(pool.ID)
+	powerTask, startErr := power.Start(sender, fmt.Sprintf("%s/%s", code, owner), body.Action, logging.RequestID(c), vmids, body.Concurrency)
+	if startErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed acting on pool's VMs due to %s", startErr)})
+	}
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": powerTask})
+}
+
+// GetTaskDB - Get progress and per-target result of task which sender has requested
+/*
+	using Params
+	@id : task's ID
+
+	using Query
+	@username : sender
+*/
+func GetTaskDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting task due to task ID : %s is invalid", c.Params("id"))})
+	}
+	requestedTask, getTaskErr := database.GetTask(id)
+	if getTaskErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting task due to %s", getTaskErr)})
+	}
+	if requestedTask.Owner != sender && group != config.ADMIN {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting task ID : %d due to user is not owner of task", id)})
+	}
+	items, getItemsErr := database.GetTaskItems(id)
+	if getItemsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting items of task due to %s", getItemsErr)})
+	}
+	// credentials are returned only from provisioning task's endpoint
+	for i := range items {
+		items[i].CIPass = ""
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"task": requestedTask, "items": items}})
+}
+
+// GetPowerSchedulesDB - Get power schedules of pool
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+*/
+func GetPowerSchedulesDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get power schedules")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get pool's power schedules due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	schedules, getSchedulesErr := database.GetPowerSchedules(pool.ID)
+	if getSchedulesErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's power schedules due to %s", getSchedulesErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": schedules})
+}
+
+// CreatePowerScheduleDB - Schedule power action on every VMs of pool, only pool's owner, co-instructor and admin
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request's Body
+	@action : {start, stop, shutdown, suspend, resume, reset}
+	@run_at : one-time schedule in RFC3339, e.g. "2023-06-02T18:00:00+07:00"
+	@cron : recurring schedule in cron format with seconds in campus's timezone, e.g. "0 0 18 * * FRI"
+*/
+func CreatePowerScheduleDB(c *fiber.Ctx) error {
+	body := new(model.PowerScheduleBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to power schedule's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to power schedule's body"})
+	}
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to schedule power action")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to schedule power action due to user is not instructor"})
+	}
+	if !power.IsValidAction(body.Action) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to schedule power action due to action : %s is invalid", body.Action)})
+	}
+	if (body.Cron == "") == body.RunAt.IsZero() {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to schedule power action due to either run_at or cron must be given"})
+	}
+	if body.Cron != "" {
+		if _, parseErr := schedule.CronParser.Parse(body.Cron); parseErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to schedule power action due to invalid cron : %s", parseErr)})
+		}
+	}
+	if !body.RunAt.IsZero() && body.RunAt.Before(time.Now()) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to schedule power action due to run_at has passed"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	powerSchedule, createErr := database.CreatePowerSchedule(model.PowerSchedule{
+		PoolID: pool.ID,
+		Owner:  sender,
+		Action: body.Action,
+		RunAt:  body.RunAt.UTC(),
+		Cron:   body.Cron,
+	})
+	if createErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to schedule power action due to %s", createErr)})
+	}
+	log.Printf("Finished scheduling %s every VMs of pool code : %s, owner : %s", body.Action, code, owner)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": powerSchedule})
+}
+
+// DeletePowerScheduleDB - Delete power schedule of pool, only pool's owner, co-instructor and admin
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+	@id : power schedule's ID
+
+	using Query
+	@username : sender
+*/
+func DeletePowerScheduleDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolInstructor(code, owner, sender, group) {
+		log.Println("Error: user is not instructor of pool to delete power schedule")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to delete power schedule due to user is not instructor"})
+	}
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to delete power schedule due to ID : %s is invalid", c.Params("id"))})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	if deleteErr := database.DeletePowerSchedule(id, pool.ID); deleteErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to delete power schedule due to %s", deleteErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Power schedule ID : %d has been deleted", id)})
+}
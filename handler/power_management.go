@@ -10,6 +10,7 @@ import (
 
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
 	"github.com/edu-cloud-api/internal/qemu"
 	"github.com/edu-cloud-api/model"
 	"github.com/gofiber/fiber/v2"
@@ -19,7 +20,7 @@ import (
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/start
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -33,8 +34,9 @@ func StartVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to start VM's body"})
 	}
 	vmid := fmt.Sprint(startBody.VMID)
+	startBody.Node = cluster.ResolveNode(vmid, startBody.Node)
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -66,7 +68,7 @@ func StartVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until starting process has been completed
-	started := qemu.CheckStatus(startBody.Node, vmid, []string{"running"}, false, (5 * time.Minute), time.Second)
+	started := qemu.CheckStatus(startBody.Node, vmid, []string{"running"}, false, config.Get().PowerTimeout, time.Second)
 	if started {
 		log.Printf("Finished starting VMID : %s in %s", vmid, startBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been started", vmid, startBody.Node)})
@@ -79,7 +81,7 @@ func StartVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/stop
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -93,8 +95,9 @@ func StopVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to stop VM's body"})
 	}
 	vmid := fmt.Sprint(stopBody.VMID)
+	stopBody.Node = cluster.ResolveNode(vmid, stopBody.Node)
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -126,7 +129,7 @@ func StopVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until stopping process has been completed
-	stopped := qemu.CheckStatus(stopBody.Node, vmid, []string{"stopped"}, false, (5 * time.Minute), time.Second)
+	stopped := qemu.CheckStatus(stopBody.Node, vmid, []string{"stopped"}, false, config.Get().PowerTimeout, time.Second)
 	if stopped {
 		log.Printf("Finished stopping VMID : %s in %s", vmid, stopBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been stopped", vmid, stopBody.Node)})
@@ -140,7 +143,7 @@ func StopVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/shutdown
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -154,12 +157,13 @@ func ShutdownVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to shut down VM's body"})
 	}
 	vmid := fmt.Sprint(shutdownBody.VMID)
+	shutdownBody.Node = cluster.ResolveNode(vmid, shutdownBody.Node)
 
 	// Construct payload
 	data := url.Values{}
 	data.Set("forceStop", "1") // ! Fixed to set "1" for waiting until VM stopped
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -191,7 +195,7 @@ func ShutdownVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until shutting down process has been completed
-	shutdown := qemu.CheckStatus(shutdownBody.Node, vmid, []string{"stopped"}, false, (5 * time.Minute), (3 * time.Second))
+	shutdown := qemu.CheckStatus(shutdownBody.Node, vmid, []string{"stopped"}, false, config.Get().PowerTimeout, (3 * time.Second))
 	if shutdown {
 		log.Printf("Finished shutting down VMID : %s in %s", vmid, shutdownBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been shut down", vmid, shutdownBody.Node)})
@@ -204,7 +208,7 @@ func ShutdownVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/suspend
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -218,8 +222,9 @@ func SuspendVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to suspend VM's body"})
 	}
 	vmid := fmt.Sprint(suspendBody.VMID)
+	suspendBody.Node = cluster.ResolveNode(vmid, suspendBody.Node)
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -251,7 +256,7 @@ func SuspendVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until suspending process has been completed
-	suspended := qemu.CheckQmpStatus(suspendBody.Node, vmid, []string{"paused"}, false, (5 * time.Minute), time.Second)
+	suspended := qemu.CheckQmpStatus(suspendBody.Node, vmid, []string{"paused"}, false, config.Get().PowerTimeout, time.Second)
 	if suspended {
 		log.Printf("Finished suspending VMID : %s in %s", vmid, suspendBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been suspended", vmid, suspendBody.Node)})
@@ -264,7 +269,7 @@ func SuspendVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/resume
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -278,8 +283,9 @@ func ResumeVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to resume VM's body"})
 	}
 	vmid := fmt.Sprint(resumeBody.VMID)
+	resumeBody.Node = cluster.ResolveNode(vmid, resumeBody.Node)
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -311,7 +317,7 @@ func ResumeVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until resuming process has been completed
-	resumed := qemu.CheckQmpStatus(resumeBody.Node, vmid, []string{"running"}, false, (5 * time.Minute), time.Second)
+	resumed := qemu.CheckQmpStatus(resumeBody.Node, vmid, []string{"running"}, false, config.Get().PowerTimeout, time.Second)
 	if resumed {
 		log.Printf("Finished resuming VMID : %s in %s", vmid, resumeBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been resumed", vmid, resumeBody.Node)})
@@ -324,7 +330,7 @@ func ResumeVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}/status/reset
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -338,8 +344,9 @@ func ResetVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to reset VM's body"})
 	}
 	vmid := fmt.Sprint(resetBody.VMID)
+	resetBody.Node = cluster.ResolveNode(vmid, resetBody.Node)
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrInstructor(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -371,7 +378,7 @@ func ResetVM(c *fiber.Ctx) error {
 	}
 
 	// Waiting until resetting process has been completed
-	reset := qemu.CheckStatus(resetBody.Node, vmid, []string{"running"}, false, (5 * time.Minute), time.Second)
+	reset := qemu.CheckStatus(resetBody.Node, vmid, []string{"running"}, false, config.Get().PowerTimeout, time.Second)
 	if reset {
 		log.Printf("Finished resetting VMID : %s in %s", vmid, resetBody.Node)
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s in %s has been reset", vmid, resetBody.Node)})
@@ -0,0 +1,277 @@
+// Package handler - handling context
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/provision"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// getProvisionTemplate - getting pool's template and its spec for provisioning
+func getProvisionTemplate(pool model.Pool, vmid string, cookies model.Cookies) (model.Instance, model.VMSpec, error) {
+	if !config.Contains(pool.VMID, vmid) {
+		return model.Instance{}, model.VMSpec{}, fmt.Errorf("error: VMID : %s is not template of pool", vmid)
+	}
+	template, getTemplateErr := database.GetInstanceTemplate(vmid)
+	if getTemplateErr != nil {
+		return template, model.VMSpec{}, getTemplateErr
+	}
+	vmGetURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", template.Node, vmid))
+	vm, vmInfoErr := qemu.GetVM(vmGetURL, cookies)
+	if vmInfoErr != nil {
+		return template, model.VMSpec{}, vmInfoErr
+	}
+	return template, model.VMSpec{Memory: vm.Info.MaxMem, CPU: vm.Info.CPUs, Disk: vm.Info.MaxDisk}, nil
+}
+
+// provisionCloneMode - filling clone mode and storage of provisioning with pool's defaults
+// linked clone records template which it is based on, its full disk is still charged to quota
+func provisionCloneMode(pool model.Pool, body *model.ProvisionBody, vmSpec *model.VMSpec) error {
+	body.Mode, body.Storage = cloneDefaults(pool, body.Mode, body.Storage)
+	if _, optionsErr := qemu.CloneOptions(body.Mode, body.Storage, true); optionsErr != nil {
+		return optionsErr
+	}
+	if body.Mode == config.CLONE_LINKED {
+		vmSpec.BaseDisk, vmSpec.BaseVMID = vmSpec.Disk, body.VMID
+	}
+	return nil
+}
+
+// getProvisionTask - getting provisioning task of specific pool from given task's ID
+func getProvisionTask(c *fiber.Ctx, code, owner string) (model.Task, error) {
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 64)
+	if parseErr != nil {
+		return model.Task{}, fmt.Errorf("error: task ID : %s is invalid", c.Params("id"))
+	}
+	provisionTask, getTaskErr := database.GetTask(id)
+	if getTaskErr != nil {
+		return provisionTask, getTaskErr
+	}
+	if provisionTask.Type != config.TASK_PROVISION || provisionTask.Target != fmt.Sprintf("%s/%s", code, owner) {
+		return model.Task{}, fmt.Errorf("error: task ID : %d is not provisioning of pool", id)
+	}
+	return provisionTask, nil
+}
+
+// ProvisionPool - Clone pool's template once for every pool's members
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender
+
+	using Request body
+	@vmid : pool's template
+	@name_pattern : {code}, {username}, {index} are replaced, default : "{code}-{username}"
+	@storage : storage's name, only for full clone, empty : pool's default storage
+	@mode : {full, linked}, empty : pool's default clone mode
+	@concurrency : amount of cloning at the same time, default : 5
+	@members : empty for every pool's members
+	@ciuser : empty for member's username
+	@cipassword : empty for generating password for each member
+*/
+func ProvisionPool(c *fiber.Ctx) error {
+	body := new(model.ProvisionBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to provision pool's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to provision pool's body"})
+	}
+	cookies := config.GetCookies(c)
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to provision")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to provision pool due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+
+	members := body.Members
+	if len(members) == 0 {
+		members = pool.Member
+	}
+	for _, member := range members {
+		if !config.Contains(pool.Member, member) {
+			log.Printf("Error: username : %s is not member of pool code : %s, owner : %s", member, code, owner)
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to provision pool due to username : %s is not member", member)})
+		}
+	}
+	if len(members) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to provision pool due to pool has no member"})
+	}
+	body.Members = members
+
+	template, vmSpec, templateErr := getProvisionTemplate(pool, body.VMID, cookies)
+	if templateErr != nil {
+		log.Printf("Error: getting template VMID : %s of pool code : %s due to %s", body.VMID, code, templateErr)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to provision pool due to %s", templateErr)})
+	}
+	if modeErr := provisionCloneMode(pool, body, &vmSpec); modeErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to provision pool due to %s", modeErr)})
+	}
+	if _, quotaErr := database.CheckPoolQuotaForMembers(pool.ID, members, vmSpec); quotaErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to provision pool due to %s", quotaErr)})
+	}
+
+	// store request's body for retrying, password is never stored in plain text
+	params := *body
+	params.CIPass = ""
+	if body.CIPass != "" {
+		encrypted, encryptErr := config.Encrypt(body.CIPass)
+		if encryptErr != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to encrypt password due to %s", encryptErr)})
+		}
+		params.CIPass = encrypted
+	}
+	paramsJSON, _ := json.Marshal(params)
+	provisionTask, createTaskErr := database.CreateTask(config.TASK_PROVISION, sender, fmt.Sprintf("%s/%s", code, owner), string(paramsJSON), cookies.RequestID, members)
+	if createTaskErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to create provisioning task due to %s", createTaskErr)})
+	}
+	items, getItemsErr := database.GetTaskItems(provisionTask.ID)
+	if getItemsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting items of provisioning task due to %s", getItemsErr)})
+	}
+
+	go provision.Run(provisionTask, items, pool, template, vmSpec, *body, cookies)
+	log.Printf("Started provisioning task ID : %d of pool code : %s, owner : %s for %d members", provisionTask.ID, code, owner, len(members))
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": provisionTask})
+}
+
+// GetProvisionTask - Get progress and per-member result of provisioning task
+// cloud-init's passwords are returned only to pool's owner and admin, otherwise they are masked
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+	@id : task's ID
+
+	using Query
+	@username : sender
+*/
+func GetProvisionTask(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to get provisioning task")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to get provisioning task due to user is not manager"})
+	}
+	provisionTask, getTaskErr := getProvisionTask(c, code, owner)
+	if getTaskErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting provisioning task due to %s", getTaskErr)})
+	}
+	items, getItemsErr := database.GetTaskItems(provisionTask.ID)
+	if getItemsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting items of provisioning task due to %s", getItemsErr)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	showPassword := group == config.ADMIN || pool.Owner == sender
+	for i := range items {
+		if items[i].CIPass == "" {
+			continue
+		}
+		if !showPassword {
+			items[i].CIPass, items[i].HasPass = "", true
+			continue
+		}
+		password, decryptErr := config.Decrypt(items[i].CIPass)
+		if decryptErr != nil {
+			log.Printf("Error: decrypting password of item ID : %d due to %s", items[i].ID, decryptErr)
+			items[i].CIPass = ""
+			continue
+		}
+		items[i].CIPass = password
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"task": provisionTask, "items": items}})
+}
+
+// RetryProvisionTask - Retry only failed items of provisioning task, VM left behind by failed item is deleted before cloning again
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+	@id : task's ID
+
+	using Query
+	@username : sender
+*/
+func RetryProvisionTask(c *fiber.Ctx) error {
+	cookies := config.GetCookies(c)
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		log.Println("Error: user is not manager of pool to retry provisioning task")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to retry provisioning task due to user is not manager"})
+	}
+	provisionTask, getTaskErr := getProvisionTask(c, code, owner)
+	if getTaskErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting provisioning task due to %s", getTaskErr)})
+	}
+	if provisionTask.Status == config.TASK_PENDING || provisionTask.Status == config.TASK_RUNNING {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to retry task ID : %d due to task is %s", provisionTask.ID, provisionTask.Status)})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	var body model.ProvisionBody
+	if unmarshalErr := json.Unmarshal([]byte(provisionTask.Params), &body); unmarshalErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to read parameters of task ID : %d due to %s", provisionTask.ID, unmarshalErr)})
+	}
+	if body.CIPass != "" {
+		password, decryptErr := config.Decrypt(body.CIPass)
+		if decryptErr != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to decrypt password of task ID : %d due to %s", provisionTask.ID, decryptErr)})
+		}
+		body.CIPass = password
+	}
+	template, vmSpec, templateErr := getProvisionTemplate(pool, body.VMID, cookies)
+	if templateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to retry provisioning task due to %s", templateErr)})
+	}
+	if modeErr := provisionCloneMode(pool, &body, &vmSpec); modeErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to retry provisioning task due to %s", modeErr)})
+	}
+	items, resetErr := database.ResetFailedTaskItems(provisionTask.ID)
+	if resetErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to retry provisioning task due to %s", resetErr)})
+	}
+
+	go provision.Run(provisionTask, items, pool, template, vmSpec, body, cookies)
+	log.Printf("Retrying %d failed items of provisioning task ID : %d", len(items), provisionTask.ID)
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Retrying %d failed items of task ID : %d", len(items), provisionTask.ID)})
+}
@@ -0,0 +1,199 @@
+// Package handler - handling context
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// deprecatedAlias - marking response of deprecated endpoint with its successor
+func deprecatedAlias(c *fiber.Ctx, successor string) {
+	c.Set("Deprecation", "true")
+	c.Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+}
+
+// imageOfSizing - converting deprecated sizing template's body into image with default flavor of the same spec
+func imageOfSizing(vmid string, body *model.SizingBody) (model.Image, error) {
+	if body.MaxCPU <= 0 || body.MaxRAM <= 0 || body.MaxDisk <= 0 {
+		return model.Image{}, fmt.Errorf("error: max_cpu, max_ram and max_disk must be positive")
+	}
+	flavor, flavorErr := database.FlavorOfSizing(body.MaxCPU, body.MaxRAM, body.MaxDisk)
+	if flavorErr != nil {
+		return model.Image{}, flavorErr
+	}
+	return imageFromBody(vmid, &model.ImageBody{
+		Name:          body.Name,
+		FlavorID:      &flavor.ID,
+		Description:   body.Description,
+		OS:            body.OS,
+		Icon:          body.Icon,
+		Tags:          body.Tags,
+		VisibleGroups: body.VisibleGroups,
+		Deprecated:    body.Deprecated,
+	})
+}
+
+// GetSizingCatalog - Get images which sender is able to clone as sizing templates
+// ! deprecated, use /vm/image/list and /vm/flavor/list
+/*
+	using Query
+	@username : sender
+*/
+func GetSizingCatalog(c *fiber.Ctx) error {
+	deprecatedAlias(c, "/vm/image/list")
+	group, getGroupErr := database.GetUserGroup(c.Query("username"))
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	catalog, getCatalogErr := database.GetImageCatalog(group)
+	if getCatalogErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting sizing templates due to %s", getCatalogErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": database.ToSizings(catalog)})
+}
+
+// GetSizingsDB - Get every images including deprecated one as sizing templates
+// ! deprecated, use /admin/image and /admin/flavor
+/*
+	using Query
+	@username : sender, only admin
+*/
+func GetSizingsDB(c *fiber.Ctx) error {
+	deprecatedAlias(c, "/admin/image")
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	images, getImagesErr := database.GetImages()
+	if getImagesErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting sizing templates due to %s", getImagesErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": database.ToSizings(images)})
+}
+
+// GetSizingDB - Get image as sizing template
+// ! deprecated, use /admin/image/:vmid
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+*/
+func GetSizingDB(c *fiber.Ctx) error {
+	deprecatedAlias(c, "/admin/image/"+c.Params("vmid"))
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	image, getImageErr := database.GetTemplate(c.Params("vmid"))
+	if getImageErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting sizing template due to %s", getImageErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": database.ToSizing(image)})
+}
+
+// CreateSizingDB - Add Proxmox's template into image catalog, defaulting to flavor of given spec
+// ! deprecated, use /admin/flavor and /admin/image
+/*
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	@vmid : Proxmox's template
+	@name : empty : template's name
+	@max_cpu : rounded up to whole cores
+	@max_ram : in GiB
+	@max_disk : in GiB, not less than template's disk
+	@description
+	@os
+	@icon
+	@tags
+	@visible_groups : {student, faculty, admin}, empty : every groups
+	@deprecated
+*/
+func CreateSizingDB(c *fiber.Ctx) error {
+	deprecatedAlias(c, "/admin/image")
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.SizingBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to create sizing template's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to create sizing template's body"})
+	}
+	image, validateErr := imageOfSizing(body.VMID, body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating sizing template due to %s", validateErr)})
+	}
+	created, createErr := database.CreateImage(image)
+	if createErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating sizing template due to %s", createErr)})
+	}
+	log.Printf("Created image VMID : %s from sizing template", created.VMID)
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "Success", "message": database.ToSizing(created)})
+}
+
+// UpdateSizingDB - Replace image's metadata and default flavor with flavor of given spec
+// ! deprecated, use /admin/image/:vmid
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+
+	using Request body
+	same as CreateSizingDB except vmid
+*/
+func UpdateSizingDB(c *fiber.Ctx) error {
+	vmid := c.Params("vmid")
+	deprecatedAlias(c, "/admin/image/"+vmid)
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	body := new(model.SizingBody)
+	if err := c.BodyParser(body); err != nil {
+		log.Println("Error: Could not parse body parser to edit sizing template's body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit sizing template's body"})
+	}
+	if _, getImageErr := database.GetTemplate(vmid); getImageErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing sizing template due to %s", getImageErr)})
+	}
+	image, validateErr := imageOfSizing(vmid, body)
+	if validateErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing sizing template due to %s", validateErr)})
+	}
+	edited, editErr := database.EditImage(image)
+	if editErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing sizing template due to %s", editErr)})
+	}
+	log.Printf("Edited image VMID : %s from sizing template", vmid)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": database.ToSizing(edited)})
+}
+
+// DeleteSizingDB - Remove deprecated image from catalog, its default flavor is kept
+// ! deprecated, use /admin/image/:vmid
+/*
+	using Params
+	@vmid : image's VMID
+
+	using Query
+	@username : sender, only admin
+*/
+func DeleteSizingDB(c *fiber.Ctx) error {
+	vmid := c.Params("vmid")
+	deprecatedAlias(c, "/admin/image/"+vmid)
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	if deleteErr := database.DeleteImage(vmid); deleteErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting sizing template due to %s", deleteErr)})
+	}
+	log.Printf("Deleted image VMID : %s from sizing template", vmid)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Deleting sizing template VMID : %s successfully", vmid)})
+}
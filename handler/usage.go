@@ -0,0 +1,177 @@
+// Package handler - handling context
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseUsagePeriod - parsing period of usage report from query, default : from first day of this month until now
+/*
+	@from : start of period in RFC3339 or YYYY-MM-DD, included
+	@to : end of period in RFC3339 or YYYY-MM-DD, excluded
+*/
+func parseUsagePeriod(c *fiber.Ctx) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	parse := func(value string, target *time.Time) error {
+		if value == "" {
+			return nil
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				*target = parsed.UTC()
+				return nil
+			}
+		}
+		return fmt.Errorf("error: %s is not RFC3339 or YYYY-MM-DD", value)
+	}
+	if err := parse(c.Query("from"), &from); err != nil {
+		return from, to, err
+	}
+	if err := parse(c.Query("to"), &to); err != nil {
+		return from, to, err
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("error: to must be after from")
+	}
+	return from, to, nil
+}
+
+// sendUsageReports - response usage reports as JSON or as CSV file when format is csv
+func sendUsageReports(c *fiber.Ctx, reports []model.UsageReport, from, to time.Time, filename string) error {
+	if c.Query("format") != "csv" {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fiber.Map{"from": from, "to": to, "reports": reports}})
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"key", "vms", "running_hours", "cpu_hours", "used_cpu_hours", "ram_gb_hours", "disk_gb_hours", "netin", "netout"})
+	for _, report := range reports {
+		writer.Write([]string{
+			report.Key,
+			fmt.Sprint(report.VMs),
+			strconv.FormatFloat(report.RunningHours, 'f', 2, 64),
+			strconv.FormatFloat(report.CPUHours, 'f', 2, 64),
+			strconv.FormatFloat(report.UsedCPUHours, 'f', 2, 64),
+			strconv.FormatFloat(report.RAMGBHours, 'f', 2, 64),
+			strconv.FormatFloat(report.DiskGBHours, 'f', 2, 64),
+			fmt.Sprint(report.NetIn),
+			fmt.Sprint(report.NetOut),
+		})
+	}
+	writer.Flush()
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s_%s_%s.csv"`, filename, from.Format("20060102"), to.Format("20060102")))
+	return c.Status(http.StatusOK).Send(buffer.Bytes())
+}
+
+// GetUsageDB - Get usage reports of every users, pools or groups in period
+/*
+	using Query
+	@username : sender, only admin
+	@by : {user, pool, group}, default : user
+	@from : start of period in RFC3339 or YYYY-MM-DD, default : first day of this month
+	@to : end of period in RFC3339 or YYYY-MM-DD, default : now
+	@format : csv for exporting as CSV file
+*/
+func GetUsageDB(c *fiber.Ctx) error {
+	if ok, err := isAdmin(c); !ok {
+		return err
+	}
+	by := c.Query("by", "user")
+	if !database.IsValidUsageKey(by) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting usage due to by : %s is invalid", by)})
+	}
+	from, to, parseErr := parseUsagePeriod(c)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting usage due to %s", parseErr)})
+	}
+	reports, getReportsErr := database.GetUsageReports(by, from, to, 0, "")
+	if getReportsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting usage due to %s", getReportsErr)})
+	}
+	return sendUsageReports(c, reports, from, to, "usage_by_"+by)
+}
+
+// GetUserUsageDB - Get usage report of user per pool in period
+/*
+	using Params
+	@username : target user
+
+	using Query
+	@username : sender, only target user or admin
+	@from : start of period in RFC3339 or YYYY-MM-DD, default : first day of this month
+	@to : end of period in RFC3339 or YYYY-MM-DD, default : now
+	@format : csv for exporting as CSV file
+*/
+func GetUserUsageDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	username := c.Params("username")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if sender != username && group != config.ADMIN {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to getting usage due to user is not allowed"})
+	}
+	from, to, parseErr := parseUsagePeriod(c)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting usage due to %s", parseErr)})
+	}
+	reports, getReportsErr := database.GetUsageReports("pool", from, to, 0, username)
+	if getReportsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting usage due to %s", getReportsErr)})
+	}
+	return sendUsageReports(c, reports, from, to, "usage_"+username)
+}
+
+// GetPoolUsageReportDB - Get usage report of pool per member in period
+/*
+	using Params
+	@username : pool owner
+	@code : course code
+
+	using Query
+	@username : sender, only pool's manager or admin
+	@from : start of period in RFC3339 or YYYY-MM-DD, default : first day of this month
+	@to : end of period in RFC3339 or YYYY-MM-DD, default : now
+	@format : csv for exporting as CSV file
+*/
+func GetPoolUsageReportDB(c *fiber.Ctx) error {
+	sender := c.Query("username")
+	owner := c.Params("username")
+	code := c.Params("code")
+	group, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if !database.IsPoolManager(code, owner, sender, group) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to getting pool's usage due to user is not manager"})
+	}
+	pool, getPoolErr := database.GetPoolByCode(code, owner)
+	if getPoolErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool from given code, owner due to %s", getPoolErr)})
+	}
+	from, to, parseErr := parseUsagePeriod(c)
+	if parseErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed to getting pool's usage due to %s", parseErr)})
+	}
+	reports, getReportsErr := database.GetUsageReports("user", from, to, pool.ID, "")
+	if getReportsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting pool's usage due to %s", getReportsErr)})
+	}
+	return sendUsageReports(c, reports, from, to, fmt.Sprintf("usage_%s_%s", code, owner))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/lifecycle"
 	"github.com/edu-cloud-api/model"
 	"github.com/gofiber/fiber/v2"
 )
@@ -115,7 +116,7 @@ func GetStudentsDB(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": students})
 }
 
-// CreateUserDB - Create user in DB
+// CreateUserDB - Create user in DB and Proxmox with default user's limit
 /*
 	using Query
 	@username : sender
@@ -124,6 +125,7 @@ func GetStudentsDB(c *fiber.Ctx) error {
 	@username
 	@password
 	@name
+	@email
 	@status
 */
 func CreateUserDB(c *fiber.Ctx) error {
@@ -148,32 +150,16 @@ func CreateUserDB(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to create user due to user's group is not allowed"})
 	}
 
-	// Checking duplicate username
-	usernames, getUsersErr := database.GetUsers()
-	if getUsersErr != nil {
-		log.Printf("Error: Could not get user's username list due to : %s", getUsersErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting user's username list due to %s", getUsersErr)})
-	}
-	if config.Contains(usernames, body.Username) {
-		log.Printf("Error: Could not create user %s username list due to duplicated username", body.Username)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed creating user %s due to duplicated username", body.Username)})
-	}
-
-	// Creating User, User's limit
-	log.Printf("Creating user : %s", body.Username)
-	_, createErr := database.CreateUserDB(body)
-	if createErr != nil {
-		log.Printf("Error: Could not create user %s in DB due to : %s", body.Username, createErr)
+	// Creating User in Proxmox, DB and User's limit
+	if createErr := lifecycle.CreateUser(body); createErr != nil {
+		log.Printf("Error: Could not create user %s due to : %s", body.Username, createErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed creating user %s due to %s", body.Username, createErr)})
 	}
-	if createLimitErr := database.CreateInstanceLimit(body.Username, body.Group); createLimitErr != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed creating user %s's limit due to %s", body.Username, createLimitErr)})
-	}
 	log.Printf("Finished creating user : %s", body.Username)
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Creating user %s successfully", body.Username)})
 }
 
-// DeleteUserDB - Delete user in DB
+// DeleteUserDB - Delete user and user's limit in DB and Proxmox
 /*
 	using Params
 	@username
@@ -197,33 +183,16 @@ func DeleteUserDB(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to create user due to user's group is not allowed"})
 	}
 
-	// Getting user's group
-	group, getGroupErr := database.GetUserGroup(username)
-	if getGroupErr != nil {
-		log.Println("Error: Could not get user's group due to :", getGroupErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting user's group due to %s", getGroupErr)})
-	}
-
-	// Deleting User
-	log.Printf("Deleting user : %s", username)
-	deleteErr := database.DeleteUserDB(username, group)
-	if deleteErr != nil {
-		log.Println("Error: Could not delete user in DB due to :", deleteErr)
+	// Deleting User, User's limit in DB and Proxmox
+	if deleteErr := lifecycle.DeleteUser(username, nil); deleteErr != nil {
+		log.Println("Error: Could not delete user due to :", deleteErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting user : %s due to %s", username, deleteErr)})
 	}
-
-	// Deleting instance limit
-	log.Printf("Deleting user's instance limit : %s", username)
-	deleteLimitErr := database.DeleteInstanceLimit(username)
-	if deleteLimitErr != nil {
-		log.Println("Error: Could not delete user's instance limit in DB due to :", deleteLimitErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting user's instance limit : %s due to %s", username, deleteLimitErr)})
-	}
-	log.Printf("Finished deleting user's instance limit : %s", username)
+	log.Printf("Finished deleting user : %s", username)
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Deleting user %s successfully", username)})
 }
 
-// UpdateUserDB - Update user in DB
+// UpdateUserDB - Update user in DB and Proxmox
 /*
 	using Params
 	@username
@@ -234,10 +203,11 @@ func DeleteUserDB(c *fiber.Ctx) error {
 	using Request body
 	@password
 	@name
+	@email
 	@status
-	@expire_time
+	@expire_time : {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339, date expires at EXPIRY_HOUR
 */
-// todo : check why could not change status from true -> false
+// status could not be changed from true -> false here, use DisableUserDB instead
 func UpdateUserDB(c *fiber.Ctx) error {
 	// Getting params from URL
 	username := c.Params("username")
@@ -261,18 +231,10 @@ func UpdateUserDB(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit user's body"})
 	}
 
-	// get user's group
-	group, getGroupErr := database.GetUserGroup(username)
-	if getGroupErr != nil {
-		log.Println("Error: Could not get user's group due to :", getGroupErr)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting user's group due to %s", getGroupErr)})
-	}
-
-	// Editing User
+	// Editing User in DB and Proxmox
 	log.Printf("Editing user : %s", username)
-	editErr := database.EditUser(username, group, body)
-	if editErr != nil {
-		log.Printf("Error: Could not edit user %s in DB due to : %s", username, editErr)
+	if editErr := lifecycle.UpdateUser(username, body); editErr != nil {
+		log.Printf("Error: Could not edit user %s due to : %s", username, editErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing user : %s due to %s", username, editErr)})
 	}
 	log.Printf("Finished editing user : %s", username)
@@ -364,3 +326,49 @@ func UpdateUserLimitDB(c *fiber.Ctx) error {
 	log.Printf("Finished editing user's limit : %s", username)
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Editing user %s's limit successfully", username)})
 }
+
+// DisableUserDB - Disable user in DB and Proxmox
+/*
+	using Params
+	@username
+
+	using Query
+	@username : sender
+*/
+func DisableUserDB(c *fiber.Ctx) error {
+	return setUserEnabled(c, false)
+}
+
+// EnableUserDB - Enable user in DB and Proxmox
+/*
+	using Params
+	@username
+
+	using Query
+	@username : sender
+*/
+func EnableUserDB(c *fiber.Ctx) error {
+	return setUserEnabled(c, true)
+}
+
+// setUserEnabled - enabling or disabling user from given params by admin
+func setUserEnabled(c *fiber.Ctx, enabled bool) error {
+	username := c.Params("username")
+	sender := c.Query("username")
+
+	// Checking sender's role
+	userGroup, getGroupErr := database.GetUserGroup(sender)
+	if getGroupErr != nil {
+		log.Println("Error: while getting user's group due to :", getGroupErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
+	}
+	if userGroup != config.ADMIN {
+		log.Println("Error: user's group is not allowed to update user")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Failed to update user due to user's group is not allowed"})
+	}
+	if setErr := lifecycle.SetUserEnabled(username, enabled); setErr != nil {
+		log.Printf("Error: Could not set user %s enabled : %t due to : %s", username, enabled, setErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed updating user : %s due to %s", username, setErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Setting user %s enabled : %t successfully", username, enabled)})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/edu-cloud-api/config"
@@ -21,17 +22,17 @@ import (
 // GET /api2/json/nodes/{node}/qemu/{vmid}/status/current
 /*
 	using Params
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
 	@username : account's username
 */
 func GetVM(c *fiber.Ctx) error {
-	node := c.Params("node")
 	vmid := c.Params("vmid")
+	node := cluster.ResolveNode(vmid, c.Params("node"))
 	username := c.Query("username")
-	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrManager(username, vmid)
 	if checkOwnerErr != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
@@ -50,6 +51,47 @@ func GetVM(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": info})
 }
 
+// GetVMMetrics - Getting VM's performance history of CPU, memory, disk I/O and network
+// GET /api2/json/nodes/{node}/qemu/{vmid}/rrddata
+/*
+	using Params
+	@vmid : VM's ID
+
+	using Query
+	@username : account's username
+	@timeframe : {hour, day, week}, default : hour
+	@points : maximum amount of points after downsampling, default : every points
+	@refresh : true for bypassing cache
+*/
+func GetVMMetrics(c *fiber.Ctx) error {
+	vmid := c.Params("vmid")
+	username := c.Query("username")
+	timeframe := c.Query("timeframe", "hour")
+	if !qemu.IsValidTimeframe(timeframe) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting metrics of VMID : %s due to timeframe : %s is invalid", vmid, timeframe)})
+	}
+	owner, checkOwnerErr := database.CheckInstanceOwnerOrManager(username, vmid)
+	if checkOwnerErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
+	}
+	if !owner {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to user is not owner of VM", vmid)})
+	}
+	instance, getInstanceErr := database.GetInstance(vmid)
+	if getInstanceErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, getInstanceErr)})
+	}
+	node := cluster.ResolveNode(vmid, instance.Node)
+	cookies := config.GetCookies(c)
+	log.Printf("Getting metrics of VMID : %s in %s, timeframe : %s", vmid, node, timeframe)
+	metrics, err := qemu.GetVMMetrics(node, vmid, timeframe, c.QueryInt("points", 0), c.QueryBool("refresh", false), cookies)
+	if err != nil {
+		log.Println("Error: from getting VM's metrics :", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting metrics of VMID : %s due to %s", vmid, err)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": metrics})
+}
+
 // GetVMListByNode - Getting VM list from given node 🚫
 // GET /api2/json/nodes/{node}/qemu
 /*
@@ -72,11 +114,44 @@ func GetVMListByNode(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": vmList})
 }
 
+// filterVMList - filtering VMs which have every given tags and name or VMID containing search, case-insensitive
+func filterVMList(vmList []model.VMsInfo, tags []string, search string) []model.VMsInfo {
+	if len(tags) == 0 && search == "" {
+		return vmList
+	}
+	search = strings.ToLower(search)
+	filtered := []model.VMsInfo{}
+	for _, vm := range vmList {
+		if search != "" && !strings.Contains(strings.ToLower(vm.Name), search) && !strings.Contains(fmt.Sprint(vm.VMID), search) {
+			continue
+		}
+		vmTags := qemu.SplitTags(strings.ToLower(vm.Tags))
+		matched := true
+		for _, tag := range tags {
+			if !config.Contains(vmTags, tag) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, vm)
+		}
+	}
+	return filtered
+}
+
+// listFilters - getting tags and search of list's query
+func listFilters(c *fiber.Ctx) ([]string, string) {
+	return qemu.SplitTags(strings.ToLower(c.Query("tag"))), strings.TrimSpace(c.Query("search"))
+}
+
 // GetVMList - Getting VM list (VM Template not included)
 // GET /api2/json/cluster/resources
 /*
 	using Query
 	username : account's username
+	tag : VM has every tags, separated by ","
+	search : VM's name or VMID contains, case-insensitive
 */
 func GetVMList(c *fiber.Ctx) error {
 	var returnList []model.VMsInfo
@@ -92,8 +167,9 @@ func GetVMList(c *fiber.Ctx) error {
 		log.Println("Error: from getting VM list :", err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting VM list due to %s", err)})
 	}
+	tags, search := listFilters(c)
 	if group == config.ADMIN {
-		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": vmList})
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": filterVMList(vmList, tags, search)})
 	}
 	list, _ := database.GetAllInstancesIDByOwner(username)
 	for _, vm := range vmList {
@@ -101,7 +177,7 @@ func GetVMList(c *fiber.Ctx) error {
 			returnList = append(returnList, vm)
 		}
 	}
-	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": returnList})
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": filterVMList(returnList, tags, search)})
 }
 
 // CreateVM - Create VM on specific node
@@ -142,7 +218,7 @@ func CreateVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting vmid due to %s", getVMIDErr)})
 	}
 	scsi0 := fmt.Sprintf("%s:%s", createBody.Storage, createBody.Disk)
-	cdrom := config.ISO + createBody.CDROM
+	cdrom := config.Get().ISO() + createBody.CDROM
 
 	// Construct payload
 	data := url.Values{}
@@ -154,7 +230,7 @@ func CreateVM(c *fiber.Ctx) error {
 	data.Set("onboot", fmt.Sprint(config.ONBOOT))
 	data.Set("scsi0", scsi0) // "ceph-vm:32"
 	data.Set("cdrom", cdrom)
-	data.Set("net0", config.NET0)
+	data.Set("net0", config.Get().NET0())
 	data.Set("scsihw", config.SCSIHW)
 
 	maxDisk, parseErr := strconv.ParseUint(createBody.Disk, 10, 64)
@@ -226,7 +302,7 @@ func CreateVM(c *fiber.Ctx) error {
 // DELETE /api2/json/nodes/{node}/qemu/{vmid}
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -240,6 +316,7 @@ func DeleteVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to delete VM's body"})
 	}
 	vmid := fmt.Sprint(deleteBody.VMID)
+	deleteBody.Node = cluster.ResolveNode(vmid, deleteBody.Node)
 	username := c.Query("username")
 	cookies := config.GetCookies(c)
 
@@ -253,6 +330,12 @@ func DeleteVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to delete VMID: %s", vmid)})
 	}
 
+	// template's disk is base of its linked clones
+	if clones := database.GetLinkedClones(vmid); len(clones) > 0 {
+		log.Printf("Error: deleting VMID : %s due to linked clones : %v", vmid, clones)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting VMID : %s due to linked clones : %v are based on it", vmid, clones)})
+	}
+
 	// First check that target VM has been stopped
 	vmGetURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", deleteBody.Node, vmid))
 	vm, err := qemu.GetVM(vmGetURL, cookies)
@@ -279,6 +362,7 @@ func DeleteVM(c *fiber.Ctx) error {
 	deleted := qemu.DeleteCompletely(deleteBody.Node, vmid)
 	if deleted {
 		log.Printf("Finished deleting VMID : %s in %s", vmid, deleteBody.Node)
+		cluster.ForgetVM(vmid)
 
 		// Delete VM in DB
 		if deleteInstanceErr := database.DeleteInstance(vmid); deleteInstanceErr != nil {
@@ -286,21 +370,7 @@ func DeleteVM(c *fiber.Ctx) error {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed deleting instance ID : %s from DB due to %s", vmid, deleteInstanceErr)})
 		}
 
-		// Delete VM in Pool DB
-		pools, getPoolsErr := database.GetPoolsByVMID(vmid)
-		if getPoolsErr != nil {
-			log.Printf("Error: Getting pools from given vmid : %s from DB due to %s", vmid, getPoolsErr)
-			// return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed removing instance ID : %s from pools DB due to %s", vmid, getPoolsErr)})
-		}
-		for _, pool := range pools {
-			pool.VMID = config.FilterString(pool.VMID, vmid)
-			updateErr := database.AddPoolInstances(pool.Code, pool.Owner, pool.VMID)
-			if updateErr != nil {
-				log.Printf("Error: updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating instances of pool code : %s, owner : %s due to %s", pool.Code, pool.Owner, updateErr)})
-			}
-			log.Printf("Successfully removed template ID : %s to pool code : %s, owner : %s", vmid, pool.Code, pool.Owner)
-		}
+		// VM is removed from pools by pool_template's foreign key
 
 		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Target VMID: %s has been deleted", vmid)})
 	}
@@ -313,15 +383,16 @@ func DeleteVM(c *fiber.Ctx) error {
 /*
 	using Query Params
 	@username : account's username
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Request's Body
 	@name : VM's name
-	@storage : Storage's name
-	@full : 1
+	@storage : Storage's name, only for full clone, empty : pool's default storage or template's storage
+	@mode : {full, linked}, empty : pool's default clone mode or full
 	@ciuser : cloudinit's username
 	@cipassword : cloudinit's password
+	@flavor : flavor's ID, empty : image's default flavor or template's spec
 */
 func CloneVM(c *fiber.Ctx) error {
 	// Getting request's body
@@ -334,8 +405,8 @@ func CloneVM(c *fiber.Ctx) error {
 
 	// getting data from query & Mapping values
 	username := c.Query("username")
-	node := c.Query("node")
 	vmid := c.Query("vmid")
+	node := cluster.ResolveNode(vmid, c.Query("node"))
 
 	group, getGroupErr := database.GetUserGroup(username)
 	if getGroupErr != nil {
@@ -343,9 +414,11 @@ func CloneVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
 	}
 
-	// able to clone only own template or sizing template except man who request is admin
-	isSizingTemplate, _ := database.IsSizingTemplate(vmid)
-	if !isSizingTemplate {
+	// able to clone only own template or image except man who request is admin
+	// clone from pool's template is charged to pool's quota
+	var quotaPool model.Pool
+	isImage, _ := database.IsImageAvailable(vmid, group)
+	if !isImage {
 		// get template from every pools that username is member
 		var poolInstances []string
 		pools, getPoolsErr := database.GetAllPoolsByMember(username)
@@ -358,12 +431,34 @@ func CloneVM(c *fiber.Ctx) error {
 					poolInstances = append(poolInstances, instance)
 				}
 			}
+			if quotaPool.ID == 0 && config.Contains(pool.VMID, vmid) {
+				quotaPool = pool
+			}
 		}
 		log.Println(poolInstances)
 		instanceTemplateOwner, _ := database.CheckInstanceTemplateOwner(username, vmid)
 		if !instanceTemplateOwner && group != config.ADMIN && !config.Contains(poolInstances, vmid) {
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cloning VMID : %s due to VM is not template or user is not owner", vmid)})
 		}
+		if instanceTemplateOwner {
+			quotaPool = model.Pool{}
+		}
+	}
+
+	// flavor is picked by request or image's default flavor, otherwise clone keeps template's spec
+	var flavor *model.Flavor
+	flavorID := cloneBody.Flavor
+	if flavorID == 0 && isImage {
+		if image, getImageErr := database.GetTemplate(vmid); getImageErr == nil && image.FlavorID != nil {
+			flavorID = *image.FlavorID
+		}
+	}
+	if flavorID != 0 {
+		picked, getFlavorErr := database.GetAvailableFlavor(flavorID, group)
+		if getFlavorErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cloning VMID : %s due to %s", vmid, getFlavorErr)})
+		}
+		flavor = &picked
 	}
 
 	// getting new vmid
@@ -376,6 +471,16 @@ func CloneVM(c *fiber.Ctx) error {
 	// Check VM Template from vmid
 	isTemplate := qemu.IsTemplate(node, vmid)
 	if isTemplate || group == config.ADMIN {
+		// clone from pool's template follows pool's default clone mode
+		mode, storage := cloneBody.Mode, cloneBody.Storage
+		if quotaPool.ID != 0 {
+			mode, storage = cloneDefaults(quotaPool, mode, storage)
+		}
+		data, optionsErr := qemu.CloneOptions(mode, storage, isTemplate)
+		if optionsErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cloning VMID : %s due to %s", vmid, optionsErr)})
+		}
+
 		// Check spec of the VM before allocate node
 		vmGetURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", node, vmid))
 		vm, vmInfoErr := qemu.GetVM(vmGetURL, cookies)
@@ -390,9 +495,33 @@ func CloneVM(c *fiber.Ctx) error {
 			CPU:    vm.Info.CPUs,
 			Disk:   vm.Info.MaxDisk,
 		}
+		if flavor != nil {
+			// template's disk could not be shrunk to flavor's disk
+			if config.GBtoByteFloat(flavor.Disk) < vm.Info.MaxDisk {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cloning VMID : %s due to flavor's disk : %v GiB is less than template's disk : %.2f GiB", vmid, flavor.Disk, config.BytetoGB(vm.Info.MaxDisk))})
+			}
+			vmSpec = model.VMSpec{
+				Memory: config.GBtoByteFloat(flavor.RAM),
+				CPU:    float64(flavor.CPU),
+				Disk:   config.GBtoByteFloat(flavor.Disk),
+			}
+		}
+		// linked clone is based on template, its full disk is still charged since copy-on-write volume grows up to it
+		if mode == config.CLONE_LINKED {
+			vmSpec.BaseDisk, vmSpec.BaseVMID = vm.Info.MaxDisk, vmid
+		}
+		vmSpec.PoolID = quotaPool.ID
+
+		// Check pool's quota and member's allowance
+		if quotaPool.ID != 0 {
+			if _, quotaErr := database.CheckPoolQuota(quotaPool.ID, username, vmSpec, true); quotaErr != nil {
+				log.Printf("Error: cloning VMID : %s due to %s", vmid, quotaErr)
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed cloning VMID : %s due to %s", vmid, quotaErr)})
+			}
+		}
 
 		// Getting target node from node allocation
-		workerNodes, target, nodeErr := cluster.AllocateNode(vmSpec, cloneBody.Storage, cookies)
+		workerNodes, target, nodeErr := cluster.AllocateNode(vmSpec, storage, cookies)
 		if nodeErr != nil {
 			log.Println("Error: allocate node :", nodeErr)
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to allocate node for creating VM due to %s", nodeErr)})
@@ -415,11 +544,9 @@ func CloneVM(c *fiber.Ctx) error {
 		}
 
 		// Construct payload
-		data := url.Values{}
 		data.Set("newid", newid)
 		data.Set("name", cloneBody.Name)
 		data.Set("target", target)
-		data.Set("full", "1") // ! fixed to `1` for full clone
 		log.Println("clone body :", data)
 
 		// Cloning VM in Proxmox
@@ -432,7 +559,7 @@ func CloneVM(c *fiber.Ctx) error {
 		}
 
 		// Waiting until cloning process has been completed
-		cloned := qemu.CheckStatus(target, newid, []string{"created", "stopped", "running"}, false, (10 * time.Minute), time.Second)
+		cloned := qemu.CheckStatus(target, newid, []string{"created", "stopped", "running"}, false, config.Get().CloneTimeout, time.Second)
 		if cloned {
 
 			// Creating VM in DB
@@ -441,16 +568,12 @@ func CloneVM(c *fiber.Ctx) error {
 				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Creating new VMID: %s has failed due to %s", newid, createInstanceErr)})
 			}
 
-			// resize disk to sizing template's disk in DB
-			if isSizingTemplate {
+			// grow disk from template's disk to flavor's disk
+			if flavor != nil && vmSpec.Disk > vm.Info.MaxDisk {
 				log.Printf("Resizing VMID : %s in %s", newid, target)
-				sizing, _ := database.GetTemplate(vmid)
-				sizingDiskByte := config.GBtoByteFloat(sizing.MaxDisk) - vm.Info.MaxDisk
-				sizingDisk := fmt.Sprint(`+`, sizingDiskByte)
-
 				resizeData := url.Values{}
 				resizeData.Set("disk", "scsi0")
-				resizeData.Set("size", sizingDisk)
+				resizeData.Set("size", fmt.Sprint(`+`, vmSpec.Disk-vm.Info.MaxDisk))
 				log.Println("resize data:", resizeData)
 
 				// Resizing Disk in Proxmox
@@ -460,22 +583,27 @@ func CloneVM(c *fiber.Ctx) error {
 					log.Printf("Error: resizing disk of VMID : %s in %s : %s", newid, target, resizeInfoErr)
 					return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed resizing disk of VMID : %s due to %s", newid, resizeInfoErr)})
 				}
-
-				// Resizing Disk in DB
-				resizeErr := database.ResizeDisk(newid, sizing.MaxDisk)
-				if resizeErr != nil {
-					log.Printf("Error: resizing disk of VMID : %s in DB : %s", newid, resizeErr)
-					return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed resizing disk of VMID : %s in DB due to %s", newid, resizeErr)})
-				}
 			}
-			// config ciuser, cipassword
+			// config ciuser, cipassword and flavor's cpu, ram
 			editData := url.Values{}
 			editData.Set("ciuser", cloneBody.CIUser)
 			editData.Set("cipassword", cloneBody.CIPass)
+			vmEditURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", target, newid))
+			if flavor != nil {
+				// cloned VM inherits template's hotplug and numa, which decide headroom of CPU hot-plug
+				options, optionsErr := qemu.GetVMOptions(vmEditURL, cookies)
+				if optionsErr != nil {
+					log.Printf("Error: getting config of VMID : %s in %s : %s", newid, target, optionsErr)
+					return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting config of VMID : %s in %s due to %s", newid, target, optionsErr)})
+				}
+				for key, values := range qemu.CPUOptions(flavor.CPU, options) {
+					editData[key] = values
+				}
+				editData.Set("memory", fmt.Sprint(uint64(flavor.RAM*1024)))
+			}
 			log.Println("edit body :", editData)
 
 			log.Printf("Editing VMID : %s in %s", newid, target)
-			vmEditURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", target, newid))
 			_, editErr := qemu.EditVM(vmEditURL, editData, cookies)
 			if editErr != nil {
 				log.Printf("Error: editing VMID : %s in %s : %s", newid, target, editErr)
@@ -497,7 +625,7 @@ func CloneVM(c *fiber.Ctx) error {
 // POST /api2/json/nodes/{node}/qemu/{vmid}}/template
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -511,6 +639,7 @@ func CreateTemplate(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to create template VM's body"})
 	}
 	vmid := fmt.Sprint(templateBody.VMID)
+	templateBody.Node = cluster.ResolveNode(vmid, templateBody.Node)
 
 	// check faculty, admin role
 	username := c.Query("username")
@@ -576,6 +705,8 @@ func CreateTemplate(c *fiber.Ctx) error {
 /*
 	using Query
 	@username - account's username
+	@tag - template has every tags, separated by ","
+	@search - template's name or VMID contains, case-insensitive
 */
 func GetTemplateList(c *fiber.Ctx) error {
 	var returnList []model.VMsInfo
@@ -592,14 +723,19 @@ func GetTemplateList(c *fiber.Ctx) error {
 		log.Println("Error: while getting user's group due to :", getGroupErr)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to getting user's group due to %s", getGroupErr)})
 	}
+	tags, search := listFilters(c)
 	if group == config.ADMIN {
-		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": templateList})
+		return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": filterVMList(templateList, tags, search)})
 	}
 
-	// get sizing templates's id
-	templates, getTemplatesErr := database.GetAllTemplatesID()
-	if getTemplatesErr != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting sizing templates list from DB due to %s", getTemplatesErr)})
+	// get id of images which are visible to user's group and have not been deprecated
+	catalog, getCatalogErr := database.GetImageCatalog(group)
+	if getCatalogErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed getting images list from DB due to %s", getCatalogErr)})
+	}
+	var templates []string
+	for _, image := range catalog {
+		templates = append(templates, image.VMID)
 	}
 	// get template from every pools that username is member
 	var poolInstances []string
@@ -622,22 +758,26 @@ func GetTemplateList(c *fiber.Ctx) error {
 		}
 	}
 	log.Println("Got VM Template list")
-	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": returnList})
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": filterVMList(returnList, tags, search)})
 }
 
-// EditVM - Set virtual machine options (asynchrounous API).
+// EditVM - Set virtual machine options (asynchrounous API), VM could be running
 // POST /api2/json/nodes/{node}/qemu/{vmid}/config
 // PUT /api2/json/nodes/{node}/qemu/{vmid}/resize
 /*
 	using Query Params
 	@username : account's username
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Request's Body
-	@cores : Amount of CPU core
-	@memory : Amount of RAM in (MB)
-	@disk : Amount of Disk (scsi0) to increase in Size_in_GiB format
+	@cores : Amount of CPU core, 0 : unchanged, running VM requires hotplug "cpu" & numa and is limited to sockets * cores
+		stopped VM which is able to hot-plug CPU gets HOTPLUG_MAX_CORES as cores and given cores as vcpus
+	@memory : Amount of RAM in (MB), 0 : unchanged, running VM requires hotplug "memory" & numa
+	@disk : Amount of Disk (scsi0) to increase in Size_in_GiB format, grown online
+	@data_disks : additional disks {size : in GiB, storage : empty : storage of scsi0}, running VM requires hotplug "disk"
+
+	cores, memory and disk could not be shrunk
 */
 func EditVM(c *fiber.Ctx) error {
 	// Getting request's body
@@ -646,14 +786,12 @@ func EditVM(c *fiber.Ctx) error {
 		log.Println("Error: Could not parse body parser to edit VM's body")
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit VM's body"})
 	}
-	editCores := fmt.Sprint(editBody.Cores)
-	editMemory := fmt.Sprint(editBody.Memory)
 	editMaxMemory := config.MBtoByte(editBody.Memory)
 
 	// Getting data from query & Mapping values
 	username := c.Query("username")
-	node := c.Query("node")
 	vmid := c.Query("vmid")
+	node := cluster.ResolveNode(vmid, c.Query("node"))
 	cookies := config.GetCookies(c)
 
 	// able to edit only own vm except requester is admin
@@ -662,13 +800,17 @@ func EditVM(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
 	}
 	if !owner {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed deleting VMID : %s due to user is not owner of VM", vmid)})
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to user is not owner of VM", vmid)})
+	}
+	instance, getInstanceErr := database.GetInstance(vmid)
+	if getInstanceErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, getInstanceErr)})
 	}
 	nodeInfo, nodeInfoErr := cluster.GetNode(node, cookies)
 	if nodeInfoErr != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to get node info for editing VM due to %s", nodeInfoErr)})
 	}
-	freeMemory, freeCPU, freeDisk := nodeInfo.MaxMem-nodeInfo.Mem, nodeInfo.MaxCPU-nodeInfo.CPU, nodeInfo.MaxDisk-nodeInfo.Disk
+	freeMemory, freeCPU := nodeInfo.MaxMem-nodeInfo.Mem, nodeInfo.MaxCPU-nodeInfo.CPU
 
 	// Check VM spec before edit configuration
 	vmGetURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", node, vmid))
@@ -677,90 +819,243 @@ func EditVM(c *fiber.Ctx) error {
 		log.Println(vm)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to get VM info for editing VM due to %s", vmInfoErr)})
 	}
-
-	// If target VM's status is not "stopped" then return
-	if vm.Info.Status != "stopped" {
-		log.Printf("Error: editing VMID : %s in %s due to VM has not been stopped", vmid, node)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Target VMID: %s in %s hasn't been stopped", vmid, node)})
+	vmConfigURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", node, vmid))
+	options, optionsErr := qemu.GetVMOptions(vmConfigURL, cookies)
+	if optionsErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed to get VM config for editing VM due to %s", optionsErr)})
 	}
-
-	// Parse mem, cpu, disk for checking free space
 	vmSpec := model.VMSpec{
 		Memory: vm.Info.MaxMem,
 		CPU:    vm.Info.CPUs,
 		Disk:   vm.Info.MaxDisk,
 	}
-	editBodyByteDisk := config.GBtoByte(editBody.Disk)
-	editBodyDisk := fmt.Sprint(`+`, editBodyByteDisk)
-
-	// Construct payload
-	data := url.Values{}
-	data.Set("cores", editCores)
-	data.Set("memory", editMemory)
-
-	resizeData := url.Values{}
-	resizeData.Set("disk", "scsi0")
-	resizeData.Set("size", editBodyDisk)
-	log.Println("resize data:", resizeData)
+	log.Printf("VM spec : {cpu: %f, mem: %d, disk: %d}", vmSpec.CPU, vmSpec.Memory, vmSpec.Disk)
 
-	extendMemory, extendCPU, extendDisk := editMaxMemory-vmSpec.Memory, editBody.Cores-vmSpec.CPU, editBodyByteDisk+vmSpec.Disk
-	if editMaxMemory < vmSpec.Memory {
-		extendMemory = vmSpec.Memory - editMaxMemory
+	// Proxmox is unable to shrink volume, and lowering cores or memory would take resources from guest OS
+	if editBody.Disk < 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not shrink disk of VMID : %s since disk is able to be grown only, move data to smaller VM instead", vmid)})
+	}
+	if editBody.Cores != 0 && editBody.Cores < vmSpec.CPU {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not shrink cores of VMID : %s from %v to %v since cores are able to be increased only, clone VM with smaller flavor instead", vmid, vmSpec.CPU, editBody.Cores)})
 	}
-	if editBody.Cores < vmSpec.CPU {
-		extendCPU = vmSpec.CPU - editBody.Cores
+	if editBody.Memory != 0 && editMaxMemory < vmSpec.Memory {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not shrink memory of VMID : %s from %d MB to %d MB since memory is able to be increased only, clone VM with smaller flavor instead", vmid, vmSpec.Memory/config.Megabyte, editBody.Memory)})
+	}
+	if editBody.Cores != float64(uint64(editBody.Cores)) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not edit VMID : %s due to cores : %v is not integer", vmid, editBody.Cores)})
 	}
 
-	log.Printf("VM spec : {cpu: %f, mem: %d, disk: %d}", vmSpec.CPU, vmSpec.Memory, vmSpec.Disk)
-	log.Printf("Edit spec : {cpu: %f, mem: %d, disk: %d}", editBody.Cores, editMaxMemory, extendDisk)
-	log.Printf("Free Node spec : {cpu: %f, mem: %d, disk: %d}", freeCPU, freeMemory, freeDisk)
-	log.Printf("Extended spec : {cpu: %f, mem: %d, disk: %d}", extendCPU, extendMemory, editBodyByteDisk)
-	log.Printf("Remain Node spec : {cpu: %f, mem: %d, disk: %d}", (freeCPU - extendCPU), (freeMemory - extendMemory), (freeDisk - editBodyByteDisk))
-
-	// Check free space of node
-	if freeCPU > extendCPU && freeMemory > extendMemory && freeDisk > editBodyByteDisk {
-		// Approve if request is spec increasing only
-		if config.GreaterOrEqual(editBody.Cores, vmSpec.CPU, editMaxMemory, vmSpec.Memory, editBodyByteDisk+vmSpec.Disk, vmSpec.Disk) {
-			log.Println("Able to edit VM config")
-			log.Printf("Editing VMID : %s in %s", vmid, node)
-			vmEditURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", node, vmid))
-			info, editErr := qemu.EditVM(vmEditURL, data, cookies)
-			if editErr != nil {
-				log.Printf("Error: editing VMID : %s in %s : %s", vmid, node, editErr)
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing VMID : %s in %s due to %s", vmid, node, editErr)})
+	// hot-plugging on running VM is limited by its hotplug features, otherwise VM has to be stopped
+	running := vm.Info.Status != "stopped"
+	hotplug := qemu.HotplugFeatures(options)
+	extend := model.VMSpec{}
+	data := url.Values{}
+	if editBody.Cores > vmSpec.CPU {
+		extend.CPU = editBody.Cores - vmSpec.CPU
+		if running {
+			if !config.Contains(hotplug, "cpu") || !qemu.IsNuma(options) {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not add cores to running VMID : %s since its hotplug does not include cpu or numa is disabled, stop VM first", vmid)})
 			}
-			log.Println(info)
-			resizeDiskURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/resize", node, vmid))
-			_, resizeInfoErr := qemu.ResizeDisk(resizeDiskURL, resizeData, cookies)
-			if resizeInfoErr != nil {
-				log.Printf("Error: editing disk on VMID : %s in %s : %s", vmid, node, resizeInfoErr)
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing disk on VMID : %s in %s due to %s", vmid, node, resizeInfoErr)})
+			if maxVCPUs := qemu.MaxVCPUs(options); uint64(editBody.Cores) > maxVCPUs {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not add cores to running VMID : %s beyond %d vCPUs (sockets * cores), stop VM first", vmid, maxVCPUs)})
 			}
-
-			// update vm spec in DB
-			updateErr := database.EditInstance(model.Instance{
-				MaxCPU:  editBody.Cores,
-				MaxRAM:  config.MBtoGB(editBody.Memory),
-				MaxDisk: float64(editBody.Disk),
-			})
-			if updateErr != nil {
-				log.Printf("Error: updating VM spec on VMID : %s in %s due to %s", vmid, node, updateErr)
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating VM spec on VMID : %s due to %s", vmid, updateErr)})
+			data.Set("vcpus", fmt.Sprint(editBody.Cores))
+		} else {
+			for key, values := range qemu.CPUOptions(uint64(editBody.Cores), options) {
+				data[key] = values
 			}
-			return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Edited VM : %s in %s successfully", vmid, node)})
 		}
+	}
+	if editMaxMemory > vmSpec.Memory {
+		extend.Memory = editMaxMemory - vmSpec.Memory
+		if running && (!config.Contains(hotplug, "memory") || !qemu.IsNuma(options)) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not add memory to running VMID : %s since its hotplug does not include memory or numa is disabled, stop VM first", vmid)})
+		}
+		data.Set("memory", fmt.Sprint(editBody.Memory))
+	}
+	// disks are grown on storage of their volumes, scsi0 is grown on its storage and data disk is on given storage or storage of scsi0
+	var dataDisk uint64
+	rootStorage := qemu.DiskStorage(options["scsi0"])
+	storageDisk := map[string]uint64{}
+	if len(editBody.DataDisks) > 0 {
+		if running && !config.Contains(hotplug, "disk") {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Could not attach disks to running VMID : %s since its hotplug does not include disk, stop VM first", vmid)})
+		}
+		buses, busesErr := qemu.NextSCSI(options, len(editBody.DataDisks))
+		if busesErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, busesErr)})
+		}
+		for i, disk := range editBody.DataDisks {
+			if disk.Size == 0 {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to size of data disk is empty", vmid)})
+			}
+			storage := disk.Storage
+			if storage == "" {
+				storage = rootStorage
+			}
+			data.Set(buses[i], fmt.Sprintf("%s:%d", storage, disk.Size))
+			dataDisk += config.GBtoByte(disk.Size)
+			storageDisk[storage] += config.GBtoByte(disk.Size)
+		}
+	}
+	growDisk := config.GBtoByte(uint64(editBody.Disk))
+	extend.Disk = growDisk + dataDisk
+	if growDisk > 0 {
+		storageDisk[rootStorage] += growDisk
+	}
+	if len(data) == 0 && growDisk == 0 {
 		log.Printf("Error: editing VMID : %s in %s due to request spec is lower or equal to current spec", vmid, node)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": "Could not edit VM due to request spec is lower or equal to current spec"})
 	}
-	log.Printf("Error: editing VMID : %s in %s due to have no enough free space", vmid, node)
-	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": "Node have no enough free space"})
+
+	log.Printf("Free Node spec : {cpu: %f, mem: %d}", freeCPU, freeMemory)
+	log.Printf("Extended spec : {cpu: %f, mem: %d, disk: %d}", extend.CPU, extend.Memory, extend.Disk)
+
+	// Check free space of node, disk is checked against storages since volumes are not on node's local disk
+	if freeCPU <= extend.CPU || freeMemory <= extend.Memory {
+		log.Printf("Error: editing VMID : %s in %s due to have no enough free space", vmid, node)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": "Node have no enough free space"})
+	}
+	for storage, disk := range storageDisk {
+		freeStorage, storageErr := cluster.GetStorageFree(storage, cookies)
+		if storageErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, storageErr)})
+		}
+		if freeStorage <= disk {
+			log.Printf("Error: editing VMID : %s due to storage : %s have no enough free space", vmid, storage)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Storage : %s have no enough free space", storage)})
+		}
+	}
+	// Check owner's instance limit, pool's quota and owner's allowance in pool against extended spec
+	if _, limitErr := database.CheckInstanceLimit(instance.OwnerID, extend, false); limitErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, limitErr)})
+	}
+	if instance.PoolID != 0 {
+		if _, quotaErr := database.CheckPoolQuota(instance.PoolID, instance.OwnerID, extend, false); quotaErr != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, quotaErr)})
+		}
+	}
+
+	log.Printf("Editing VMID : %s in %s (running : %t)", vmid, node, running)
+	// scsi0 is grown first, so growth which could not be undone is the only thing left when editing config has failed
+	if growDisk > 0 {
+		resizeData := url.Values{}
+		resizeData.Set("disk", "scsi0")
+		resizeData.Set("size", fmt.Sprint(`+`, growDisk))
+		log.Println("resize data:", resizeData)
+		resizeDiskURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/resize", node, vmid))
+		if _, resizeInfoErr := qemu.ResizeDisk(resizeDiskURL, resizeData, cookies); resizeInfoErr != nil {
+			log.Printf("Error: editing disk on VMID : %s in %s : %s", vmid, node, resizeInfoErr)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing disk on VMID : %s in %s due to %s", vmid, node, resizeInfoErr)})
+		}
+	}
+	if len(data) > 0 {
+		info, editErr := qemu.EditVM(vmConfigURL, data, cookies)
+		if editErr != nil {
+			log.Printf("Error: editing VMID : %s in %s : %s", vmid, node, editErr)
+			if growDisk > 0 {
+				if updateErr := database.EditInstance(model.Instance{VMID: vmid, MaxDisk: config.BytetoGB(vmSpec.Disk + growDisk)}); updateErr != nil {
+					log.Printf("Error: updating grown disk on VMID : %s due to %s, reconciliation is required", vmid, updateErr)
+				}
+			}
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing VMID : %s in %s due to %s", vmid, node, editErr)})
+		}
+		log.Println(info)
+	}
+
+	// update vm spec in DB
+	updateErr := database.EditInstance(model.Instance{
+		VMID:     vmid,
+		MaxCPU:   vmSpec.CPU + extend.CPU,
+		MaxRAM:   config.BytetoGB(vmSpec.Memory + extend.Memory),
+		MaxDisk:  config.BytetoGB(vmSpec.Disk + growDisk),
+		DataDisk: instance.DataDisk + config.BytetoGB(dataDisk),
+	})
+	if updateErr != nil {
+		log.Printf("Error: updating VM spec on VMID : %s in %s due to %s", vmid, node, updateErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Internal server error", "message": fmt.Sprintf("Failed updating VM spec on VMID : %s due to %s", vmid, updateErr)})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Edited VM : %s in %s successfully", vmid, node)})
+}
+
+// EditVMInfo - Replace VM's name, description and tags, VM could be running
+// POST /api2/json/nodes/{node}/qemu/{vmid}/config
+/*
+	using Params
+	@vmid : VM's ID
+
+	using Query
+	@username : account's username
+	@node : node's name (optional, resolved from VMID)
+
+	using Request's Body
+	@name : DNS name e.g. "web-01"
+	@description : notes, empty : removed
+	@tags : {a-z, 0-9, _, +, ., -}, empty : removed
+*/
+func EditVMInfo(c *fiber.Ctx) error {
+	infoBody := new(model.EditInfoBody)
+	if err := c.BodyParser(infoBody); err != nil {
+		log.Println("Error: Could not parse body parser to edit VM's info body")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to edit VM's info body"})
+	}
+	username := c.Query("username")
+	vmid := c.Params("vmid")
+	node := cluster.ResolveNode(vmid, c.Query("node"))
+	cookies := config.GetCookies(c)
+
+	// able to edit only own vm except requester is admin
+	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
+	if checkOwnerErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to %s", vmid, checkOwnerErr)})
+	}
+	if !owner {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to user is not owner of VM", vmid)})
+	}
+	if nameErr := qemu.ValidateName(infoBody.Name); nameErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, nameErr)})
+	}
+	tags, tagsErr := qemu.NormalizeTags(infoBody.Tags)
+	if tagsErr != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed editing VMID : %s due to %s", vmid, tagsErr)})
+	}
+
+	// empty description and tags are removed from config, since Proxmox ignores empty value
+	data := url.Values{}
+	data.Set("name", infoBody.Name)
+	var deleted []string
+	if description := strings.TrimSpace(infoBody.Description); description != "" {
+		data.Set("description", description)
+	} else {
+		deleted = append(deleted, "description")
+	}
+	if len(tags) > 0 {
+		data.Set("tags", strings.Join(tags, ";"))
+	} else {
+		deleted = append(deleted, "tags")
+	}
+	if len(deleted) > 0 {
+		data.Set("delete", strings.Join(deleted, ","))
+	}
+
+	log.Printf("Editing info of VMID : %s in %s", vmid, node)
+	vmEditURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", node, vmid))
+	if _, editErr := qemu.EditVM(vmEditURL, data, cookies); editErr != nil {
+		log.Printf("Error: editing info of VMID : %s in %s : %s", vmid, node, editErr)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed editing VMID : %s in %s due to %s", vmid, node, editErr)})
+	}
+	if renameErr := database.RenameInstance(vmid, infoBody.Name); renameErr != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": fmt.Sprintf("Failed renaming VMID : %s in DB due to %s", vmid, renameErr)})
+	}
+	log.Printf("Finished editing info of VMID : %s in %s", vmid, node)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": fmt.Sprintf("Edited info of VMID : %s successfully", vmid)})
 }
 
 // GetVncTicket - Get VNC Ticket from given VMID
 // POST /api2/json/nodes/{node}/qemu/{vmid}/vncproxy
 /*
 	using Request's Body
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -774,6 +1069,7 @@ func GetVncTicket(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "Failure", "message": "Failed parsing body parser to VNC Proxy body"})
 	}
 	vmid := fmt.Sprint(vncProxyBody.VMID)
+	vncProxyBody.Node = cluster.ResolveNode(vmid, vncProxyBody.Node)
 	username := c.Query("username")
 	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
 	if checkOwnerErr != nil {
@@ -801,7 +1097,7 @@ func GetVncTicket(c *fiber.Ctx) error {
 // GetVncConsole - Get VNC console URL from given VMID
 /*
 	using Params
-	@node : node's name
+	@node : node's name (optional, resolved from VMID)
 	@vmid : VM's ID
 
 	using Query
@@ -809,7 +1105,7 @@ func GetVncTicket(c *fiber.Ctx) error {
 */
 func GetVncConsole(c *fiber.Ctx) error {
 	vmid := c.Params("vmid")
-	node := c.Params("node")
+	node := cluster.ResolveNode(vmid, c.Params("node"))
 	username := c.Query("username")
 	owner, checkOwnerErr := database.CheckInstanceOwner(username, vmid)
 	if checkOwnerErr != nil {
@@ -818,7 +1114,7 @@ func GetVncConsole(c *fiber.Ctx) error {
 	if !owner {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "Bad request", "message": fmt.Sprintf("Failed getting VMID : %s due to user is not owner of VM", vmid)})
 	}
-	consoleURL := config.GetFromENV("PROXMOX_HOST") + fmt.Sprintf("/?console=kvm&novnc=1&vmid=%s&vmname=&node=%s&resize=off&cmd=", vmid, node)
+	consoleURL := config.Get().ProxmoxHost + fmt.Sprintf("/?console=kvm&novnc=1&vmid=%s&vmname=&node=%s&resize=off&cmd=", vmid, node)
 	log.Printf("Finished getting VNC console URL from VMID : %s in %s", vmid, node)
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "Success", "message": consoleURL})
 }
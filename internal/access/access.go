@@ -51,3 +51,35 @@ func DeleteUser(url string, cookies model.Cookies) (string, error) {
 	}
 	return string(body), nil
 }
+
+// GetUsersUsingToken - list every users in proxmox using api token
+func GetUsersUsingToken() ([]model.ProxmoxUser, error) {
+	users := model.ProxmoxUserList{}
+	url := config.GetURL("/api2/json/access/users")
+	body, err := config.SendRequestUsingToken(http.MethodGet, url, nil)
+	if err != nil {
+		return users.Users, err
+	}
+	if marshalErr := json.Unmarshal(body, &users); marshalErr != nil {
+		return users.Users, marshalErr
+	}
+	return users.Users, nil
+}
+
+// UpdateUserUsingToken - update user in proxmox using api token
+func UpdateUserUsingToken(url string, data url.Values) (string, error) {
+	body, err := config.SendRequestUsingToken(http.MethodPut, url, data)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// DeleteUserUsingToken - delete user in proxmox using api token
+func DeleteUserUsingToken(url string) (string, error) {
+	body, err := config.SendRequestUsingToken(http.MethodDelete, url, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
@@ -0,0 +1,357 @@
+// Package archive - Archiving pool at the end of semester, members's VMs are stopped, backed up then deleted
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/power"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/internal/task"
+	"github.com/edu-cloud-api/model"
+	"go.uber.org/zap"
+)
+
+// Concurrency - amount of VMs which are archived at the same time, backups are heavy on storage
+const Concurrency = 3
+
+// startMutex - prevent pool from being archived twice by scheduler and request at the same time
+var startMutex sync.Mutex
+
+// displayFormat - format of time in notification's message
+const displayFormat = "2006-01-02 15:04 MST"
+
+// WarnTime - time which pool's members are warned, nil when pool never expires
+func WarnTime(pool model.Pool, lifecycle model.PoolLifecycle) *time.Time {
+	if pool.ExpireTime == nil {
+		return nil
+	}
+	warnTime := pool.ExpireTime.In(config.Location()).AddDate(0, 0, -lifecycle.WarnDays)
+	return &warnTime
+}
+
+// vmActions - actions on member's VM in order
+func vmActions(lifecycle model.PoolLifecycle) []string {
+	var actions []string
+	if lifecycle.StopVMs || lifecycle.DeleteVMs {
+		actions = append(actions, "stop")
+	}
+	if lifecycle.Backup != config.ARCHIVE_NONE {
+		actions = append(actions, lifecycle.Backup)
+	}
+	if lifecycle.DeleteVMs {
+		actions = append(actions, "delete")
+	}
+	return actions
+}
+
+// templateActions - actions on pool's template in order, nil when templates are kept
+func templateActions(lifecycle model.PoolLifecycle) []string {
+	if lifecycle.Templates != config.TEMPLATE_ARCHIVE {
+		return nil
+	}
+	return []string{config.ARCHIVE_BACKUP, "remove from pool"}
+}
+
+// memberVMs - VMs which are charged to pool, pool's templates are excluded
+func memberVMs(pool model.Pool) []string {
+	var vmids []string
+	for _, vmid := range database.GetAllInstancesIDByPool(pool.ID) {
+		if !config.Contains(pool.VMID, vmid) {
+			vmids = append(vmids, vmid)
+		}
+	}
+	return vmids
+}
+
+// recipients - pool's owner, managers and members
+func recipients(pool model.Pool) []string {
+	usernames := []string{pool.Owner}
+	if managers, err := database.GetPoolManagers(pool.ID); err == nil {
+		for _, manager := range managers {
+			usernames = append(usernames, manager.Username)
+		}
+	}
+	for _, member := range pool.Member {
+		if !config.Contains(usernames, member) {
+			usernames = append(usernames, member)
+		}
+	}
+	return usernames
+}
+
+// Preview - report of what archiving pool would do without doing it
+func Preview(pool model.Pool, lifecycle model.PoolLifecycle) model.PoolArchivePreview {
+	preview := model.PoolArchivePreview{
+		Code:       pool.Code,
+		Owner:      pool.Owner,
+		ExpireTime: pool.ExpireTime,
+		WarnTime:   WarnTime(pool, lifecycle),
+		Lifecycle:  lifecycle,
+		VMs:        []model.PoolArchiveItem{},
+		Templates:  []model.PoolArchiveItem{},
+		Unenroll:   []string{},
+	}
+	if lifecycle.ArchivedAt != nil {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("pool has been archived at %s", lifecycle.ArchivedAt.In(config.Location()).Format(displayFormat)))
+	}
+	if pool.ExpireTime == nil {
+		preview.Warnings = append(preview.Warnings, "pool never expires, it is archived only on request")
+	} else if !lifecycle.AutoArchive {
+		preview.Warnings = append(preview.Warnings, "auto archive is disabled, pool is archived only on request")
+	}
+	for _, vmid := range memberVMs(pool) {
+		item := model.PoolArchiveItem{VMID: vmid, Actions: vmActions(lifecycle)}
+		if instance, err := database.GetInstance(vmid); err == nil {
+			item.Name, item.Owner, item.Node = instance.Name, instance.OwnerID, instance.Node
+		}
+		preview.VMs = append(preview.VMs, item)
+	}
+	if actions := templateActions(lifecycle); actions != nil {
+		for _, vmid := range pool.VMID {
+			item := model.PoolArchiveItem{VMID: vmid, Actions: actions}
+			if instance, err := database.GetInstance(vmid); err == nil {
+				item.Name, item.Owner, item.Node = instance.Name, instance.OwnerID, instance.Node
+			}
+			preview.Templates = append(preview.Templates, item)
+		}
+	}
+	if lifecycle.Unenroll {
+		preview.Unenroll = append(preview.Unenroll, pool.Member...)
+	}
+	return preview
+}
+
+// Warn - notifying pool's owner, managers and members that pool is going to be archived
+// pool without auto archive is only noticed that it expires, since nothing happens to it until archived on request
+func Warn(pool model.Pool, lifecycle model.PoolLifecycle) error {
+	expireTime := "never"
+	if pool.ExpireTime != nil {
+		expireTime = pool.ExpireTime.In(config.Location()).Format(displayFormat)
+	}
+	message := fmt.Sprintf("Pool %s of %s expires at %s.", pool.Code, pool.Owner, expireTime)
+	subject := fmt.Sprintf("Pool %s is going to expire", pool.Code)
+	if lifecycle.AutoArchive {
+		if actions := vmActions(lifecycle); len(actions) > 0 {
+			message += fmt.Sprintf(" VMs of pool will be : %s.", strings.Join(actions, ", "))
+		}
+		if lifecycle.Unenroll {
+			message += " Members will be removed from pool."
+		}
+		subject = fmt.Sprintf("Pool %s is going to be archived", pool.Code)
+	}
+	message += " Ask pool's owner to extend the pool if more time is needed."
+	if err := database.CreateNotifications(recipients(pool), config.NOTIFY_POOL_EXPIRING, subject, message); err != nil {
+		return err
+	}
+	return database.MarkPoolWarned(pool.ID)
+}
+
+// Start - creating archive task for every member's VMs and pool's templates and running it in background
+// requestID is empty when pool is archived by scheduler
+func Start(owner string, pool model.Pool, requestID string) (model.Task, error) {
+	startMutex.Lock()
+	defer startMutex.Unlock()
+	lifecycle, getLifecycleErr := database.GetPoolLifecycle(pool.ID)
+	if getLifecycleErr != nil {
+		return model.Task{}, getLifecycleErr
+	}
+	if lifecycle.ArchivedAt != nil {
+		return model.Task{}, fmt.Errorf("error: pool code : %s, owner : %s has already been archived", pool.Code, pool.Owner)
+	}
+	if lifecycle.ArchiveTaskID != 0 {
+		if running, err := database.GetTask(lifecycle.ArchiveTaskID); err == nil && (running.Status == config.TASK_PENDING || running.Status == config.TASK_RUNNING) {
+			return running, fmt.Errorf("error: pool code : %s, owner : %s is being archived by task ID : %d", pool.Code, pool.Owner, running.ID)
+		}
+	}
+	if validateErr := database.ValidatePoolLifecycle(lifecycle); validateErr != nil {
+		return model.Task{}, validateErr
+	}
+
+	targets := memberVMs(pool)
+	if templateActions(lifecycle) != nil {
+		targets = append(targets, pool.VMID...)
+	}
+	params, _ := json.Marshal(lifecycle)
+	archiveTask, createTaskErr := database.CreateTask(config.TASK_ARCHIVE, owner, fmt.Sprintf("%s/%s", pool.Code, pool.Owner), string(params), requestID, targets)
+	if createTaskErr != nil {
+		return archiveTask, createTaskErr
+	}
+	if setTaskErr := database.SetPoolArchiveTask(pool.ID, archiveTask.ID); setTaskErr != nil {
+		return archiveTask, setTaskErr
+	}
+	items, getItemsErr := database.GetTaskItems(archiveTask.ID)
+	if getItemsErr != nil {
+		return archiveTask, getItemsErr
+	}
+	go Run(archiveTask, items, pool, lifecycle)
+	logging.ForTask(archiveTask).Info("started archive task", zap.String("target", archiveTask.Target), zap.Int("vms", len(targets)))
+	return archiveTask, nil
+}
+
+// Run - archiving every pending items of archive task, then unenrolling members and marking pool as archived
+// pool is not marked as archived when some items have failed, so it could be archived again
+// Run blocks until every items have been finished, so it should be called as goroutine
+func Run(archiveTask model.Task, items []model.TaskItem, pool model.Pool, lifecycle model.PoolLifecycle) {
+	taskLogger := logging.ForTask(archiveTask)
+	database.UpdateTaskStatus(archiveTask.ID, config.TASK_RUNNING)
+	notified := recipients(pool)
+
+	task.ForEach(len(items), Concurrency, func(i int) {
+		item := items[i]
+		item.VMID = item.Target
+		item.Status = config.TASK_RUNNING
+		database.UpdateTaskItem(item)
+
+		var message string
+		var err error
+		if config.Contains(pool.VMID, item.Target) {
+			message, err = archiveTemplate(&item, pool, lifecycle)
+		} else {
+			message, err = archiveVM(&item, pool, lifecycle)
+		}
+		if err != nil {
+			taskLogger.Error("archiving failed", zap.String("vmid", item.Target), zap.Error(err))
+			item.Status, item.Message = config.TASK_FAILURE, err.Error()
+		} else {
+			item.Status, item.Message = config.TASK_SUCCESS, message
+		}
+		database.UpdateTaskItem(item)
+		database.RefreshTask(archiveTask.ID)
+	})
+
+	finished, refreshErr := database.RefreshTask(archiveTask.ID)
+	if refreshErr != nil || finished.Failed > 0 {
+		taskLogger.Warn("pool has not been archived, since some VMs could not be archived", zap.Uint64("failed", finished.Failed))
+		return
+	}
+	if lifecycle.Unenroll && len(pool.Member) > 0 {
+		if err := database.RemovePoolMembers(pool.ID, pool.Member); err != nil {
+			taskLogger.Error("unenrolling members failed", zap.Error(err))
+			database.UpdateTaskStatus(archiveTask.ID, config.TASK_FAILURE)
+			return
+		}
+	}
+	if err := database.MarkPoolArchived(pool.ID); err != nil {
+		taskLogger.Error("marking pool as archived failed", zap.Error(err))
+		database.UpdateTaskStatus(archiveTask.ID, config.TASK_FAILURE)
+		return
+	}
+	subject := fmt.Sprintf("Pool %s has been archived", pool.Code)
+	message := fmt.Sprintf("Pool %s of %s has been archived.", pool.Code, pool.Owner)
+	if actions := vmActions(lifecycle); len(actions) > 0 {
+		message += fmt.Sprintf(" VMs of pool have been : %s.", strings.Join(actions, ", "))
+	}
+	database.CreateNotifications(notified, config.NOTIFY_POOL_ARCHIVED, subject, message)
+	taskLogger.Info("finished archive task")
+}
+
+// archiveVM - stopping, backing up then deleting member's VM as configured
+func archiveVM(item *model.TaskItem, pool model.Pool, lifecycle model.PoolLifecycle) (string, error) {
+	instance, getInstanceErr := database.GetInstance(item.Target)
+	if getInstanceErr != nil {
+		return "", getInstanceErr
+	}
+	instance.Node = cluster.ResolveNode(instance.VMID, instance.Node)
+	item.Node, item.Name = instance.Node, instance.Name
+
+	var done []string
+	if lifecycle.StopVMs || lifecycle.DeleteVMs {
+		if _, stopErr := power.Do(instance.Node, instance.VMID, "stop"); stopErr != nil {
+			return "", stopErr
+		}
+		done = append(done, "stopped")
+	}
+	switch lifecycle.Backup {
+	case config.ARCHIVE_SNAPSHOT:
+		if err := snapshot(instance, pool); err != nil {
+			return "", err
+		}
+		done = append(done, "snapshotted")
+	case config.ARCHIVE_BACKUP:
+		if err := backup(instance, pool, lifecycle); err != nil {
+			return "", err
+		}
+		done = append(done, "backed up")
+	}
+	if lifecycle.DeleteVMs {
+		vmDeleteURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s", instance.Node, instance.VMID))
+		if deleteErr := qemu.DeleteVMUsingToken(vmDeleteURL); deleteErr != nil {
+			return "", fmt.Errorf("error: unable to delete VMID : %s in %s due to %s", instance.VMID, instance.Node, deleteErr)
+		}
+		if !qemu.DeleteCompletely(instance.Node, instance.VMID) {
+			return "", fmt.Errorf("error: VMID : %s in %s hasn't been deleted correctly", instance.VMID, instance.Node)
+		}
+		if deleteInstanceErr := database.DeleteInstance(instance.VMID); deleteInstanceErr != nil {
+			return "", deleteInstanceErr
+		}
+		done = append(done, "deleted")
+	}
+	if len(done) == 0 {
+		return fmt.Sprintf("VMID : %s has been kept as it is", instance.VMID), nil
+	}
+	return fmt.Sprintf("VMID : %s has been %s", instance.VMID, strings.Join(done, ", ")), nil
+}
+
+// archiveTemplate - backing up pool's template then removing it from pool, template is kept by its owner
+func archiveTemplate(item *model.TaskItem, pool model.Pool, lifecycle model.PoolLifecycle) (string, error) {
+	instance, getInstanceErr := database.GetInstance(item.Target)
+	if getInstanceErr != nil {
+		return "", getInstanceErr
+	}
+	instance.Node = cluster.ResolveNode(instance.VMID, instance.Node)
+	item.Node, item.Name = instance.Node, instance.Name
+	if err := backup(instance, pool, lifecycle); err != nil {
+		return "", err
+	}
+	if err := database.RemovePoolTemplates(pool.ID, []string{instance.VMID}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("template VMID : %s has been backed up and removed from pool", instance.VMID), nil
+}
+
+// snapshot - taking snapshot of VM named after archived date and waiting until finished
+func snapshot(instance model.Instance, pool model.Pool) error {
+	data := url.Values{}
+	data.Set("snapname", "archive_"+config.Now().Format("20060102_1504"))
+	data.Set("description", fmt.Sprintf("archived from pool %s of %s", pool.Code, pool.Owner))
+	log.Printf("Taking snapshot of VMID : %s in %s", instance.VMID, instance.Node)
+	snapshotURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/snapshot", instance.Node, instance.VMID))
+	response, err := qemu.SnapshotVMUsingToken(snapshotURL, data)
+	if err != nil {
+		return fmt.Errorf("error: unable to take snapshot of VMID : %s due to %s", instance.VMID, err)
+	}
+	return qemu.WaitTask(instance.Node, response.Info, config.Get().BackupTimeout, (5 * time.Second))
+}
+
+// backup - backing up VM with vzdump into pool's backup storage and waiting until finished
+func backup(instance model.Instance, pool model.Pool, lifecycle model.PoolLifecycle) error {
+	data := url.Values{}
+	data.Set("vmid", instance.VMID)
+	data.Set("mode", "snapshot")
+	data.Set("compress", "zstd")
+	data.Set("notes-template", fmt.Sprintf("{{guestname}} archived from pool %s of %s", pool.Code, pool.Owner))
+	storage := lifecycle.BackupStorage
+	if storage == "" {
+		storage = config.Get().BackupStorage
+	}
+	if storage != "" {
+		data.Set("storage", storage)
+	}
+	log.Printf("Backing up VMID : %s in %s", instance.VMID, instance.Node)
+	backupURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/vzdump", instance.Node))
+	response, err := qemu.BackupVMUsingToken(backupURL, data)
+	if err != nil {
+		return fmt.Errorf("error: unable to back up VMID : %s due to %s", instance.VMID, err)
+	}
+	return qemu.WaitTask(instance.Node, response.Info, config.Get().BackupTimeout, (5 * time.Second))
+}
@@ -4,24 +4,33 @@ package cluster
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"math"
 	"net/http"
-	"regexp"
 	"strings"
 
 	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
 	"github.com/edu-cloud-api/model"
 )
 
 // AllocateNode - allocate which node is the best choice to have interaction with (e.g. cloning, creating)
 // GET /api2/json/cluster/resources
 func AllocateNode(spec model.VMSpec, storage string, cookies model.Cookies) ([]model.Node, string, error) {
+	return AllocateNodeExcept(spec, storage, nil, cookies)
+}
+
+// AllocateNodeExcept - allocate the best node like AllocateNode but never select nodes in except (e.g. migrating, draining)
+// storage is not checked when storage is empty, since migrated VM's disk stays on shared storage
+// cordoned nodes are never selected
+// GET /api2/json/cluster/resources
+func AllocateNodeExcept(spec model.VMSpec, storage string, except []string, cookies model.Cookies) ([]model.Node, string, error) {
 	log.Println("Getting nodes from cluster's resources ...")
-	url := config.GetURL("/api2/json/cluster/resources")
+	except = append(CordonedNodes(), except...)
 	nodeResource := model.NodeResource{}
 	storageResource := model.StorageResource{}
-	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+	body, err := getResources(cookies)
 	if err != nil {
 		return []model.Node{}, "", err
 	}
@@ -38,12 +47,15 @@ func AllocateNode(spec model.VMSpec, storage string, cookies model.Cookies) ([]m
 		}
 	}
 	maxFreeDisk := selectedStorage.MaxDisk - selectedStorage.Disk
+	if storage == "" {
+		maxFreeDisk = math.MaxUint64
+	}
 	log.Printf("storage: %s, free disk: %d", selectedStorage.Storage, maxFreeDisk)
 	// Regex and return only worker nodes
 	var nodeList []model.Node
 	for j := 0; j < len(nodeResource.Nodes); j++ {
-		r, _ := regexp.Compile(config.WorkerNode) // match node which start with work-{number}
-		if nodeResource.Nodes[j].Type == "node" && r.MatchString(nodeResource.Nodes[j].Node) && nodeResource.Nodes[j].Status != "offline" {
+		r := config.Get().NodeSelector // match worker node, default : node which start with work-{number}
+		if nodeResource.Nodes[j].Type == "node" && r.MatchString(nodeResource.Nodes[j].Node) && nodeResource.Nodes[j].Status != "offline" && !config.Contains(except, nodeResource.Nodes[j].Node) {
 			nodeList = append(nodeList, nodeResource.Nodes[j])
 		}
 	}
@@ -63,8 +75,8 @@ func AllocateNode(spec model.VMSpec, storage string, cookies model.Cookies) ([]m
 		}
 	}
 	log.Printf("selected node: %s, free mem: %d, free cpu: %f, free disk: %d", selectedNode.Node, maxFreeMemory/config.Gigabyte, maxFreeCPU, maxFreeDisk/config.Gigabyte)
-	log.Printf("vm spec mem: %d, cpu: %f, disk: %d", spec.Memory/config.Gigabyte, spec.CPU, spec.Disk/config.Gigabyte)
-	if maxFreeMemory > spec.Memory && maxFreeCPU > spec.CPU && maxFreeDisk > spec.Disk {
+	log.Printf("vm spec mem: %d, cpu: %f, disk: %d", spec.Memory/config.Gigabyte, spec.CPU, spec.ChargedDisk()/config.Gigabyte)
+	if maxFreeMemory > spec.Memory && maxFreeCPU > spec.CPU && maxFreeDisk > spec.ChargedDisk() {
 		log.Printf("Return selected node : %s", selectedNode.Node)
 		return nodeList, selectedNode.Node, nil
 	}
@@ -98,11 +110,31 @@ func GetStorageList(cookies model.Cookies) ([]string, error) {
 	return storages, nil
 }
 
+// GetStorageFree - Getting free space of RBD storage by given name, storage which is not RBD storage of cluster is rejected
+// GET /api2/json/cluster/resources
+func GetStorageFree(storage string, cookies model.Cookies) (uint64, error) {
+	storageResource := model.StorageResource{}
+	body, err := getResources(cookies)
+	if err != nil {
+		return 0, err
+	}
+	if marshalErr := json.Unmarshal(body, &storageResource); marshalErr != nil {
+		return 0, marshalErr
+	}
+	for _, s := range storageResource.Storages {
+		if s.Type == "storage" && s.Storage == storage && s.PluginType == "rbd" {
+			return s.MaxDisk - s.Disk, nil
+		}
+	}
+	log.Printf("Error: Storage : %s is not RBD storage of cluster", storage)
+	return 0, fmt.Errorf("error: storage : %s is not RBD storage of cluster", storage)
+}
+
 // GetISOList - Getting ISO file list
 // GET /api2/json/nodes/{node}/storage/{storage}/content
 func GetISOList(cookies model.Cookies) ([]string, error) {
 	log.Println("Getting ISO file list from cluster's resources ...")
-	url := config.GetURL("/api2/json/nodes/ops1/storage/cephfs/content")
+	url := config.GetURL(fmt.Sprintf("/api2/json/nodes/ops1/storage/%s/content", config.Get().ISOStorage))
 	storageContent := model.ISOList{}
 	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
 	if err != nil {
@@ -113,7 +145,7 @@ func GetISOList(cookies model.Cookies) ([]string, error) {
 	}
 	var ISOList []string
 	for _, iso := range storageContent.ISOList {
-		ISOList = append(ISOList, strings.TrimPrefix(iso.Volid, config.ISO))
+		ISOList = append(ISOList, strings.TrimPrefix(iso.Volid, config.Get().ISO()))
 	}
 	log.Println(ISOList)
 	return ISOList, nil
@@ -123,9 +155,8 @@ func GetISOList(cookies model.Cookies) ([]string, error) {
 // GET /api2/json/cluster/resources
 func GetNodes(cookies model.Cookies) ([]model.Node, error) {
 	log.Println("Getting node information from given node ...")
-	url := config.GetURL("/api2/json/cluster/resources")
 	nodeResource := model.NodeResource{}
-	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+	body, err := getResources(cookies)
 	if err != nil {
 		return []model.Node{}, err
 	}
@@ -135,9 +166,9 @@ func GetNodes(cookies model.Cookies) ([]model.Node, error) {
 	// Regex and return only worker nodes
 	var nodeList []model.Node
 	for i := 0; i < len(nodeResource.Nodes); i++ {
-		r, _ := regexp.Compile(config.WorkerNode) // match node which start with work-{number}
+		r := config.Get().NodeSelector // match worker node, default : node which start with work-{number}
 		if nodeResource.Nodes[i].Type == "node" && r.MatchString(nodeResource.Nodes[i].Node) {
-			nodeList = append(nodeList, nodeResource.Nodes[i])
+			nodeList = append(nodeList, withCordon(nodeResource.Nodes[i]))
 		}
 	}
 	return nodeList, nil
@@ -159,7 +190,7 @@ func GetNode(name string, cookies model.Cookies) (model.Node, error) {
 	// Regex and return only worker nodes
 	var nodeList []model.Node
 	for i := 0; i < len(nodeResource.Nodes); i++ {
-		r, _ := regexp.Compile(config.WorkerNode) // match node which start with work-{number}
+		r := config.Get().NodeSelector // match worker node, default : node which start with work-{number}
 		if nodeResource.Nodes[i].Type == "node" && r.MatchString(nodeResource.Nodes[i].Node) {
 			nodeList = append(nodeList, nodeResource.Nodes[i])
 		}
@@ -167,8 +198,90 @@ func GetNode(name string, cookies model.Cookies) (model.Node, error) {
 	matchNode := model.Node{}
 	for _, node := range nodeList {
 		if node.Node == name {
-			matchNode = node
+			matchNode = withCordon(node)
 		}
 	}
 	return matchNode, nil
 }
+
+// SpreadNodes - allocate nodes for given amount of VMs which have the same spec
+// each VM is placed on the node which has the most free memory left after previous placements
+// GET /api2/json/cluster/resources
+func SpreadNodes(spec model.VMSpec, storage string, count int, cookies model.Cookies) ([]string, error) {
+	log.Println("Getting nodes from cluster's resources ...")
+	cordoned := CordonedNodes()
+	nodeResource := model.NodeResource{}
+	storageResource := model.StorageResource{}
+	body, err := getResources(cookies)
+	if err != nil {
+		return []string{}, err
+	}
+	if marshalErr := json.Unmarshal(body, &nodeResource); marshalErr != nil {
+		return []string{}, marshalErr
+	}
+	if marshalStorageErr := json.Unmarshal(body, &storageResource); marshalStorageErr != nil {
+		return []string{}, marshalStorageErr
+	}
+	var freeDisk uint64
+	for _, s := range storageResource.Storages {
+		if s.Type == "storage" && s.Storage == storage && s.PluginType == "rbd" {
+			freeDisk = s.MaxDisk - s.Disk
+		}
+	}
+	if freeDisk < spec.ChargedDisk()*uint64(count) {
+		log.Printf("Storage : %s have no enough free space for %d VMs", storage, count)
+		return []string{}, errors.New("error: Storage have no enough free space")
+	}
+
+	// Regex and return only worker nodes
+	r := config.Get().NodeSelector // match worker node, default : node which start with work-{number}
+	var nodeList []model.Node
+	for _, node := range nodeResource.Nodes {
+		if node.Type == "node" && r.MatchString(node.Node) && node.Status != "offline" && !config.Contains(cordoned, node.Node) {
+			nodeList = append(nodeList, node)
+		}
+	}
+	freeMemory := make([]uint64, len(nodeList))
+	freeCPU := make([]float64, len(nodeList))
+	for i, node := range nodeList {
+		freeMemory[i], freeCPU[i] = node.MaxMem-node.Mem, node.MaxCPU-node.CPU
+	}
+
+	targets := make([]string, 0, count)
+	for n := 0; n < count; n++ {
+		selected := -1
+		for i := range nodeList {
+			if freeMemory[i] > spec.Memory && freeCPU[i] > spec.CPU && (selected == -1 || freeMemory[i] > freeMemory[selected]) {
+				selected = i
+			}
+		}
+		if selected == -1 {
+			log.Printf("Nodes have no enough free space for %d VMs", count)
+			return targets, errors.New("error: Node have no enough free space")
+		}
+		freeMemory[selected] -= spec.Memory
+		freeCPU[selected] -= spec.CPU
+		targets = append(targets, nodeList[selected].Node)
+	}
+	log.Printf("Spread %d VMs to nodes : %v", count, targets)
+	return targets, nil
+}
+
+// withCordon - fill cordon state of node from node_state or CORDONED_NODES
+func withCordon(node model.Node) model.Node {
+	state := database.GetNodeState(node.Node)
+	node.Cordoned, node.CordonReason = state.Cordoned, state.Reason
+	if !node.Cordoned && config.Contains(labeledNodes(), node.Node) {
+		node.Cordoned, node.CordonReason = true, "labeled in CORDONED_NODES"
+	}
+	return node
+}
+
+// getResources - GET /api2/json/cluster/resources, using api token when cookies are empty (e.g. scheduled jobs)
+func getResources(cookies model.Cookies) ([]byte, error) {
+	url := config.GetURL("/api2/json/cluster/resources")
+	if cookies.Cookie.Value == "" {
+		return config.SendRequestUsingToken(http.MethodGet, url, nil)
+	}
+	return config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+}
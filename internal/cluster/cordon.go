@@ -0,0 +1,24 @@
+// Package cluster - Cluster functions
+package cluster
+
+import (
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+)
+
+// CordonedNodes - getting nodes which must not be allocated for new VMs
+// node is cordoned in node_state or labeled in CORDONED_NODES
+func CordonedNodes() []string {
+	nodes := database.GetCordonedNodes()
+	for _, node := range labeledNodes() {
+		if !config.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// labeledNodes - getting nodes which are labeled as cordoned in CORDONED_NODES, e.g. "work-3,work-7"
+func labeledNodes() []string {
+	return config.Get().CordonedNodes
+}
@@ -0,0 +1,96 @@
+// Package cluster - Cluster functions
+package cluster
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
+)
+
+// LocateTTL - how long VM's node from cluster's resources is cached
+const LocateTTL = 10 * time.Second
+
+// LocateMissTTL - how long VM which is not found in cluster is not reloaded again
+const LocateMissTTL = 2 * time.Second
+
+var (
+	locateMutex   sync.RWMutex // guarding locateCache and locateRefresh, never held during I/O
+	refreshMutex  sync.Mutex   // only one reload of cluster's resources at a time
+	locateCache   = map[string]string{}
+	locateRefresh time.Time
+)
+
+// cachedLocation - getting VM's node from cache and time which cache has been reloaded
+func cachedLocation(vmid string) (string, bool, time.Time) {
+	locateMutex.RLock()
+	defer locateMutex.RUnlock()
+	node, found := locateCache[vmid]
+	return node, found, locateRefresh
+}
+
+// refreshLocations - reload every VM's node from cluster's resources
+// callers waiting for the same reload are served by it instead of reloading again
+func refreshLocations(since time.Time) error {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	if _, _, refreshed := cachedLocation(""); refreshed.After(since) {
+		return nil
+	}
+	resources, err := qemu.GetResourcesUsingToken()
+	if err != nil {
+		return err
+	}
+	locations := map[string]string{}
+	for _, resource := range resources {
+		locations[fmt.Sprint(resource.VMID)] = resource.Node
+	}
+	locateMutex.Lock()
+	locateCache = locations
+	locateRefresh = time.Now()
+	locateMutex.Unlock()
+	return nil
+}
+
+// LocateVM - getting VM's current node from cluster's resources with short-lived cache
+// Instance.Node is updated when VM has been moved to another node
+func LocateVM(vmid string) (string, error) {
+	node, found, refreshed := cachedLocation(vmid)
+	// reload when cache has expired or VM is not found, e.g. VM has just been created
+	if time.Since(refreshed) > LocateTTL || (!found && time.Since(refreshed) > LocateMissTTL) {
+		if err := refreshLocations(refreshed); err != nil {
+			return "", fmt.Errorf("error: unable to locate VMID : %s due to %s", vmid, err)
+		}
+		node, found, _ = cachedLocation(vmid)
+	}
+	if !found {
+		return "", fmt.Errorf("error: VMID : %s is not found in cluster", vmid)
+	}
+	if instance, err := database.GetInstance(vmid); err == nil && instance.Node != node {
+		log.Printf("VMID : %s has been moved from %s to %s", vmid, instance.Node, node)
+		database.SetInstanceNode(vmid, node)
+	}
+	return node, nil
+}
+
+// ResolveNode - getting VM's current node, given node is used when VM could not be located
+func ResolveNode(vmid, fallback string) string {
+	node, err := LocateVM(vmid)
+	if err != nil {
+		log.Println(err)
+		return fallback
+	}
+	return node
+}
+
+// ForgetVM - remove VM from cache after VM has been deleted or migrated
+// cache is expired as well, so VM's new node is reloaded on next lookup
+func ForgetVM(vmid string) {
+	locateMutex.Lock()
+	defer locateMutex.Unlock()
+	delete(locateCache, vmid)
+	locateRefresh = time.Time{}
+}
@@ -0,0 +1,58 @@
+// Package directory - Reading users from class roster or directory
+package directory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/edu-cloud-api/model"
+)
+
+// ReadCSV - reading users from class roster in CSV with header
+// required column : username, optional columns : name, email, group
+func ReadCSV(r io.Reader) ([]model.ImportUser, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error: unable to read header of CSV due to %s", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("error: CSV has no username column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var users []model.ImportUser
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return users, fmt.Errorf("error: unable to read CSV due to %s", readErr)
+		}
+		if strings.TrimSpace(field(record, "username")) == "" {
+			continue
+		}
+		users = append(users, model.ImportUser{
+			Username: field(record, "username"),
+			Name:     field(record, "name"),
+			Email:    field(record, "email"),
+			Group:    field(record, "group"),
+		})
+	}
+	return users, nil
+}
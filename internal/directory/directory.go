@@ -0,0 +1,71 @@
+// Package directory - Reading users from class roster or directory
+package directory
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+)
+
+// Directory - source of users which can be imported
+type Directory interface {
+	Search(filter string) ([]model.ImportUser, error)
+}
+
+var validUsername = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// Normalize - trim user's fields, set default group and validate username, group
+func Normalize(user model.ImportUser) (model.ImportUser, error) {
+	user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+	user.Name = strings.TrimSpace(user.Name)
+	user.Email = strings.TrimSpace(user.Email)
+	user.Group = strings.ToLower(strings.TrimSpace(user.Group))
+	if user.Group == "" {
+		user.Group = config.STUDENT
+	}
+	if !validUsername.MatchString(user.Username) {
+		return user, fmt.Errorf("error: username : %s is invalid", user.Username)
+	}
+	if user.Group != config.STUDENT && user.Group != config.FACULTY {
+		return user, fmt.Errorf("error: group : %s of username : %s is not allowed to import", user.Group, user.Username)
+	}
+	return user, nil
+}
+
+// staticScheme - LDAP_URL's scheme of static directory, e.g. "file:///etc/edu-cloud/roster.csv"
+const staticScheme = "file://"
+
+// NewFromENV - creating directory from LDAP_URL, CSV roster of "file://" is used as static directory
+func NewFromENV() (Directory, error) {
+	url := config.GetFromENV("LDAP_URL")
+	if !strings.HasPrefix(url, staticScheme) {
+		return NewLDAPFromENV()
+	}
+	return NewStaticFromCSV(strings.TrimPrefix(url, staticScheme))
+}
+
+// Static - directory which returns fixed users, for developing and testing without LDAP server
+type Static []model.ImportUser
+
+// NewStaticFromCSV - creating static directory from class roster in CSV file
+func NewStaticFromCSV(path string) (Static, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, fmt.Errorf("error: unable to open static directory due to %s", openErr)
+	}
+	defer file.Close()
+	users, readErr := ReadCSV(file)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return Static(users), nil
+}
+
+// Search - returning every users, filter is ignored
+func (s Static) Search(filter string) ([]model.ImportUser, error) {
+	return s, nil
+}
@@ -0,0 +1,84 @@
+// Package directory - Reading users from class roster or directory
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP - directory which reads users from LDAP server
+type LDAP struct {
+	URL          string
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	UserAttr     string // attribute of username, default : uid
+	NameAttr     string // attribute of name, default : cn
+	MailAttr     string // attribute of email, default : mail
+}
+
+// NewLDAPFromENV - creating LDAP directory from LDAP_* environment variables
+func NewLDAPFromENV() (*LDAP, error) {
+	directory := &LDAP{
+		URL:          config.GetFromENV("LDAP_URL"),
+		BindDN:       config.GetFromENV("LDAP_BIND_DN"),
+		BindPassword: config.GetFromENV("LDAP_BIND_PASSWORD"),
+		BaseDN:       config.GetFromENV("LDAP_BASE_DN"),
+		UserAttr:     config.GetFromENV("LDAP_USER_ATTR"),
+		NameAttr:     config.GetFromENV("LDAP_NAME_ATTR"),
+		MailAttr:     config.GetFromENV("LDAP_MAIL_ATTR"),
+	}
+	if directory.URL == "" || directory.BaseDN == "" {
+		return nil, errors.New("error: LDAP_URL and LDAP_BASE_DN are required")
+	}
+	if directory.UserAttr == "" {
+		directory.UserAttr = "uid"
+	}
+	if directory.NameAttr == "" {
+		directory.NameAttr = "cn"
+	}
+	if directory.MailAttr == "" {
+		directory.MailAttr = "mail"
+	}
+	return directory, nil
+}
+
+// Search - searching users under BaseDN by given LDAP filter
+func (d *LDAP) Search(filter string) ([]model.ImportUser, error) {
+	if filter == "" {
+		filter = fmt.Sprintf("(%s=*)", d.UserAttr)
+	}
+	conn, err := ldap.DialURL(d.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error: unable to connect LDAP due to %s", err)
+	}
+	defer conn.Close()
+	if d.BindDN != "" {
+		if bindErr := conn.Bind(d.BindDN, d.BindPassword); bindErr != nil {
+			return nil, fmt.Errorf("error: unable to bind LDAP due to %s", bindErr)
+		}
+	}
+	log.Printf("Searching LDAP users in %s by filter : %s", d.BaseDN, filter)
+	request := ldap.NewSearchRequest(
+		d.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		filter, []string{d.UserAttr, d.NameAttr, d.MailAttr}, nil,
+	)
+	result, searchErr := conn.SearchWithPaging(request, 500)
+	if searchErr != nil {
+		return nil, fmt.Errorf("error: unable to search LDAP due to %s", searchErr)
+	}
+	var users []model.ImportUser
+	for _, entry := range result.Entries {
+		users = append(users, model.ImportUser{
+			Username: entry.GetAttributeValue(d.UserAttr),
+			Name:     entry.GetAttributeValue(d.NameAttr),
+			Email:    entry.GetAttributeValue(d.MailAttr),
+		})
+	}
+	return users, nil
+}
@@ -0,0 +1,186 @@
+// Package lifecycle - User's lifecycle which keeps DB and Proxmox in sync
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/access"
+	"github.com/edu-cloud-api/model"
+)
+
+// userURL - getting Proxmox's URL of given username
+func userURL(username string) string {
+	return config.GetURL(fmt.Sprintf("/api2/json/access/users/%s%s", username, config.REALM))
+}
+
+// enableValue - converting status into Proxmox's enable value
+func enableValue(enabled bool) string {
+	if enabled {
+		return "1"
+	}
+	return "0"
+}
+
+// CreateUser - creating user in Proxmox, DB and default instance limit
+// every created parts are removed when any step has failed
+func CreateUser(body *model.CreateUserDB) error {
+	if body.Group != config.STUDENT && body.Group != config.FACULTY && body.Group != config.ADMIN {
+		return fmt.Errorf("error: group : %s is invalid", body.Group)
+	}
+	if body.Username == "" || body.Password == "" {
+		return errors.New("error: username and password are required")
+	}
+	if _, getGroupErr := database.GetUserGroup(body.Username); getGroupErr == nil {
+		return fmt.Errorf("error: username : %s is duplicated", body.Username)
+	}
+
+	// Creating User in Proxmox
+	data := url.Values{}
+	data.Set("userid", fmt.Sprintf("%s%s", body.Username, config.REALM))
+	data.Set("password", body.Password)
+	data.Set("groups", body.Group)
+	if body.Email != "" {
+		data.Set("email", body.Email)
+	}
+	log.Printf("Creating user : %s in Proxmox", body.Username)
+	if _, createErr := access.CreateUser(data); createErr != nil {
+		return fmt.Errorf("error: unable to create user in Proxmox due to %s", createErr)
+	}
+
+	// Creating User, User's limit in DB
+	log.Printf("Creating user : %s in DB", body.Username)
+	if _, createErr := database.CreateUserDB(body); createErr != nil {
+		compensateDelete(body.Username)
+		return createErr
+	}
+	if createLimitErr := database.CreateInstanceLimit(body.Username, body.Group); createLimitErr != nil {
+		if deleteErr := database.DeleteUserDB(body.Username, body.Group); deleteErr != nil {
+			log.Printf("Error: Could not remove user : %s from DB after failure due to %s", body.Username, deleteErr)
+		}
+		compensateDelete(body.Username)
+		return createLimitErr
+	}
+	log.Printf("Finished creating user : %s", body.Username)
+	return nil
+}
+
+// compensateDelete - removing user from Proxmox after DB has failed
+func compensateDelete(username string) {
+	log.Printf("Removing user : %s from Proxmox due to failure in DB", username)
+	if _, deleteErr := access.DeleteUserUsingToken(userURL(username)); deleteErr != nil {
+		log.Printf("Error: Could not remove user : %s from Proxmox due to %s, reconciliation is required", username, deleteErr)
+	}
+}
+
+// UpdateUser - updating user in DB then email, status in Proxmox
+// DB is reverted when Proxmox has failed
+func UpdateUser(username string, body *model.EditUserDB) error {
+	group, getGroupErr := database.GetUserGroup(username)
+	if getGroupErr != nil {
+		return getGroupErr
+	}
+	previous, getUserErr := database.GetUser(username, group)
+	if getUserErr != nil {
+		return getUserErr
+	}
+	if editErr := database.EditUser(username, group, body); editErr != nil {
+		return editErr
+	}
+	// status is changed only from false to true, same as DB's update
+	enabled := previous.Status || body.Status
+	data := url.Values{}
+	data.Set("enable", enableValue(enabled))
+	if body.Email != "" {
+		data.Set("email", body.Email)
+	}
+	if _, updateErr := access.UpdateUserUsingToken(userURL(username), data); updateErr != nil {
+		log.Printf("Error: Could not update user : %s in Proxmox due to %s, reverting DB", username, updateErr)
+		revert := &model.EditUserDB{
+			Password:   previous.Password,
+			Name:       previous.Name,
+			Email:      previous.Email,
+			Status:     previous.Status,
+			ExpireTime: config.FormatTime(previous.ExpireTime),
+		}
+		if revertErr := database.EditUser(username, group, revert); revertErr != nil {
+			log.Printf("Error: Could not revert user : %s in DB due to %s, reconciliation is required", username, revertErr)
+		}
+		if statusErr := database.SetUserStatus(username, group, previous.Status); statusErr != nil {
+			log.Printf("Error: Could not revert status of user : %s due to %s", username, statusErr)
+		}
+		return fmt.Errorf("error: unable to update user in Proxmox due to %s", updateErr)
+	}
+	log.Printf("Finished updating user : %s", username)
+	return nil
+}
+
+// SetUserEnabled - enabling or disabling user in DB then Proxmox
+// DB is reverted when Proxmox has failed
+func SetUserEnabled(username string, enabled bool) error {
+	group, getGroupErr := database.GetUserGroup(username)
+	if getGroupErr != nil {
+		return getGroupErr
+	}
+	previous, getUserErr := database.GetUser(username, group)
+	if getUserErr != nil {
+		return getUserErr
+	}
+	if statusErr := database.SetUserStatus(username, group, enabled); statusErr != nil {
+		return statusErr
+	}
+	data := url.Values{}
+	data.Set("enable", enableValue(enabled))
+	if _, updateErr := access.UpdateUserUsingToken(userURL(username), data); updateErr != nil {
+		log.Printf("Error: Could not set enable of user : %s in Proxmox due to %s, reverting DB", username, updateErr)
+		if revertErr := database.SetUserStatus(username, group, previous.Status); revertErr != nil {
+			log.Printf("Error: Could not revert status of user : %s due to %s, reconciliation is required", username, revertErr)
+		}
+		return fmt.Errorf("error: unable to set enable of user in Proxmox due to %s", updateErr)
+	}
+	log.Printf("Finished setting user : %s enabled : %t", username, enabled)
+	return nil
+}
+
+// DeleteUser - deleting user, user's instance limit and memberships in DB then Proxmox
+// Proxmox's user is deleted by given cookies or api token when cookies is nil, DB is restored when Proxmox has failed
+func DeleteUser(username string, cookies *model.Cookies) error {
+	group, getGroupErr := database.GetUserGroup(username)
+	if getGroupErr != nil {
+		return getGroupErr
+	}
+	user, getUserErr := database.GetUser(username, group)
+	if getUserErr != nil {
+		return getUserErr
+	}
+	limit, _ := database.GetInstanceLimit(username)
+	memberships, getMembershipsErr := database.GetUserMemberships(username)
+	if getMembershipsErr != nil {
+		return getMembershipsErr
+	}
+
+	log.Printf("Deleting user : %s in DB", username)
+	if deleteErr := database.DeleteUserWithLimit(username, group); deleteErr != nil {
+		return deleteErr
+	}
+	log.Printf("Deleting user : %s in Proxmox", username)
+	var deleteErr error
+	if cookies != nil {
+		_, deleteErr = access.DeleteUser(userURL(username), *cookies)
+	} else {
+		_, deleteErr = access.DeleteUserUsingToken(userURL(username))
+	}
+	if deleteErr != nil {
+		log.Printf("Error: Could not delete user : %s in Proxmox due to %s, restoring DB", username, deleteErr)
+		if restoreErr := database.RestoreUser(user, group, limit, memberships); restoreErr != nil {
+			log.Printf("Error: Could not restore user : %s in DB due to %s, reconciliation is required", username, restoreErr)
+		}
+		return fmt.Errorf("error: unable to delete user in Proxmox due to %s", deleteErr)
+	}
+	log.Printf("Finished deleting user : %s", username)
+	return nil
+}
@@ -0,0 +1,161 @@
+// Package logging - Structured logging with levels, request ID and redaction of secrets
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edu-cloud-api/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RequestIDHeader - header of request ID, which is also sent to Proxmox
+	RequestIDHeader = "X-Request-ID"
+	// requestIDKey - key of request ID in fiber's locals
+	requestIDKey = "requestid"
+)
+
+var (
+	logger = zap.NewNop()
+	level  = zap.NewAtomicLevelAt(zap.InfoLevel)
+)
+
+// IsValidLevel - check is given level one of {debug, info, warn, error}
+func IsValidLevel(name string) bool {
+	var parsed zapcore.Level
+	return parsed.UnmarshalText([]byte(name)) == nil && parsed <= zapcore.ErrorLevel
+}
+
+// SetLevel - changing level of global logger without restarting
+func SetLevel(name string) error {
+	if !IsValidLevel(name) {
+		return fmt.Errorf("error: log level : %s is invalid", name)
+	}
+	return level.UnmarshalText([]byte(name))
+}
+
+// Init - constructing global logger and redirecting standard log into it
+/*
+	@levelName : {debug, info, warn, error}, default : info
+	@format : {json, console}, default : console
+*/
+func Init(levelName, format string) {
+	if levelName != "" {
+		if err := SetLevel(levelName); err != nil {
+			log.Printf("Warning: LOG_LEVEL : %s is invalid, using info", levelName)
+		}
+	}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	if format == "json" {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	core := zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), level)
+	logger = zap.New(&redactCore{Core: core}, zap.AddCaller())
+	zap.ReplaceGlobals(logger)
+
+	// existing log.Printf calls are written through logger, level is guessed from message
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{logger: logger.WithOptions(zap.AddCallerSkip(3))})
+}
+
+// L - global logger
+func L() *zap.Logger {
+	return logger
+}
+
+// WithRequestID - global logger with request ID field, request ID is omitted when empty
+func WithRequestID(requestID string) *zap.Logger {
+	if requestID == "" {
+		return logger
+	}
+	return logger.With(zap.String("request_id", requestID))
+}
+
+// RequestID - getting request ID of context
+func RequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals(requestIDKey).(string)
+	return requestID
+}
+
+// Ctx - global logger with request ID of context
+func Ctx(c *fiber.Ctx) *zap.Logger {
+	return WithRequestID(RequestID(c))
+}
+
+// Middleware - setting request ID from X-Request-ID header or generating new one, and logging every requests
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = utils.UUIDv4()
+		}
+		c.Locals(requestIDKey, requestID)
+		c.Set(RequestIDHeader, requestID)
+
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		}
+		fields := []zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.String("route", c.Route().Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("ip", c.IP()),
+		}
+		if username := c.Query("username"); username != "" {
+			fields = append(fields, zap.String("username", username))
+		}
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logger.Error("request", fields...)
+		case status >= fiber.StatusBadRequest:
+			logger.Warn("request", fields...)
+		default:
+			logger.Info("request", fields...)
+		}
+		return err
+	}
+}
+
+// stdWriter - writer of standard log, "Error..." messages are logged as error, "Warning..." as warn and others as info
+type stdWriter struct {
+	logger *zap.Logger
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	message := string(bytes.TrimRight(p, "\n"))
+	lower := strings.ToLower(message)
+	switch {
+	case strings.HasPrefix(lower, "error"), strings.HasPrefix(lower, "schedule job error"):
+		w.logger.Error(message)
+	case strings.HasPrefix(lower, "warning"):
+		w.logger.Warn(message)
+	default:
+		w.logger.Info(message)
+	}
+	return len(p), nil
+}
+
+// ForTask - global logger with task's ID, type and request ID which created the task
+func ForTask(task model.Task) *zap.Logger {
+	return WithRequestID(task.RequestID).With(zap.Uint64("task_id", task.ID), zap.String("task_type", task.Type))
+}
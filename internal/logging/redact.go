@@ -0,0 +1,134 @@
+// Package logging - Structured logging with levels, request ID and redaction of secrets
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted - replacement of secret's value
+const Redacted = "[REDACTED]"
+
+// secretKeys - field or struct's key containing one of these is redacted, compared in lowercase
+var secretKeys = []string{"password", "cipass", "ticket", "token", "cookie", "csrf", "secret", "authorization", "apikey", "api_key"}
+
+// secretValues - secrets which could be in free-text messages, e.g. Proxmox's tickets and API tokens
+// value after "password :" or "token=" is replaced, while the key is kept
+var secretValues = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`PVE:[^\s"',;]+`), Redacted},
+	{regexp.MustCompile(`PVEAPIToken=[^\s"',;]+`), Redacted},
+	{regexp.MustCompile(`(?i)((?:password|cipassword|ticket|token)["']?\s*[:=]\s*["']?)[^\s"',;}]+`), "${1}" + Redacted},
+}
+
+// IsSecretKey - check is given key of field or struct's key a secret
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString - replacing secrets in free-text message
+func RedactString(message string) string {
+	for _, secret := range secretValues {
+		message = secret.pattern.ReplaceAllString(message, secret.replacement)
+	}
+	return message
+}
+
+// Redact - converting value into JSON's value with every secret keys redacted
+func Redact(value interface{}) interface{} {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return Redacted
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return Redacted
+	}
+	return redactValue(decoded)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if IsSecretKey(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	case string:
+		return RedactString(v)
+	}
+	return value
+}
+
+// redactField - redacting field by its key, its message or its struct's keys
+func redactField(field zapcore.Field) zapcore.Field {
+	if IsSecretKey(field.Key) {
+		return zap.String(field.Key, Redacted)
+	}
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, RedactString(field.String))
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			return zap.String(field.Key, RedactString(err.Error()))
+		}
+	case zapcore.ReflectType:
+		return zap.Any(field.Key, Redact(field.Interface))
+	case zapcore.StringerType:
+		if stringer, ok := field.Interface.(fmt.Stringer); ok {
+			return zap.String(field.Key, RedactString(stringer.String()))
+		}
+	}
+	return field
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = redactField(field)
+	}
+	return redacted
+}
+
+// redactCore - core which redacts secrets from message and fields before writing
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = RedactString(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
@@ -0,0 +1,105 @@
+// Package metering - Sampling VMs's usage for accounting reports
+package metering
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+)
+
+// Interval - how often usage is sampled, has to be the same as schedule of sampling job
+const Interval = 5 * time.Minute
+
+// counter - network counters and uptime of VM from previous sample
+type counter struct {
+	netIn, netOut, upTime uint64
+}
+
+var (
+	sampleMutex    sync.Mutex
+	lastCounters   = map[string]counter{}
+	lastSampleTime time.Time
+)
+
+// Sample - sampling usage of every VMs from cluster's resources into usage_sample
+// VM without instance (e.g. orphan) is not sampled, since it could not be charged to anyone
+func Sample() (int, error) {
+	sampleMutex.Lock()
+	defer sampleMutex.Unlock()
+
+	resources, getResourcesErr := qemu.GetResourcesUsingToken()
+	if getResourcesErr != nil {
+		return 0, fmt.Errorf("error: unable to get cluster's resources due to %s", getResourcesErr)
+	}
+	now := time.Now().UTC()
+	// sample represents time since previous sample, unless previous sample is missing
+	interval := Interval
+	if elapsed := now.Sub(lastSampleTime); !lastSampleTime.IsZero() && elapsed > 0 && elapsed <= 2*Interval {
+		interval = elapsed
+	}
+
+	instances := map[string]model.Instance{}
+	for _, instance := range database.GetAllInstances() {
+		instances[instance.VMID] = instance
+	}
+	groups := map[string]string{}
+	counters := map[string]counter{}
+	var samples []model.UsageSample
+	for _, resource := range resources {
+		vmid := fmt.Sprint(resource.VMID)
+		instance, found := instances[vmid]
+		if resource.Template == 1 || !found {
+			continue
+		}
+		group, cached := groups[instance.OwnerID]
+		if !cached {
+			group, _ = database.GetUserGroup(instance.OwnerID)
+			groups[instance.OwnerID] = group
+		}
+		current := counter{netIn: resource.NetIn, netOut: resource.NetOut, upTime: resource.UpTime}
+		netIn, netOut := networkDelta(lastCounters[vmid], current)
+		counters[vmid] = current
+		samples = append(samples, model.UsageSample{
+			VMID:       vmid,
+			Node:       resource.Node,
+			OwnerID:    instance.OwnerID,
+			PoolID:     instance.PoolID,
+			UserGroup:  group,
+			Status:     resource.Status,
+			MaxCPU:     resource.MaxCPU,
+			CPU:        resource.CPU,
+			MaxMem:     resource.MaxMem,
+			Mem:        resource.Mem,
+			MaxDisk:    resource.MaxDisk,
+			NetIn:      netIn,
+			NetOut:     netOut,
+			UpTime:     resource.UpTime,
+			Interval:   uint64(interval.Seconds()),
+			SampleTime: now,
+		})
+	}
+	if createErr := database.CreateUsageSamples(samples); createErr != nil {
+		return 0, createErr
+	}
+	lastCounters = counters
+	lastSampleTime = now
+	log.Printf("Sampled usage of %d VMs", len(samples))
+	return len(samples), nil
+}
+
+// networkDelta - bytes since previous sample from counters which are reset when VM is restarted
+// zero is returned without previous sample, since bytes before it are unknown
+func networkDelta(previous, current counter) (uint64, uint64) {
+	if previous == (counter{}) {
+		return 0, 0
+	}
+	if current.upTime < previous.upTime || current.netIn < previous.netIn || current.netOut < previous.netOut {
+		return current.netIn, current.netOut
+	}
+	return current.netIn - previous.netIn, current.netOut - previous.netOut
+}
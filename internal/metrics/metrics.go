@@ -0,0 +1,165 @@
+// Package metrics - Prometheus's metrics of API, Proxmox's client, tasks, scheduler and cluster
+package metrics
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edu-cloud-api/internal/task"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Registry - registry of every metrics which is exposed by /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "edu_cloud_http_requests_total",
+		Help: "Amount of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "edu_cloud_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	proxmoxDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "edu_cloud_proxmox_request_duration_seconds",
+		Help:    "Latency of requests to Proxmox's API by method and endpoint.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "endpoint"})
+	proxmoxErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "edu_cloud_proxmox_request_errors_total",
+		Help: "Amount of failed requests to Proxmox's API by method and endpoint.",
+	}, []string{"method", "endpoint"})
+
+	schedulerRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "edu_cloud_scheduler_job_runs_total",
+		Help: "Amount of scheduled job's runs by job and result {success, failure}.",
+	}, []string{"job", "result"})
+	schedulerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "edu_cloud_scheduler_job_duration_seconds",
+		Help:    "Duration of scheduled job's runs by job.",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"job"})
+
+	taskRunning = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "edu_cloud_task_running_workers",
+		Help: "Amount of task's items which are being processed by workers in this instance.",
+	}, func() float64 { return float64(task.Running()) })
+
+	// TaskItems - amount of task's items by status {pending, running}, set before scraping
+	TaskItems = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_task_items",
+		Help: "Amount of unfinished task's items by status.",
+	}, []string{"status"})
+
+	// NodeFreeCPU - free CPU of worker node, set before scraping
+	NodeFreeCPU = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_node_free_cpu",
+		Help: "Free CPU of worker node.",
+	}, []string{"node"})
+	// NodeFreeMemory - free memory in bytes of worker node, set before scraping
+	NodeFreeMemory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_node_free_memory_bytes",
+		Help: "Free memory of worker node in bytes.",
+	}, []string{"node"})
+	// NodeCordoned - 1 when worker node is cordoned, set before scraping
+	NodeCordoned = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_node_cordoned",
+		Help: "Whether worker node is cordoned.",
+	}, []string{"node"})
+	// VMs - amount of VMs by status, templates are not included, set before scraping
+	VMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_vms",
+		Help: "Amount of VMs in cluster by status.",
+	}, []string{"status"})
+	// QuotaUtilization - used / limit of instance limits by group and resource, set before scraping
+	QuotaUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "edu_cloud_quota_utilization_ratio",
+		Help: "Used per limit of users's instance limits by group and resource {cpu, ram, disk, instance}.",
+	}, []string{"group", "resource"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		proxmoxDuration, proxmoxErrors,
+		schedulerRuns, schedulerDuration,
+		taskRunning, TaskItems,
+		NodeFreeCPU, NodeFreeMemory, NodeCordoned, VMs, QuotaUtilization,
+	)
+}
+
+// Middleware - counting HTTP requests and observing latency by route's pattern
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		}
+		// route's pattern instead of path, so VMID or username is not a label
+		route := c.Route().Path
+		httpRequests.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// ObserveProxmox - observing latency and error of request to Proxmox's API
+func ObserveProxmox(method, rawURL string, start time.Time, err error) {
+	endpoint := ProxmoxEndpoint(rawURL)
+	proxmoxDuration.WithLabelValues(method, endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		proxmoxErrors.WithLabelValues(method, endpoint).Inc()
+	}
+}
+
+// ObserveJob - counting result and observing duration of scheduled job
+func ObserveJob(job string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	schedulerRuns.WithLabelValues(job, result).Inc()
+	schedulerDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+}
+
+// placeholders - segment after these segments is replaced by placeholder
+var placeholders = map[string]string{
+	"nodes":     "{node}",
+	"qemu":      "{vmid}",
+	"tasks":     "{upid}",
+	"storage":   "{storage}",
+	"users":     "{userid}",
+	"resources": "{sid}",
+	"pools":     "{poolid}",
+}
+
+var numeric = regexp.MustCompile(`^[0-9]+$`)
+
+// ProxmoxEndpoint - Proxmox's API path with node, VMID, UPID replaced by placeholders
+// e.g. /api2/json/nodes/work-1/qemu/100/status/start => /api2/json/nodes/{node}/qemu/{vmid}/status/start
+func ProxmoxEndpoint(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if placeholder, ok := placeholders[segments[i-1]]; ok && segments[i] != "" {
+			segments[i] = placeholder
+		} else if numeric.MatchString(segments[i]) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,188 @@
+// Package migrate - Migrating VMs between nodes
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/internal/task"
+	"github.com/edu-cloud-api/model"
+	"go.uber.org/zap"
+)
+
+// DefaultConcurrency - amount of migrating at the same time when concurrency is not given
+const DefaultConcurrency = 1
+
+// Do - migrating VM from node into target node using api token and waiting until finished
+// running VM is migrated online (live), stopped VM and template are migrated offline
+// VM which is managed by HA is migrated by HA manager
+func Do(node, vmid, target string) (string, error) {
+	if node == target {
+		return fmt.Sprintf("VMID : %s has already been in %s", vmid, target), nil
+	}
+	vmStatusURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", node, vmid))
+	vm, err := qemu.GetVMUsingToken(vmStatusURL)
+	if err != nil {
+		return "", fmt.Errorf("error: unable to get VMID : %s in %s due to %s", vmid, node, err)
+	}
+	online := vm.Info.Status == "running"
+
+	log.Printf("Migrating VMID : %s from %s to %s, online : %t", vmid, node, target, online)
+	if vm.Info.HA.Manage == 1 {
+		if haErr := qemu.MigrateHAUsingToken(vmid, target); haErr != nil {
+			return "", fmt.Errorf("error: unable to migrate VMID : %s by HA manager due to %s", vmid, haErr)
+		}
+		if waitErr := waitNode(vmid, target); waitErr != nil {
+			return "", waitErr
+		}
+	} else {
+		data := url.Values{}
+		data.Set("target", target)
+		if online {
+			data.Set("online", "1")
+		}
+		vmMigrateURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/migrate", node, vmid))
+		response, migrateErr := qemu.MigrateVMUsingToken(vmMigrateURL, data)
+		if migrateErr != nil {
+			return "", fmt.Errorf("error: unable to migrate VMID : %s from %s due to %s", vmid, node, migrateErr)
+		}
+		if waitErr := qemu.WaitTask(node, response.Info, config.Get().MigrateTimeout, (5 * time.Second)); waitErr != nil {
+			return "", waitErr
+		}
+	}
+
+	cluster.ForgetVM(vmid)
+	if updateErr := database.SetInstanceNode(vmid, target); updateErr != nil {
+		log.Printf("Error: VMID : %s has been migrated but instance could not be updated due to %s", vmid, updateErr)
+	}
+	return fmt.Sprintf("Finished migrating VMID : %s from %s to %s", vmid, node, target), nil
+}
+
+// waitNode - waiting until VM has appeared in target node of cluster's resources
+func waitNode(vmid, target string) error {
+	timeout := config.Get().MigrateTimeout
+	timeoutCh := time.After(timeout)
+	for {
+		select {
+		case <-timeoutCh:
+			return fmt.Errorf("error: VMID : %s hasn't been moved to %s in %s", vmid, target, timeout)
+		default:
+			cluster.ForgetVM(vmid)
+			if node, err := cluster.LocateVM(vmid); err == nil && node == target {
+				return nil
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
+
+// Run - migrating every pending items of migrating task
+// target node is allocated for each VM when target is empty, nodes in except are never allocated
+// Run blocks until every items have been finished, so it should be called as goroutine
+func Run(migrateTask model.Task, items []model.TaskItem, target string, except []string, concurrency int, cookies model.Cookies) {
+	taskLogger := logging.ForTask(migrateTask)
+	database.UpdateTaskStatus(migrateTask.ID, config.TASK_RUNNING)
+	defer database.RefreshTask(migrateTask.ID)
+
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	task.ForEach(len(items), concurrency, func(i int) {
+		item := items[i]
+		item.VMID = item.Target
+		item.Status = config.TASK_RUNNING
+		database.UpdateTaskItem(item)
+
+		message, err := migrateItem(&item, target, except, cookies)
+		if err != nil {
+			taskLogger.Error("migration failed", zap.String("vmid", item.Target), zap.Error(err))
+			item.Status, item.Message = config.TASK_FAILURE, err.Error()
+		} else {
+			item.Status, item.Message = config.TASK_SUCCESS, message
+		}
+		database.UpdateTaskItem(item)
+		database.RefreshTask(migrateTask.ID)
+	})
+	taskLogger.Info("finished migrate task")
+}
+
+// migrateItem - locating VM, allocating target node when it is not given and migrating
+func migrateItem(item *model.TaskItem, target string, except []string, cookies model.Cookies) (string, error) {
+	node, locateErr := cluster.LocateVM(item.VMID)
+	if locateErr != nil {
+		return "", locateErr
+	}
+	item.Node = node
+	vmStatusURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", node, item.VMID))
+	vm, getVMErr := qemu.GetVMUsingToken(vmStatusURL)
+	if getVMErr != nil {
+		return "", fmt.Errorf("error: unable to get VMID : %s in %s due to %s", item.VMID, node, getVMErr)
+	}
+	item.Name = vm.Info.Name
+	if target == "" {
+		spec := model.VMSpec{CPU: vm.Info.CPUs, Memory: vm.Info.MaxMem}
+		_, allocated, allocateErr := cluster.AllocateNodeExcept(spec, "", append([]string{node}, except...), cookies)
+		if allocateErr != nil {
+			return "", fmt.Errorf("error: unable to allocate node for VMID : %s due to %s", item.VMID, allocateErr)
+		}
+		target = allocated
+	}
+	message, err := Do(node, item.VMID, target)
+	if err == nil {
+		item.Node = target
+	}
+	return message, err
+}
+
+// Start - creating migrating task for given VMs and running it in background
+func Start(owner, taskTarget string, vmids []string, body model.MigrateBody, except []string, cookies model.Cookies) (model.Task, error) {
+	if len(vmids) == 0 {
+		return model.Task{}, fmt.Errorf("error: there is no VM to migrate")
+	}
+	params, _ := json.Marshal(body)
+	migrateTask, createTaskErr := database.CreateTask(config.TASK_MIGRATE, owner, taskTarget, string(params), cookies.RequestID, vmids)
+	if createTaskErr != nil {
+		return migrateTask, createTaskErr
+	}
+	items, getItemsErr := database.GetTaskItems(migrateTask.ID)
+	if getItemsErr != nil {
+		return migrateTask, getItemsErr
+	}
+	go Run(migrateTask, items, body.Target, except, body.Concurrency, cookies)
+	logging.ForTask(migrateTask).Info("started migrate task", zap.Int("vms", len(vmids)), zap.String("target", taskTarget))
+	return migrateTask, nil
+}
+
+// Drain - cordon node then start migrating every VMs and templates off node in background
+// node is cordoned first, so migrated VMs and new VMs are never allocated back into node
+// empty task is returned when node has no VM
+func Drain(owner, node string, concurrency int, cookies model.Cookies) (model.Task, error) {
+	if state := database.GetNodeState(node); !state.Cordoned {
+		if _, cordonErr := database.SetNodeCordon(node, true, "draining", owner); cordonErr != nil {
+			return model.Task{}, cordonErr
+		}
+	}
+	resources, getResourcesErr := qemu.GetResourcesUsingToken()
+	if getResourcesErr != nil {
+		return model.Task{}, fmt.Errorf("error: unable to get VMs in node : %s due to %s", node, getResourcesErr)
+	}
+	var vmids []string
+	for _, resource := range resources {
+		if resource.Node == node {
+			vmids = append(vmids, fmt.Sprint(resource.VMID))
+		}
+	}
+	if len(vmids) == 0 {
+		log.Printf("Node : %s has no VM to migrate", node)
+		return model.Task{}, nil
+	}
+	return Start(owner, node, vmids, model.MigrateBody{Concurrency: concurrency}, []string{node}, cookies)
+}
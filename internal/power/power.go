@@ -0,0 +1,137 @@
+// Package power - Power management functions for many VMs
+package power
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/internal/task"
+	"github.com/edu-cloud-api/model"
+	"go.uber.org/zap"
+)
+
+// Action - required status before acting and status after action has been completed
+type Action struct {
+	From []string
+	To   []string
+	QMP  bool // check status from QMP status instead of VM's status
+}
+
+// Actions - supported power actions
+var Actions = map[string]Action{
+	"start":    {From: []string{"stopped"}, To: []string{"running"}},
+	"stop":     {From: []string{"running"}, To: []string{"stopped"}},
+	"shutdown": {From: []string{"running"}, To: []string{"stopped"}},
+	"reset":    {From: []string{"running"}, To: []string{"running"}},
+	"suspend":  {From: []string{"running"}, To: []string{"paused"}, QMP: true},
+	"resume":   {From: []string{"paused"}, To: []string{"running"}, QMP: true},
+}
+
+// IsValidAction - check is given action supported
+func IsValidAction(action string) bool {
+	_, ok := Actions[action]
+	return ok
+}
+
+// Do - acting power action on VM using api token and waiting until finished
+// VM which has already been in the target status is skipped
+func Do(node, vmid, action string) (string, error) {
+	act, ok := Actions[action]
+	if !ok {
+		return "", fmt.Errorf("error: action : %s is invalid", action)
+	}
+	vmStatusURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", node, vmid))
+	vm, err := qemu.GetVMUsingToken(vmStatusURL)
+	if err != nil {
+		return "", fmt.Errorf("error: unable to get VMID : %s in %s due to %s", vmid, node, err)
+	}
+	status := vm.Info.Status
+	if act.QMP {
+		status = vm.Info.QmpStatus
+	}
+	if action != "reset" && config.Contains(act.To, status) {
+		return fmt.Sprintf("VMID : %s has already been %s", vmid, status), nil
+	}
+	if !config.Contains(act.From, status) {
+		return "", fmt.Errorf("error: VMID : %s is %s, unable to %s", vmid, status, action)
+	}
+
+	log.Printf("Acting %s on VMID : %s in %s", action, vmid, node)
+	vmActionURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/%s", node, vmid, action))
+	if _, actionErr := qemu.PowerManagementUsingToken(vmActionURL, nil); actionErr != nil {
+		return "", fmt.Errorf("error: unable to %s VMID : %s in %s due to %s", action, vmid, node, actionErr)
+	}
+	var finished bool
+	if act.QMP {
+		finished = qemu.CheckQmpStatus(node, vmid, act.To, false, config.Get().PowerTimeout, time.Second)
+	} else {
+		finished = qemu.CheckStatus(node, vmid, act.To, false, config.Get().PowerTimeout, (3 * time.Second))
+	}
+	if !finished {
+		return "", fmt.Errorf("error: VMID : %s in %s hasn't been %s correctly", vmid, node, action)
+	}
+	return fmt.Sprintf("Finished %s VMID : %s in %s", action, vmid, node), nil
+}
+
+// Run - acting power action on every pending items of power task
+// Run blocks until every items have been finished, so it should be called as goroutine
+func Run(powerTask model.Task, items []model.TaskItem, action string, concurrency int) {
+	taskLogger := logging.ForTask(powerTask)
+	database.UpdateTaskStatus(powerTask.ID, config.TASK_RUNNING)
+	defer database.RefreshTask(powerTask.ID)
+
+	task.ForEach(len(items), concurrency, func(i int) {
+		item := items[i]
+		item.VMID = item.Target
+		item.Status = config.TASK_RUNNING
+		database.UpdateTaskItem(item)
+
+		instance, getInstanceErr := database.GetInstance(item.Target)
+		if getInstanceErr != nil {
+			item.Status, item.Message = config.TASK_FAILURE, getInstanceErr.Error()
+		} else {
+			instance.Node = cluster.ResolveNode(instance.VMID, instance.Node)
+			item.Node = instance.Node
+			item.Name = instance.Name
+			if message, err := Do(instance.Node, instance.VMID, action); err != nil {
+				taskLogger.Error("power action failed", zap.String("action", action), zap.String("vmid", item.Target), zap.Error(err))
+				item.Status, item.Message = config.TASK_FAILURE, err.Error()
+			} else {
+				item.Status, item.Message = config.TASK_SUCCESS, message
+			}
+		}
+		database.UpdateTaskItem(item)
+		database.RefreshTask(powerTask.ID)
+	})
+	taskLogger.Info("finished power task")
+}
+
+// Start - creating power task for given VMs and running it in background
+// requestID is empty when task is not created by request, e.g. power schedule
+func Start(owner, target, action, requestID string, vmids []string, concurrency int) (model.Task, error) {
+	if !IsValidAction(action) {
+		return model.Task{}, fmt.Errorf("error: action : %s is invalid", action)
+	}
+	if len(vmids) == 0 {
+		return model.Task{}, fmt.Errorf("error: there is no VM to %s", action)
+	}
+	params, _ := json.Marshal(model.PowerBatchBody{Action: action, Concurrency: concurrency})
+	powerTask, createTaskErr := database.CreateTask(config.TASK_POWER, owner, target, string(params), requestID, vmids)
+	if createTaskErr != nil {
+		return powerTask, createTaskErr
+	}
+	items, getItemsErr := database.GetTaskItems(powerTask.ID)
+	if getItemsErr != nil {
+		return powerTask, getItemsErr
+	}
+	go Run(powerTask, items, action, concurrency)
+	logging.ForTask(powerTask).Info("started power task", zap.String("action", action), zap.Int("vms", len(vmids)), zap.String("target", target))
+	return powerTask, nil
+}
@@ -0,0 +1,193 @@
+// Package provision - Provisioning pool's template for every pool's members
+package provision
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/internal/task"
+	"github.com/edu-cloud-api/model"
+	"go.uber.org/zap"
+)
+
+// DefaultNamePattern - name pattern of provisioned VM when pattern is not given
+const DefaultNamePattern = "{code}-{username}"
+
+var invalidName = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// VMName - replace {code}, {username}, {index} in pattern and sanitize into valid VM's name
+func VMName(pattern, code, username string, index int) string {
+	if pattern == "" {
+		pattern = DefaultNamePattern
+	}
+	name := strings.NewReplacer("{code}", code, "{username}", username, "{index}", fmt.Sprint(index)).Replace(pattern)
+	name = invalidName.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, "-")
+}
+
+// Run - cloning template for every pending items of provisioning task
+// Run blocks until every items have been finished, so it should be called as goroutine
+func Run(provisionTask model.Task, items []model.TaskItem, pool model.Pool, template model.Instance, vmSpec model.VMSpec, body model.ProvisionBody, cookies model.Cookies) {
+	// retried task is logged with request ID of retrying request
+	logged := provisionTask
+	if cookies.RequestID != "" {
+		logged.RequestID = cookies.RequestID
+	}
+	taskLogger := logging.ForTask(logged)
+	database.UpdateTaskStatus(provisionTask.ID, config.TASK_RUNNING)
+	defer database.RefreshTask(provisionTask.ID)
+
+	targets, nodeErr := cluster.SpreadNodes(vmSpec, body.Storage, len(items), cookies)
+	if nodeErr != nil {
+		taskLogger.Error("allocating nodes failed", zap.Error(nodeErr))
+		for _, item := range items {
+			item.Status = config.TASK_FAILURE
+			item.Message = fmt.Sprintf("Failed to allocate node due to %s", nodeErr)
+			database.UpdateTaskItem(item)
+		}
+		return
+	}
+
+	// template might have been moved after it was created
+	template.Node = cluster.ResolveNode(template.VMID, template.Node)
+	task.ForEach(len(items), body.Concurrency, func(i int) {
+		item := items[i]
+		if item.Name == "" {
+			item.Name = VMName(body.NamePattern, pool.Code, item.Target, i+1)
+		}
+		// VM left behind by previous attempt is deleted before cloning again, so member is not charged twice
+		err := discardClone(&item, cookies)
+		if err == nil {
+			item.Node = targets[i]
+			err = cloneForMember(&item, pool, template, vmSpec, body, cookies)
+		}
+		if err != nil {
+			taskLogger.Error("provisioning failed", zap.String("username", item.Target), zap.Error(err))
+			item.Status = config.TASK_FAILURE
+			item.Message = err.Error()
+		} else {
+			item.Status = config.TASK_SUCCESS
+			item.Message = fmt.Sprintf("Cloning new VMID : %s successfully", item.VMID)
+		}
+		database.UpdateTaskItem(item)
+		database.RefreshTask(provisionTask.ID)
+	})
+	taskLogger.Info("finished provisioning task")
+}
+
+// cloneForMember - cloning template into new VM of member and set cloud-init's credentials
+func cloneForMember(item *model.TaskItem, pool model.Pool, template model.Instance, vmSpec model.VMSpec, body model.ProvisionBody, cookies model.Cookies) error {
+	item.Status = config.TASK_RUNNING
+	database.UpdateTaskItem(*item)
+
+	// member's personal limit and pool's quota
+	if _, limitErr := database.CheckInstanceLimit(item.Target, vmSpec, true); limitErr != nil {
+		return limitErr
+	}
+	if _, quotaErr := database.CheckPoolQuota(pool.ID, item.Target, vmSpec, true); quotaErr != nil {
+		return quotaErr
+	}
+
+	newid, getVMIDErr := qemu.ReserveVMID(cookies)
+	if getVMIDErr != nil {
+		return fmt.Errorf("error: unable to get vmid due to %s", getVMIDErr)
+	}
+	defer qemu.ReleaseVMID(newid)
+
+	data, optionsErr := qemu.CloneOptions(body.Mode, body.Storage, true)
+	if optionsErr != nil {
+		return optionsErr
+	}
+	data.Set("newid", newid)
+	data.Set("name", item.Name)
+	data.Set("target", item.Node)
+	log.Printf("Cloning VMID : %s in %s for username : %s", newid, item.Node, item.Target)
+	vmCloneURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/clone", template.Node, template.VMID))
+	if _, cloneErr := qemu.CloneVM(vmCloneURL, data, cookies); cloneErr != nil {
+		return fmt.Errorf("error: unable to clone VMID : %s due to %s", template.VMID, cloneErr)
+	}
+	// VMID is kept in item before anything else could fail, so the clone is able to be deleted later
+	item.VMID = newid
+	database.UpdateTaskItem(*item)
+	if err := setupClone(item, pool, vmSpec, body, cookies); err != nil {
+		if discardErr := discardClone(item, cookies); discardErr != nil {
+			log.Printf("Error: VMID : %s is left for retrying due to %s", newid, discardErr)
+		}
+		return err
+	}
+	log.Printf("Finished cloning VMID : %s in %s for username : %s", newid, item.Node, item.Target)
+	return nil
+}
+
+// setupClone - waiting for cloned VM of item, then recording its instance and set cloud-init's credentials
+func setupClone(item *model.TaskItem, pool model.Pool, vmSpec model.VMSpec, body model.ProvisionBody, cookies model.Cookies) error {
+	newid := item.VMID
+	if !qemu.CheckStatus(item.Node, newid, []string{"created", "stopped", "running"}, false, config.Get().CloneTimeout, (3 * time.Second)) {
+		return fmt.Errorf("error: cloning new VMID : %s has failed", newid)
+	}
+
+	vmSpec.PoolID = pool.ID
+	if _, createInstanceErr := database.CreateInstance(newid, item.Target, item.Node, item.Name, vmSpec); createInstanceErr != nil {
+		return createInstanceErr
+	}
+
+	// config ciuser, cipassword
+	ciuser, cipass := body.CIUser, body.CIPass
+	if ciuser == "" {
+		ciuser = item.Target
+	}
+	if cipass == "" {
+		password, passwordErr := config.RandomPassword(12)
+		if passwordErr != nil {
+			return fmt.Errorf("error: unable to generate password due to %s", passwordErr)
+		}
+		cipass = password
+	}
+	editData := url.Values{}
+	editData.Set("ciuser", ciuser)
+	editData.Set("cipassword", cipass)
+	vmEditURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/config", item.Node, newid))
+	if _, editErr := qemu.EditVM(vmEditURL, editData, cookies); editErr != nil {
+		return fmt.Errorf("error: unable to set cloud-init of VMID : %s due to %s", newid, editErr)
+	}
+	encrypted, encryptErr := config.Encrypt(cipass)
+	if encryptErr != nil {
+		return fmt.Errorf("error: unable to encrypt password of VMID : %s due to %s", newid, encryptErr)
+	}
+	item.CIUser, item.CIPass = ciuser, encrypted
+	return nil
+}
+
+// discardClone - deleting VM and instance of item which has not been provisioned completely
+// item's VMID is kept when VM could not be deleted, so retrying is able to delete it again
+func discardClone(item *model.TaskItem, cookies model.Cookies) error {
+	if item.VMID == "" {
+		return nil
+	}
+	node := cluster.ResolveNode(item.VMID, item.Node)
+	vmDeleteURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s", node, item.VMID))
+	if _, deleteErr := qemu.DeleteVM(vmDeleteURL, cookies); deleteErr != nil {
+		log.Printf("Error: deleting VMID : %s in %s due to %s", item.VMID, node, deleteErr)
+	}
+	if !qemu.DeleteCompletely(node, item.VMID) {
+		return fmt.Errorf("error: unable to delete VMID : %s in %s left by failed provisioning", item.VMID, node)
+	}
+	cluster.ForgetVM(item.VMID)
+	if _, getInstanceErr := database.GetInstance(item.VMID); getInstanceErr == nil {
+		if deleteInstanceErr := database.DeleteInstance(item.VMID); deleteInstanceErr != nil {
+			return deleteInstanceErr
+		}
+	}
+	log.Printf("Deleted VMID : %s in %s left by failed provisioning", item.VMID, node)
+	item.VMID = ""
+	return database.UpdateTaskItem(*item)
+}
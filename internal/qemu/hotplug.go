@@ -0,0 +1,111 @@
+// Package qemu - QEMU functions
+package qemu
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+)
+
+// maxSCSI - highest SCSI bus index Proxmox accepts, scsi0 - scsi30
+const maxSCSI = 30
+
+// defaultHotplug - hotplug features of VM which has not configured `hotplug` or configured it as "1"
+var defaultHotplug = []string{"network", "disk", "usb"}
+
+// GetVMOptions - GET /api2/json/nodes/{node}/qemu/{vmid}/config but keeping every options as string
+// e.g. scsi1 ... scsi30 which are not in model.ConfigDetail
+func GetVMOptions(url string, cookies model.Cookies) (map[string]string, error) {
+	options := map[string]string{}
+	response := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+	if err != nil {
+		return options, err
+	}
+	if marshalErr := json.Unmarshal(body, &response); marshalErr != nil {
+		return options, marshalErr
+	}
+	for key, value := range response.Data {
+		options[key] = fmt.Sprint(value)
+	}
+	return options, nil
+}
+
+// HotplugFeatures - getting hotplug features {network, disk, cpu, memory, usb, cloudinit} of VM's options
+func HotplugFeatures(options map[string]string) []string {
+	hotplug, ok := options["hotplug"]
+	switch {
+	case !ok || hotplug == "1":
+		return defaultHotplug
+	case hotplug == "0":
+		return []string{}
+	}
+	return strings.Split(hotplug, ",")
+}
+
+// IsNuma - check VM's options has enabled NUMA, which CPU and memory hot-plug require
+func IsNuma(options map[string]string) bool {
+	return options["numa"] == "1"
+}
+
+// MaxVCPUs - getting vCPUs which running VM is able to hot-plug up to, sockets * cores
+func MaxVCPUs(options map[string]string) uint64 {
+	sockets, cores := uint64(1), uint64(1)
+	if value, err := strconv.ParseUint(options["sockets"], 10, 64); err == nil && value > 0 {
+		sockets = value
+	}
+	if value, err := strconv.ParseUint(options["cores"], 10, 64); err == nil && value > 0 {
+		cores = value
+	}
+	return sockets * cores
+}
+
+// CPUOptions - getting payload which sets VM's vCPUs to given cores
+// VM which is able to hot-plug CPU gets HOTPLUG_MAX_CORES as cores and given cores as vcpus, leaving headroom to add cores while running
+func CPUOptions(cores uint64, options map[string]string) url.Values {
+	data := url.Values{}
+	data.Set("sockets", "1")
+	if config.Contains(HotplugFeatures(options), "cpu") && IsNuma(options) {
+		maxCores := config.Get().HotplugMaxCores
+		if cores > maxCores {
+			maxCores = cores
+		}
+		data.Set("cores", fmt.Sprint(maxCores))
+		data.Set("vcpus", fmt.Sprint(cores))
+		return data
+	}
+	data.Set("cores", fmt.Sprint(cores))
+	if _, ok := options["vcpus"]; ok {
+		data.Set("delete", "vcpus")
+	}
+	return data
+}
+
+// NextSCSI - getting SCSI buses which are not used by VM's options, lowest first
+func NextSCSI(options map[string]string, count int) ([]string, error) {
+	buses := []string{}
+	for i := 1; i <= maxSCSI && len(buses) < count; i++ {
+		bus := fmt.Sprintf("scsi%d", i)
+		if _, used := options[bus]; !used {
+			buses = append(buses, bus)
+		}
+	}
+	if len(buses) < count {
+		return buses, fmt.Errorf("error: unable to attach %d disks due to VM has only %d free SCSI buses", count, len(buses))
+	}
+	return buses, nil
+}
+
+// DiskStorage - getting storage of disk's volume e.g. "local-lvm:vm-100-disk-0,size=32G" : "local-lvm"
+func DiskStorage(volume string) string {
+	storage, _, _ := strings.Cut(volume, ":")
+	return storage
+}
@@ -0,0 +1,123 @@
+// Package qemu - QEMU functions
+package qemu
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/model"
+)
+
+// Timeframes - supported timeframes of VM's performance history and how long rrddata is cached
+var Timeframes = map[string]time.Duration{
+	"hour": 30 * time.Second,
+	"day":  5 * time.Minute,
+	"week": 30 * time.Minute,
+}
+
+// rrdCache - cached rrddata of VM in timeframe
+type rrdCache struct {
+	points    []model.RRDPoint
+	expiresAt time.Time
+}
+
+var (
+	rrdMutex  sync.Mutex
+	rrdCaches = map[string]rrdCache{}
+)
+
+// IsValidTimeframe - check is given timeframe supported
+func IsValidTimeframe(timeframe string) bool {
+	_, ok := Timeframes[timeframe]
+	return ok
+}
+
+// GetRRDData - GET /api2/json/nodes/{node}/qemu/{vmid}/rrddata
+func GetRRDData(node, vmid, timeframe string, cookies model.Cookies) ([]model.RRDPoint, error) {
+	url := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/rrddata", node, vmid)) + fmt.Sprintf("?timeframe=%s&cf=AVERAGE", timeframe)
+	rrd := model.RRDData{}
+	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+	if err != nil {
+		return rrd.Data, err
+	}
+	if marshalErr := json.Unmarshal(body, &rrd); marshalErr != nil {
+		return rrd.Data, marshalErr
+	}
+	return rrd.Data, nil
+}
+
+// GetVMMetrics - getting VM's performance history from cached rrddata and downsampling into at most maxPoints
+/*
+	maxPoints : 0 for every points from Proxmox
+	refresh : true for bypassing cache
+*/
+func GetVMMetrics(node, vmid, timeframe string, maxPoints int, refresh bool, cookies model.Cookies) (model.VMMetrics, error) {
+	metrics := model.VMMetrics{VMID: vmid, Node: node, Timeframe: timeframe, Points: []model.VMMetricPoint{}}
+	ttl, ok := Timeframes[timeframe]
+	if !ok {
+		return metrics, fmt.Errorf("error: timeframe : %s is invalid", timeframe)
+	}
+	key := fmt.Sprintf("%s/%s", vmid, timeframe)
+	rrdMutex.Lock()
+	cached, found := rrdCaches[key]
+	rrdMutex.Unlock()
+
+	points := cached.points
+	if refresh || !found || time.Now().After(cached.expiresAt) {
+		rrd, err := GetRRDData(node, vmid, timeframe, cookies)
+		if err != nil {
+			return metrics, err
+		}
+		points = rrd
+		rrdMutex.Lock()
+		rrdCaches[key] = rrdCache{points: rrd, expiresAt: time.Now().Add(ttl)}
+		rrdMutex.Unlock()
+	}
+
+	size := 1
+	if maxPoints > 0 && len(points) > maxPoints {
+		size = (len(points) + maxPoints - 1) / maxPoints
+	}
+	if len(points) > 1 {
+		metrics.Step = (points[1].Time - points[0].Time) * int64(size)
+	}
+	for start := 0; start < len(points); start += size {
+		end := start + size
+		if end > len(points) {
+			end = len(points)
+		}
+		metrics.Points = append(metrics.Points, averagePoints(points[start:end]))
+	}
+	return metrics, nil
+}
+
+// averagePoints - averaging rrddata's points into one point in unified schema, time of first point is used
+func averagePoints(points []model.RRDPoint) model.VMMetricPoint {
+	var sum model.RRDPoint
+	for _, point := range points {
+		sum.CPU += point.CPU
+		sum.MaxCPU += point.MaxCPU
+		sum.Mem += point.Mem
+		sum.MaxMem += point.MaxMem
+		sum.DiskRead += point.DiskRead
+		sum.DiskWrite += point.DiskWrite
+		sum.NetIn += point.NetIn
+		sum.NetOut += point.NetOut
+	}
+	n := float64(len(points))
+	return model.VMMetricPoint{
+		Time:      time.Unix(points[0].Time, 0).UTC(),
+		CPU:       sum.CPU / n * 100,
+		CPUs:      sum.MaxCPU / n,
+		MemUsed:   sum.Mem / n,
+		MemTotal:  sum.MaxMem / n,
+		DiskRead:  sum.DiskRead / n,
+		DiskWrite: sum.DiskWrite / n,
+		NetIn:     sum.NetIn / n,
+		NetOut:    sum.NetOut / n,
+	}
+}
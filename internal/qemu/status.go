@@ -258,3 +258,37 @@ func IsTemplate(node, vmid string) bool {
 	}
 	return false
 }
+
+// WaitTask - waiting until Proxmox's task from given UPID has been stopped using api token
+// GET /api2/json/nodes/{node}/tasks/{upid}/status
+func WaitTask(node, upid string, timeout, sleepTime time.Duration) error {
+	log.Printf("Checking task : %s in %s ...", upid, node)
+	taskStatusURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/tasks/%s/status", node, upid))
+	timeoutCh := time.After(timeout)
+	for {
+		select {
+		case <-timeoutCh:
+			log.Println("Timeout reached, Task not finished")
+			return fmt.Errorf("error: task : %s in %s hasn't been finished in %s", upid, node, timeout)
+		default:
+			body, err := config.SendRequestUsingToken(http.MethodGet, taskStatusURL, nil)
+			if err != nil {
+				log.Println(err)
+				time.Sleep(sleepTime)
+				continue
+			}
+			task := model.ProxmoxTask{}
+			if marshalErr := json.Unmarshal(body, &task); marshalErr != nil {
+				log.Println(marshalErr)
+			}
+			if task.Info.Status == "stopped" {
+				if task.Info.ExitStatus != "OK" {
+					return fmt.Errorf("error: task : %s in %s has been failed due to %s", upid, node, task.Info.ExitStatus)
+				}
+				log.Printf("Task : %s in %s has been finished", upid, node)
+				return nil
+			}
+			time.Sleep(sleepTime)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/model"
@@ -140,6 +143,66 @@ func CloneVM(url string, data url.Values, cookies model.Cookies) (model.VMRespon
 	return response, nil
 }
 
+var (
+	vmName = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]{0,61}[a-zA-Z0-9])?$`)
+	vmTag  = regexp.MustCompile(`^[a-z0-9_][a-z0-9_+.-]*$`)
+)
+
+// ValidateName - check VM's name is DNS name which Proxmox accepts
+func ValidateName(name string) error {
+	if !vmName.MatchString(name) {
+		return fmt.Errorf("error: name : %s is not valid DNS name", name)
+	}
+	return nil
+}
+
+// NormalizeTags - lowering, removing duplicates and checking tags with Proxmox's tag format
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || config.Contains(normalized, tag) {
+			continue
+		}
+		if !vmTag.MatchString(tag) {
+			return normalized, fmt.Errorf("error: tag : %s contains characters other than a-z, 0-9, _, +, ., -", tag)
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// SplitTags - splitting tags of VM's config or cluster's resources, which are separated by ";", "," or space
+func SplitTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	})
+}
+
+// CloneOptions - getting clone's payload of given mode and storage by Proxmox's rules
+// linked clone is only from template and its disk stays on template's storage, so storage is only for full clone
+func CloneOptions(mode, storage string, isTemplate bool) (url.Values, error) {
+	data := url.Values{}
+	switch mode {
+	case "", config.CLONE_FULL:
+		data.Set("full", "1")
+		if storage != "" {
+			data.Set("storage", storage)
+		}
+	case config.CLONE_LINKED:
+		if !isTemplate {
+			return data, fmt.Errorf("error: linked clone is only from template")
+		}
+		if storage != "" {
+			return data, fmt.Errorf("error: storage : %s could not be set for linked clone, disk stays on template's storage", storage)
+		}
+		data.Set("full", "0")
+	default:
+		return data, fmt.Errorf("error: clone mode : %s is not %s or %s", mode, config.CLONE_FULL, config.CLONE_LINKED)
+	}
+	return data, nil
+}
+
 // CreateTemplate - POST /api2/json/nodes/{node}/qemu/{vmid}/template
 func CreateTemplate(url string, cookies model.Cookies) (model.VMResponse, error) {
 	response := model.VMResponse{}
@@ -307,3 +370,122 @@ func GetVMID(cookies model.Cookies) (string, error) {
 	log.Println("min vmid :", min)
 	return fmt.Sprint(min), nil
 }
+
+var (
+	vmidMutex    sync.Mutex
+	reservedVMID = map[uint64]bool{}
+)
+
+// ReserveVMID - Getting VMID like GetVMID but skipping VMID which has been reserved by other running process
+// reserved VMID must be released by ReleaseVMID after the VM appears in cluster's resources or process has failed
+func ReserveVMID(cookies model.Cookies) (string, error) {
+	vmidMutex.Lock()
+	defer vmidMutex.Unlock()
+	url := config.GetURL("/api2/json/cluster/resources")
+	resources := model.VMsList{}
+	body, err := config.SendRequestWithErr(http.MethodGet, url, nil, cookies)
+	if err != nil {
+		return "", err
+	}
+	if marshalErr := json.Unmarshal(body, &resources); marshalErr != nil {
+		return "", marshalErr
+	}
+	present := map[uint64]bool{}
+	for _, resource := range resources.VMsList {
+		if resource.Type == "qemu" {
+			present[resource.VMID] = true
+		}
+	}
+	// fixed VMID must more than 100
+	vmid := uint64(100)
+	for present[vmid] || reservedVMID[vmid] {
+		vmid++
+	}
+	reservedVMID[vmid] = true
+	log.Println("reserved vmid :", vmid)
+	return fmt.Sprint(vmid), nil
+}
+
+// ReleaseVMID - Release VMID which has been reserved by ReserveVMID
+func ReleaseVMID(vmid string) {
+	vmidMutex.Lock()
+	defer vmidMutex.Unlock()
+	for id := range reservedVMID {
+		if fmt.Sprint(id) == vmid {
+			delete(reservedVMID, id)
+		}
+	}
+}
+
+// GetResourcesUsingToken - Getting every VMs and templates from cluster's resources using api token
+// GET /api2/json/cluster/resources
+func GetResourcesUsingToken() ([]model.VMsInfo, error) {
+	url := config.GetURL("/api2/json/cluster/resources")
+	resources := model.VMsList{}
+	body, err := config.SendRequestUsingToken(http.MethodGet, url, nil)
+	if err != nil {
+		return []model.VMsInfo{}, err
+	}
+	if marshalErr := json.Unmarshal(body, &resources); marshalErr != nil {
+		return []model.VMsInfo{}, marshalErr
+	}
+	var vmList []model.VMsInfo
+	for _, resource := range resources.VMsList {
+		if resource.Type == "qemu" {
+			vmList = append(vmList, resource)
+		}
+	}
+	return vmList, nil
+}
+
+// MigrateVMUsingToken - POST /api2/json/nodes/{node}/qemu/{vmid}/migrate using api token
+// response's data is UPID of migration task
+func MigrateVMUsingToken(url string, data url.Values) (model.VMResponse, error) {
+	response := model.VMResponse{}
+	body, err := config.SendRequestUsingToken(http.MethodPost, url, data)
+	if err != nil {
+		return response, err
+	}
+	if marshalErr := json.Unmarshal(body, &response); marshalErr != nil {
+		return response, marshalErr
+	}
+	return response, nil
+}
+
+// MigrateHAUsingToken - POST /api2/json/cluster/ha/resources/vm:{vmid}/migrate using api token
+// HA manager migrates VM in background, so there is no UPID
+func MigrateHAUsingToken(vmid, target string) error {
+	data := url.Values{}
+	data.Set("node", target)
+	haMigrateURL := config.GetURL(fmt.Sprintf("/api2/json/cluster/ha/resources/vm:%s/migrate", vmid))
+	_, err := config.SendRequestUsingToken(http.MethodPost, haMigrateURL, data)
+	return err
+}
+
+// SnapshotVMUsingToken - POST /api2/json/nodes/{node}/qemu/{vmid}/snapshot using api token
+// response's data is UPID of snapshot task
+func SnapshotVMUsingToken(url string, data url.Values) (model.VMResponse, error) {
+	response := model.VMResponse{}
+	body, err := config.SendRequestUsingToken(http.MethodPost, url, data)
+	if err != nil {
+		return response, err
+	}
+	if marshalErr := json.Unmarshal(body, &response); marshalErr != nil {
+		return response, marshalErr
+	}
+	return response, nil
+}
+
+// BackupVMUsingToken - POST /api2/json/nodes/{node}/vzdump using api token
+// response's data is UPID of backup task
+func BackupVMUsingToken(url string, data url.Values) (model.VMResponse, error) {
+	response := model.VMResponse{}
+	body, err := config.SendRequestUsingToken(http.MethodPost, url, data)
+	if err != nil {
+		return response, err
+	}
+	if marshalErr := json.Unmarshal(body, &response); marshalErr != nil {
+		return response, marshalErr
+	}
+	return response, nil
+}
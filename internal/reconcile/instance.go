@@ -0,0 +1,123 @@
+// Package reconcile - Finding and fixing differences between DB and Proxmox
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/model"
+)
+
+// specTolerance - difference in GiB or CPU which is ignored when comparing spec
+const specTolerance = 0.01
+
+// Instances - comparing instance table with VMs in cluster's resources
+/*
+	orphan : VM in cluster without instance, e.g. failed clone, only reported
+	ghost : instance without VM in cluster, e.g. deleted from PVE UI
+	mismatch : instance's node or spec is different from cluster, e.g. after migration
+
+	fix :
+	ghost : delete instance
+	mismatch : update instance from cluster
+*/
+func Instances(fix bool) (model.InstanceReconcileReport, error) {
+	report := model.InstanceReconcileReport{
+		Orphans:    []model.VMsInfo{},
+		Ghosts:     []string{},
+		Mismatches: []model.InstanceDrift{},
+		Fixed:      []string{},
+		Failed:     []string{},
+	}
+	resources, getResourcesErr := qemu.GetResourcesUsingToken()
+	if getResourcesErr != nil {
+		return report, fmt.Errorf("error: unable to get cluster's resources due to %s", getResourcesErr)
+	}
+	// never treat every instances as ghost from empty response
+	if len(resources) == 0 {
+		return report, errors.New("error: cluster's resources has no VM")
+	}
+	inCluster := map[string]model.VMsInfo{}
+	for _, resource := range resources {
+		inCluster[fmt.Sprint(resource.VMID)] = resource
+	}
+
+	instances := database.GetAllInstances()
+	inDB := map[string]bool{}
+	for _, instance := range instances {
+		inDB[instance.VMID] = true
+		resource, found := inCluster[instance.VMID]
+		if !found {
+			report.Ghosts = append(report.Ghosts, instance.VMID)
+			if fix {
+				recordInstanceFix(&report, instance.VMID, database.DeleteInstance(instance.VMID))
+			}
+			continue
+		}
+		drifts := compareInstance(instance, resource)
+		if len(drifts) == 0 {
+			continue
+		}
+		report.Mismatches = append(report.Mismatches, drifts...)
+		if fix {
+			recordInstanceFix(&report, instance.VMID, database.SyncInstance(
+				instance.VMID,
+				resource.Node,
+				resource.MaxCPU,
+				config.BytetoGB(resource.MaxMem),
+				config.BytetoGB(resource.MaxDisk),
+				resource.Template == 1,
+			))
+		}
+	}
+
+	images, _ := database.GetAllTemplatesID()
+	for _, resource := range resources {
+		vmid := fmt.Sprint(resource.VMID)
+		if !inDB[vmid] && !config.Contains(images, vmid) {
+			report.Orphans = append(report.Orphans, resource)
+		}
+	}
+	log.Printf("Reconciled instances : %d orphans, %d ghosts, %d mismatches, %d fixed, %d failed",
+		len(report.Orphans), len(report.Ghosts), len(report.Mismatches), len(report.Fixed), len(report.Failed))
+	return report, nil
+}
+
+// compareInstance - comparing node, spec and template flag of instance with VM in cluster
+func compareInstance(instance model.Instance, resource model.VMsInfo) []model.InstanceDrift {
+	var drifts []model.InstanceDrift
+	add := func(field, db, cluster string) {
+		drifts = append(drifts, model.InstanceDrift{VMID: instance.VMID, Field: field, DB: db, Cluster: cluster})
+	}
+	if instance.Node != resource.Node {
+		add("node", instance.Node, resource.Node)
+	}
+	if math.Abs(instance.MaxCPU-resource.MaxCPU) > specTolerance {
+		add("cpu", fmt.Sprint(instance.MaxCPU), fmt.Sprint(resource.MaxCPU))
+	}
+	if ram := config.BytetoGB(resource.MaxMem); math.Abs(instance.MaxRAM-ram) > specTolerance {
+		add("ram", fmt.Sprint(instance.MaxRAM), fmt.Sprint(ram))
+	}
+	if disk := config.BytetoGB(resource.MaxDisk); math.Abs(instance.MaxDisk-disk) > specTolerance {
+		add("disk", fmt.Sprint(instance.MaxDisk), fmt.Sprint(disk))
+	}
+	if instance.IsTemplate != (resource.Template == 1) {
+		add("template", fmt.Sprint(instance.IsTemplate), fmt.Sprint(resource.Template == 1))
+	}
+	return drifts
+}
+
+// recordInstanceFix - recording result of fixing instance into report
+func recordInstanceFix(report *model.InstanceReconcileReport, vmid string, err error) {
+	if err != nil {
+		log.Printf("Error: Could not reconcile instance ID : %s due to %s", vmid, err)
+		report.Failed = append(report.Failed, vmid)
+		return
+	}
+	report.Fixed = append(report.Fixed, vmid)
+}
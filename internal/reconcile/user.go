@@ -0,0 +1,113 @@
+// Package reconcile - Finding and fixing differences between DB and Proxmox
+package reconcile
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/edu-cloud-api/config"
+	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/access"
+	"github.com/edu-cloud-api/model"
+)
+
+// Users - comparing users in DB with users in Proxmox's realm
+/*
+	fix :
+	only in DB : create user in Proxmox with DB's password
+	only in Proxmox : disable user in Proxmox, user is never deleted automatically
+	status mismatch : set Proxmox's enable from DB's status
+*/
+func Users(fix bool) (model.UserReconcileReport, error) {
+	report := model.UserReconcileReport{
+		OnlyInDB:       []string{},
+		OnlyInProxmox:  []string{},
+		StatusMismatch: []string{},
+		Fixed:          []string{},
+		Failed:         []string{},
+	}
+	proxmoxUsers, getUsersErr := access.GetUsersUsingToken()
+	if getUsersErr != nil {
+		return report, fmt.Errorf("error: unable to list users in Proxmox due to %s", getUsersErr)
+	}
+	inProxmox := map[string]model.ProxmoxUser{}
+	for _, user := range proxmoxUsers {
+		if strings.HasSuffix(user.UserID, config.REALM) {
+			inProxmox[strings.TrimSuffix(user.UserID, config.REALM)] = user
+		}
+	}
+
+	inDB := map[string]bool{}
+	for _, group := range []string{config.STUDENT, config.FACULTY, config.ADMIN} {
+		users, _ := database.GetAllUsersByGroup(group)
+		for _, user := range users {
+			inDB[user.Username] = true
+			proxmoxUser, found := inProxmox[user.Username]
+			if !found {
+				report.OnlyInDB = append(report.OnlyInDB, user.Username)
+				if fix {
+					data := url.Values{}
+					data.Set("userid", fmt.Sprintf("%s%s", user.Username, config.REALM))
+					data.Set("password", user.Password)
+					data.Set("groups", group)
+					data.Set("enable", enableValue(user.Status))
+					if user.Email != "" {
+						data.Set("email", user.Email)
+					}
+					_, fixErr := access.CreateUser(data)
+					recordUserFix(&report, user.Username, fixErr)
+				}
+				continue
+			}
+			if proxmoxUser.IsEnabled() != user.Status {
+				report.StatusMismatch = append(report.StatusMismatch, user.Username)
+				if fix {
+					recordUserFix(&report, user.Username, setEnable(user.Username, user.Status))
+				}
+			}
+		}
+	}
+
+	for username, proxmoxUser := range inProxmox {
+		if inDB[username] {
+			continue
+		}
+		report.OnlyInProxmox = append(report.OnlyInProxmox, username)
+		if fix && proxmoxUser.IsEnabled() {
+			recordUserFix(&report, username, setEnable(username, false))
+		}
+	}
+	sort.Strings(report.OnlyInProxmox)
+	log.Printf("Reconciled users : %d only in DB, %d only in Proxmox, %d status mismatch, %d fixed, %d failed",
+		len(report.OnlyInDB), len(report.OnlyInProxmox), len(report.StatusMismatch), len(report.Fixed), len(report.Failed))
+	return report, nil
+}
+
+// recordUserFix - recording result of fixing user into report
+func recordUserFix(report *model.UserReconcileReport, username string, err error) {
+	if err != nil {
+		log.Printf("Error: Could not reconcile user : %s due to %s", username, err)
+		report.Failed = append(report.Failed, username)
+		return
+	}
+	report.Fixed = append(report.Fixed, username)
+}
+
+// setEnable - setting enable of user in Proxmox
+func setEnable(username string, enabled bool) error {
+	data := url.Values{}
+	data.Set("enable", enableValue(enabled))
+	_, err := access.UpdateUserUsingToken(config.GetURL(fmt.Sprintf("/api2/json/access/users/%s%s", username, config.REALM)), data)
+	return err
+}
+
+// enableValue - converting status into Proxmox's enable value
+func enableValue(enabled bool) string {
+	if enabled {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,52 @@
+// Package task - Task runner functions
+package task
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultConcurrency - amount of workers when concurrency is not given
+	DefaultConcurrency = 5
+	// MaxConcurrency - maximum amount of workers of each task
+	MaxConcurrency = 20
+)
+
+var running int64
+
+// Running - amount of items which are being processed by every tasks
+func Running() int64 {
+	return atomic.LoadInt64(&running)
+}
+
+// Concurrency - clamp given concurrency into {1 ... MaxConcurrency}
+func Concurrency(concurrency int) int {
+	if concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	if concurrency > MaxConcurrency {
+		return MaxConcurrency
+	}
+	return concurrency
+}
+
+// ForEach - run fn for every index from 0 to n-1 with bounded concurrency and wait until finished
+func ForEach(n, concurrency int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, Concurrency(concurrency))
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			atomic.AddInt64(&running, 1)
+			defer func() {
+				atomic.AddInt64(&running, -1)
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -3,24 +3,52 @@ package main
 
 import (
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/logging"
+	"github.com/edu-cloud-api/internal/metrics"
 	"github.com/edu-cloud-api/router"
+	"github.com/edu-cloud-api/schedule"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
 func main() {
-	database.Initialize()
+	settings, settingsErr := config.Load()
+	logging.Init(settings.LogLevel, settings.LogFormat)
+	if settingsErr != nil {
+		log.Fatal(settingsErr)
+	}
+
+	// app migrate <command> manages database's schema without serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	// database whose schema is newer than this build is refused
+	if initErr := database.Initialize(); initErr != nil {
+		log.Fatal(initErr)
+	}
 	app := fiber.New()
 
 	// Configure CORS to allow credentials and set the allowed origin to your frontend URL
 	app.Use(cors.New(cors.Config{
 		AllowCredentials: true,
 		AllowOrigins:     "*",
+		ExposeHeaders:    logging.RequestIDHeader,
 	}))
 
+	// Set request ID and log every requests
+	app.Use(logging.Middleware())
+
+	// Count requests and observe latency per route
+	app.Use(metrics.Middleware())
+
 	router.SetupRoutes(app)
 
 	// app.Get("/ws", fiberWs.New(func(c *fiberWs.Conn) {
@@ -74,14 +102,56 @@ func main() {
 	// 	log.Println("Error adding Mark Expire VM job scheduled job:", markExpireUserErr)
 	// }
 
-	// // Schedule job - Pool
-	// MarkExpirePoolErr := schedule.CronJob(cron, schedule.MarkExpirePool, "* * * ? * *")
-	// if MarkExpirePoolErr != nil {
-	// 	log.Println("Error adding Mark Expire VM job scheduled job:", MarkExpirePoolErr)
-	// }
-
 	// // Start cron job scheduler
 	// cron.Start()
 
-	log.Fatal(app.Listen(":3002"))
+	// Schedule job - Power schedules of pools
+	jobCron := schedule.SetupCron()
+	if powerScheduleErr := schedule.CronJob(jobCron, schedule.RunPowerSchedules, "0 * * * * *"); powerScheduleErr != nil {
+		log.Println("Error adding Power Schedules scheduled job:", powerScheduleErr)
+	}
+
+	// Schedule job - Pool's warning and archival at the end of semester
+	if poolLifecycleErr := schedule.CronJob(jobCron, schedule.RunPoolLifecycles, "0 0 * * * *"); poolLifecycleErr != nil {
+		log.Println("Error adding Pool Lifecycles scheduled job:", poolLifecycleErr)
+	}
+
+	// Schedule job - Users reconciliation report
+	if reconcileUsersErr := schedule.CronJob(jobCron, schedule.ReconcileUsers, "0 0 2 * * *"); reconcileUsersErr != nil {
+		log.Println("Error adding Reconcile Users scheduled job:", reconcileUsersErr)
+	}
+
+	// Schedule job - Instances reconciliation
+	if reconcileInstancesErr := schedule.CronJob(jobCron, schedule.ReconcileInstances, "0 30 * * * *"); reconcileInstancesErr != nil {
+		log.Println("Error adding Reconcile Instances scheduled job:", reconcileInstancesErr)
+	}
+
+	// Schedule job - Node's maintenance windows
+	if maintenanceErr := schedule.CronJob(jobCron, schedule.RunMaintenanceWindows, "0 * * * * *"); maintenanceErr != nil {
+		log.Println("Error adding Maintenance Windows scheduled job:", maintenanceErr)
+	}
+
+	// Schedule job - Usage metering, has to be the same as metering.Interval
+	if sampleUsageErr := schedule.CronJob(jobCron, schedule.SampleUsage, "0 */5 * * * *"); sampleUsageErr != nil {
+		log.Println("Error adding Sample Usage scheduled job:", sampleUsageErr)
+	}
+
+	// Schedule job - Usage retention, rolling up old samples daily
+	if pruneUsageErr := schedule.CronJob(jobCron, schedule.PruneUsage, "0 30 3 * * *"); pruneUsageErr != nil {
+		log.Println("Error adding Prune Usage scheduled job:", pruneUsageErr)
+	}
+	jobCron.Start()
+
+	// Reload non-critical settings on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if _, reloadErr := config.Reload(); reloadErr != nil {
+				log.Println("Error: reloading settings due to", reloadErr)
+			}
+		}
+	}()
+
+	log.Fatal(app.Listen(settings.ListenAddress))
 }
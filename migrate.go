@@ -0,0 +1,82 @@
+// Package main ...
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/edu-cloud-api/database"
+)
+
+// migrateUsage - usage of migrate subcommand
+const migrateUsage = `usage: app migrate <command>
+  up [version]   apply pending migrations until version, default : latest
+  down [steps]   roll back latest applied migrations, default : 1
+  status         list known migrations and when they were applied`
+
+// runMigrate - running migrate subcommand, returning exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+	if err := database.Connect(); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	var err error
+	switch args[0] {
+	case "up":
+		var target uint64
+		if len(args) > 1 {
+			if target, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				fmt.Printf("error: version : %s is not number\n", args[1])
+				return 2
+			}
+		}
+		err = database.MigrateUp(uint(target))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Printf("error: steps : %s is not positive number\n", args[1])
+				return 2
+			}
+		}
+		err = database.MigrateDown(steps)
+	case "status":
+		err = printMigrationStatus()
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// printMigrationStatus - printing every known migrations and schema's version
+func printMigrationStatus() error {
+	if err := database.CheckSchema(); err != nil {
+		return err
+	}
+	statuses, err := database.MigrationStatuses()
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Printf("%4d  %-40s %s\n", status.Version, status.Name, appliedAt)
+	}
+	version, versionErr := database.SchemaVersion()
+	if versionErr != nil {
+		return versionErr
+	}
+	fmt.Printf("schema version : %d, latest version : %d\n", version, database.LatestVersion())
+	return nil
+}
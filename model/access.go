@@ -29,6 +29,7 @@ type Login struct {
 type Cookies struct {
 	Cookie              http.Cookie
 	CSRFPreventionToken fiber.Cookie
+	RequestID           string // ID of request which cookies come from, sent to Proxmox as X-Request-ID
 }
 
 // CookiesResponse - struct for parsing Cookies as response
@@ -49,3 +50,30 @@ type UpdateUserBody struct {
 	Enable string `json:"enable"`
 	Groups string `json:"groups"`
 }
+
+// ProxmoxUserList - struct for listing users in proxmox
+type ProxmoxUserList struct {
+	Users []ProxmoxUser `json:"data"`
+}
+
+// ProxmoxUser - struct for user in proxmox
+type ProxmoxUser struct {
+	UserID string `json:"userid"` // {username}@{realm}
+	Enable *int   `json:"enable"` // omitted : enabled
+	Groups string `json:"groups"`
+	Email  string `json:"email"`
+}
+
+// IsEnabled - check is user enabled in proxmox
+func (u ProxmoxUser) IsEnabled() bool {
+	return u.Enable == nil || *u.Enable == 1
+}
+
+// UserReconcileReport - struct for users which are different between DB and proxmox
+type UserReconcileReport struct {
+	OnlyInDB       []string `json:"only_in_db"`
+	OnlyInProxmox  []string `json:"only_in_proxmox"`
+	StatusMismatch []string `json:"status_mismatch"` // enabled in one place but disabled in the other place
+	Fixed          []string `json:"fixed"`
+	Failed         []string `json:"failed"`
+}
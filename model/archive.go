@@ -0,0 +1,63 @@
+// Package model - structs
+package model
+
+import "time"
+
+// PoolLifecycle - struct for what happens to pool's members, VMs and templates when pool has been expired
+type PoolLifecycle struct {
+	PoolID        uint64     `gorm:"primaryKey;column:pool_id" json:"pool_id"`
+	AutoArchive   bool       `json:"auto_archive"` // archive automatically at pool's expire time
+	WarnDays      int        `json:"warn_days"`    // days before pool's expire time which members are warned
+	StopVMs       bool       `gorm:"column:stop_vms" json:"stop_vms"`
+	Backup        string     `json:"backup"`         // {none, snapshot, backup}
+	BackupStorage string     `json:"backup_storage"` // empty : BACKUP_STORAGE
+	DeleteVMs     bool       `gorm:"column:delete_vms" json:"delete_vms"`
+	Templates     string     `json:"templates"` // {keep, archive}
+	Unenroll      bool       `json:"unenroll"`  // remove pool's members after every VMs have been archived
+	WarnedAt      *time.Time `json:"warned_at"`
+	ArchivedAt    *time.Time `json:"archived_at"`
+	ArchiveTaskID uint64     `gorm:"column:archive_task_id" json:"archive_task_id"`
+}
+
+// ExtendPoolBody - struct for extending pool's expire time
+type ExtendPoolBody struct {
+	ExpireTime string `json:"expire_time"` // {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339
+}
+
+// PoolArchiveItem - struct for VM or template in pool's archive preview
+type PoolArchiveItem struct {
+	VMID    string   `json:"vmid"`
+	Name    string   `json:"name"`
+	Owner   string   `json:"owner"`
+	Node    string   `json:"node"`
+	Actions []string `json:"actions"` // {stop, snapshot, backup, delete, remove from pool}, in order
+}
+
+// PoolArchivePreview - struct for report of what archiving pool would do
+type PoolArchivePreview struct {
+	Code       string            `json:"code"`
+	Owner      string            `json:"owner"`
+	ExpireTime *time.Time        `json:"expire_time"`
+	WarnTime   *time.Time        `json:"warn_time"`
+	Lifecycle  PoolLifecycle     `json:"lifecycle"`
+	VMs        []PoolArchiveItem `json:"vms"`
+	Templates  []PoolArchiveItem `json:"templates"`
+	Unenroll   []string          `json:"unenroll"`
+	Warnings   []string          `json:"warnings"`
+}
+
+// Notification - struct for message to user, e.g. pool is going to be archived
+type Notification struct {
+	ID         uint64     `gorm:"primaryKey;column:id" json:"id"`
+	Username   string     `gorm:"index" json:"username"`
+	Kind       string     `json:"kind"`
+	Subject    string     `json:"subject"`
+	Message    string     `json:"message"`
+	CreateTime time.Time  `json:"create_time"`
+	ReadTime   *time.Time `json:"read_time"`
+}
+
+// ReadNotificationBody - struct for marking notifications as read
+type ReadNotificationBody struct {
+	ID []uint64 `json:"id"` // empty : every notifications
+}
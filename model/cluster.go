@@ -19,13 +19,26 @@ type Node struct {
 	CPU     float64 `json:"cpu"`
 	Mem     uint64  `json:"mem"`
 	UpTime  uint64  `json:"uptime"`
+
+	// from node_state, not from Proxmox
+	Cordoned     bool   `json:"cordoned"`
+	CordonReason string `json:"cordon_reason,omitempty"`
 }
 
 // VMSpec - struct of VM's specification
 type VMSpec struct {
-	Memory uint64
-	CPU    float64
-	Disk   uint64
+	Memory   uint64
+	CPU      float64
+	Disk     uint64
+	BaseDisk uint64 // Disk shared with template of linked clone when it was cloned
+	BaseVMID string // template which linked clone is based on, empty : full clone
+	PoolID   uint64 // pool which VM's resources are charged to, 0 : personal
+}
+
+// ChargedDisk - disk which is charged to quota and storage
+// linked clone is charged its full virtual disk, since its copy-on-write volume grows up to it as guest writes
+func (s VMSpec) ChargedDisk() uint64 {
+	return s.Disk
 }
 
 // StorageResource - struct of storage's resources
@@ -64,6 +77,13 @@ type VMsInfo struct {
 	MaxDisk  uint64  `json:"maxdisk"`
 	Status   string  `json:"status"`
 	MaxCPU   float64 `json:"maxcpu"`
+	CPU      float64 `json:"cpu"` // CPU's usage in {0 ... 1} of MaxCPU
+	Mem      uint64  `json:"mem"`
+	Disk     uint64  `json:"disk"`
+	NetIn    uint64  `json:"netin"`  // bytes since VM has been started
+	NetOut   uint64  `json:"netout"` // bytes since VM has been started
+	UpTime   uint64  `json:"uptime"`
+	Tags     string  `json:"tags"` // separated by ";"
 }
 
 // ISOList - ISO list
@@ -79,3 +99,20 @@ type ISOInfo struct {
 	Format  string `json:"format"`
 	CTime   uint64 `json:"ctime"`
 }
+
+// InstanceReconcileReport - struct for differences between instance table and VMs in cluster
+type InstanceReconcileReport struct {
+	Orphans    []VMsInfo       `json:"orphans"`    // VMs in cluster without instance, never deleted automatically
+	Ghosts     []string        `json:"ghosts"`     // instances without VM in cluster
+	Mismatches []InstanceDrift `json:"mismatches"` // instances which node or spec is different from cluster
+	Fixed      []string        `json:"fixed"`
+	Failed     []string        `json:"failed"`
+}
+
+// InstanceDrift - struct for field of instance which is different from cluster
+type InstanceDrift struct {
+	VMID    string `json:"vmid"`
+	Field   string `json:"field"` // {node, cpu, ram, disk, template}
+	DB      string `json:"db"`
+	Cluster string `json:"cluster"`
+}
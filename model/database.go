@@ -3,6 +3,7 @@ package model
 
 import (
 	"database/sql/driver"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -22,9 +23,10 @@ type User struct {
 	Username   string `gorm:"primaryKey"`
 	Password   string
 	Name       string
+	Email      string
 	Status     bool
-	CreateTime string
-	ExpireTime string
+	CreateTime time.Time  `gorm:"type:timestamptz"`
+	ExpireTime *time.Time `gorm:"type:timestamptz"` // nil : never expire
 	Salt       string
 }
 
@@ -33,6 +35,7 @@ type CreateUserDB struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Name     string `json:"name"`
+	Email    string `json:"email"`
 	Group    string `json:"group"`
 }
 
@@ -40,8 +43,9 @@ type CreateUserDB struct {
 type EditUserDB struct {
 	Password   string `json:"password"`
 	Name       string `json:"name"`
+	Email      string `json:"email"`
 	Status     bool   `json:"status"`
-	ExpireTime string `json:"expire_time"`
+	ExpireTime string `json:"expire_time"` // {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339
 }
 
 // InstanceLimit - struct for instance limit
@@ -68,13 +72,17 @@ type Instance struct {
 	Node         string
 	Name         string
 	IsTemplate   bool
-	MaxCPU       float64 // Amount of CPU limit
-	MaxRAM       float64 // Amount of RAM limit in GiB
-	MaxDisk      float64 // Amount of Disk limit in GiB
-	CreateTime   string
-	ExpireTime   string
+	MaxCPU       float64    // Amount of CPU limit
+	MaxRAM       float64    // Amount of RAM limit in GiB
+	MaxDisk      float64    // Amount of Disk limit in GiB
+	CreateTime   time.Time  `gorm:"type:timestamptz"`
+	ExpireTime   *time.Time `gorm:"type:timestamptz"` // nil : never expire
 	WillBeExpire bool
-	Expired      bool // true : expired
+	Expired      bool    // true : expired
+	PoolID       uint64  `gorm:"column:pool_id"`   // pool which instance's resources are charged to, 0 : personal
+	BaseVMID     string  `gorm:"column:base_vmid"` // template which linked clone is based on, empty : full clone
+	BaseDisk     float64 `gorm:"column:base_disk"` // Amount of Disk in GiB shared with base template when it was cloned
+	DataDisk     float64 `gorm:"column:data_disk"` // Amount of Disk in GiB of additional data disks (scsi1 ...)
 }
 
 // InstanceBody - struct for instance's request body
@@ -87,28 +95,176 @@ type InstanceBody struct {
 	ExpireTime string
 }
 
-// Sizing - struct for instance's template
+// Image - struct for instance's template, base image which users are able to clone with flavor
+type Image struct {
+	VMID          string         `gorm:"primaryKey;column:vmid" json:"vmid"`
+	Node          string         `json:"node"`
+	Name          string         `json:"name"`
+	FlavorID      *uint64        `gorm:"column:flavor_id" json:"flavor_id"` // default flavor when clone request has no flavor
+	Description   string         `json:"description"`
+	OS            string         `gorm:"column:os" json:"os"`
+	Icon          string         `json:"icon"` // icon's name or URL, displayed by frontend
+	Tags          pq.StringArray `gorm:"type:text[]" json:"tags"`
+	VisibleGroups pq.StringArray `gorm:"type:text[]" json:"visible_groups"` // {student, faculty, admin}, empty : every groups
+	Deprecated    bool           `json:"deprecated"`                        // hidden from catalog and could not be cloned, existing clones are kept
+	DeprecateTime *time.Time     `gorm:"type:timestamptz" json:"deprecate_time"`
+	CreateTime    time.Time      `gorm:"type:timestamptz" json:"create_time"`
+	UpdateTime    time.Time      `gorm:"type:timestamptz" json:"update_time"`
+}
+
+// ImageBody - struct for create, edit image
+type ImageBody struct {
+	VMID          string   `json:"vmid"` // only when creating, Proxmox's template
+	Name          string   `json:"name"` // empty : template's name
+	FlavorID      *uint64  `json:"flavor_id"`
+	Description   string   `json:"description"`
+	OS            string   `json:"os"`
+	Icon          string   `json:"icon"`
+	Tags          []string `json:"tags"`
+	VisibleGroups []string `json:"visible_groups"`
+	Deprecated    bool     `json:"deprecated"`
+}
+
+// Flavor - struct for preset spec which cloned VM is configured to
+type Flavor struct {
+	ID            uint64         `gorm:"primaryKey;column:id" json:"id"`
+	Name          string         `json:"name"`
+	CPU           uint64         `gorm:"column:cpu" json:"cpu"`   // Amount of CPU cores
+	RAM           float64        `gorm:"column:ram" json:"ram"`   // Amount of RAM in GiB
+	Disk          float64        `gorm:"column:disk" json:"disk"` // Amount of Disk in GiB, image's disk is grown to
+	Description   string         `json:"description"`
+	VisibleGroups pq.StringArray `gorm:"type:text[]" json:"visible_groups"` // {student, faculty, admin}, empty : every groups
+	Deprecated    bool           `json:"deprecated"`                        // hidden from catalog and could not be cloned, existing clones are kept
+	DeprecateTime *time.Time     `gorm:"type:timestamptz" json:"deprecate_time"`
+	CreateTime    time.Time      `gorm:"type:timestamptz" json:"create_time"`
+	UpdateTime    time.Time      `gorm:"type:timestamptz" json:"update_time"`
+}
+
+// FlavorBody - struct for create, edit flavor
+type FlavorBody struct {
+	Name          string   `json:"name"`
+	CPU           uint64   `json:"cpu"`
+	RAM           float64  `json:"ram"`  // in GiB
+	Disk          float64  `json:"disk"` // in GiB
+	Description   string   `json:"description"`
+	VisibleGroups []string `json:"visible_groups"`
+	Deprecated    bool     `json:"deprecated"`
+}
+
+// Sizing - struct for deprecated sizing template, image with spec of its default flavor
+// ! deprecated, kept for /admin/sizing and /vm/sizing/list, use Image and Flavor instead
 type Sizing struct {
-	VMID       string `gorm:"primaryKey;column:vmid"`
-	Node       string
-	Name       string
-	MaxCPU     float64 // Amount of CPU limit
-	MaxRAM     float64 // Amount of RAM limit in GiB
-	MaxDisk    float64 // Amount of Disk limit in GiB
-	CreateTime string
+	VMID          string         `json:"vmid"`
+	Node          string         `json:"node"`
+	Name          string         `json:"name"`
+	MaxCPU        float64        `json:"max_cpu"`  // Amount of CPU of default flavor, 0 : image has no default flavor
+	MaxRAM        float64        `json:"max_ram"`  // Amount of RAM of default flavor in GiB
+	MaxDisk       float64        `json:"max_disk"` // Amount of Disk of default flavor in GiB
+	Description   string         `json:"description"`
+	OS            string         `json:"os"`
+	Icon          string         `json:"icon"`
+	Tags          pq.StringArray `json:"tags"`
+	VisibleGroups pq.StringArray `json:"visible_groups"`
+	Deprecated    bool           `json:"deprecated"`
+	DeprecateTime *time.Time     `json:"deprecate_time"`
+	CreateTime    time.Time      `json:"create_time"`
+	UpdateTime    time.Time      `json:"update_time"`
+}
+
+// SizingBody - struct for create, edit deprecated sizing template
+// ! deprecated, use ImageBody and FlavorBody instead
+type SizingBody struct {
+	VMID          string   `json:"vmid"`     // only when creating, Proxmox's template
+	Name          string   `json:"name"`     // empty : template's name
+	MaxCPU        float64  `json:"max_cpu"`  // rounded up to whole cores
+	MaxRAM        float64  `json:"max_ram"`  // in GiB
+	MaxDisk       float64  `json:"max_disk"` // in GiB, not less than template's disk
+	Description   string   `json:"description"`
+	OS            string   `json:"os"`
+	Icon          string   `json:"icon"`
+	Tags          []string `json:"tags"`
+	VisibleGroups []string `json:"visible_groups"`
+	Deprecated    bool     `json:"deprecated"`
 }
 
 // Pool - struct for pool
 type Pool struct {
+	ID           uint64 `gorm:"primaryKey;column:id"`
+	Owner        string
+	Code         string
+	Name         string
+	VMID         pq.StringArray `gorm:"-"` // pool's templates from pool_template
+	Member       pq.StringArray `gorm:"-"` // pool's members from pool_member
+	CreateTime   time.Time      `gorm:"type:timestamptz"`
+	ExpireTime   *time.Time     `gorm:"type:timestamptz"` // nil : never expire
+	Status       bool
+	CloneMode    string // default clone's mode of pool's templates {full, linked}
+	CloneStorage string // default storage of full clone, empty : template's storage
+}
+
+// PoolCloneBody - struct for editing pool's default clone mode and storage
+type PoolCloneBody struct {
+	Mode    string `json:"mode"`    // {full, linked}
+	Storage string `json:"storage"` // only for full clone, empty : template's storage
+}
+
+// PoolMember - struct for pool's member with membership's metadata
+type PoolMember struct {
+	PoolID   uint64    `gorm:"primaryKey;column:pool_id" json:"pool_id"`
+	Username string    `gorm:"primaryKey;index" json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// PoolTemplate - struct for template which is available to pool's members
+type PoolTemplate struct {
+	PoolID  uint64    `gorm:"primaryKey;column:pool_id" json:"pool_id"`
+	VMID    string    `gorm:"primaryKey;column:vmid;index" json:"vmid"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// PoolManager - struct for pool's manager {co-instructor, ta}
+type PoolManager struct {
 	ID         uint64 `gorm:"primaryKey;column:id"`
-	Owner      string
-	Code       string
-	Name       string
-	VMID       pq.StringArray `gorm:"column:vmid;type:text[]"`
-	Member     pq.StringArray `gorm:"type:text[]"`
-	CreateTime string
-	ExpireTime string
-	Status     bool
+	PoolID     uint64 `gorm:"column:pool_id"`
+	Username   string
+	Role       string
+	CreateTime time.Time `gorm:"type:timestamptz"`
+}
+
+// PoolQuota - struct for pool's resource quota shared by members
+type PoolQuota struct {
+	PoolID      uint64  `gorm:"primaryKey;column:pool_id" json:"pool_id"`
+	MaxCPU      float64 `json:"max_cpu"`      // Amount of CPU quota
+	MaxRAM      float64 `json:"max_ram"`      // Amount of RAM quota in GiB
+	MaxDisk     float64 `json:"max_disk"`     // Amount of Disk quota in GiB
+	MaxInstance uint64  `json:"max_instance"` // Amount of instance count quota
+}
+
+// PoolAllowance - struct for member's allowance inside pool's quota
+type PoolAllowance struct {
+	PoolID      uint64  `gorm:"primaryKey;column:pool_id" json:"pool_id"`
+	Username    string  `gorm:"primaryKey" json:"username"`
+	MaxCPU      float64 `json:"max_cpu"`
+	MaxRAM      float64 `json:"max_ram"`
+	MaxDisk     float64 `json:"max_disk"`
+	MaxInstance uint64  `json:"max_instance"`
+}
+
+// UserMemberships - struct for user's rows in pools, kept to be restored when deleting user has failed
+type UserMemberships struct {
+	Members    []PoolMember
+	Allowances []PoolAllowance
+	Managers   []PoolManager
+}
+
+// PoolUsage - struct for resource usage of pool or pool's member
+type PoolUsage struct {
+	Username string  `json:"username,omitempty"`
+	CPU      float64 `json:"cpu"`
+	RAM      float64 `json:"ram"`  // in GiB
+	Disk     float64 `json:"disk"` // in GiB
+	Instance uint64  `json:"instance"`
 }
 
 // CreatePoolBody - struct for create pool's request body
@@ -123,6 +279,39 @@ type AddPoolMemberBody struct {
 	Member pq.StringArray `json:"members"`
 }
 
+// PoolInvite - struct for join code which students redeem to enroll themselves into pool
+type PoolInvite struct {
+	ID           uint64         `gorm:"primaryKey;column:id" json:"id"`
+	PoolID       uint64         `gorm:"column:pool_id;index" json:"pool_id"`
+	Code         string         `gorm:"uniqueIndex" json:"code"`
+	CreatedBy    string         `json:"created_by"`
+	MaxUses      uint64         `json:"max_uses"` // 0 : unlimited
+	Uses         uint64         `json:"uses"`
+	EmailDomains pq.StringArray `gorm:"type:text[]" json:"email_domains"`    // empty : every domains
+	ExpireTime   *time.Time     `gorm:"type:timestamptz" json:"expire_time"` // nil : never expire
+	RevokeTime   *time.Time     `gorm:"type:timestamptz" json:"revoke_time"`
+	CreateTime   time.Time      `gorm:"type:timestamptz" json:"create_time"`
+}
+
+// CreatePoolInviteBody - struct for create pool's join code
+type CreatePoolInviteBody struct {
+	ExpireTime   string   `json:"expire_time"`   // {2006-01-02, 2006-01-02T15:04} in campus's timezone or RFC3339, empty : never expire
+	MaxUses      uint64   `json:"max_uses"`      // 0 : unlimited
+	EmailDomains []string `json:"email_domains"` // e.g. ["kmitl.ac.th"], empty : every domains
+}
+
+// JoinPoolBody - struct for redeem pool's join code
+type JoinPoolBody struct {
+	Code string `json:"code"`
+}
+
+// JoinedPool - struct for pool which student has joined, without other members and templates
+type JoinedPool struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
+
 // PoolInstanceBody - struct for add pool's instance
 type PoolInstanceBody struct {
 	VMID string `json:"vmid"`
@@ -132,3 +321,34 @@ type PoolInstanceBody struct {
 type RemovePoolInstanceBody struct {
 	VMID pq.StringArray `json:"vmid"`
 }
+
+// PoolManagerBody - struct for add, remove pool's manager
+type PoolManagerBody struct {
+	Username string `json:"username"`
+	Role     string `json:"role"` // {co-instructor, ta}
+}
+
+// TransferPoolBody - struct for transfer pool's ownership
+type TransferPoolBody struct {
+	Owner        string `json:"owner"`
+	PreviousRole string `json:"previous_role"` // role of previous owner after transfer, empty to remove
+}
+
+// PoolAllowanceBody - struct for rebalance pool's member allowances
+type PoolAllowanceBody struct {
+	Mode       string          `json:"mode"` // {even, custom}
+	Allowances []PoolAllowance `json:"allowances"`
+}
+
+// GroupQuotaUsage - struct for sum of users's instance limits and instances's spec by group
+type GroupQuotaUsage struct {
+	UserGroup    string  `gorm:"column:user_group"`
+	UsedCPU      float64 `gorm:"column:used_cpu"`
+	MaxCPU       float64 `gorm:"column:max_cpu"`
+	UsedRAM      float64 `gorm:"column:used_ram"` // in GiB
+	MaxRAM       float64 `gorm:"column:max_ram"`  // in GiB
+	UsedDisk     float64 `gorm:"column:used_disk"`
+	MaxDisk      float64 `gorm:"column:max_disk"`
+	UsedInstance float64 `gorm:"column:used_instance"`
+	MaxInstance  float64 `gorm:"column:max_instance"`
+}
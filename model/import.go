@@ -0,0 +1,32 @@
+// Package model - structs
+package model
+
+// ImportUser - struct for user from class roster or directory
+type ImportUser struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Group    string `json:"group"`              // default : student
+	Password string `json:"password,omitempty"` // initial password, returned only once after import
+}
+
+// ImportBody - struct for request importing users from LDAP directory into pool
+type ImportBody struct {
+	Source string `json:"source"` // {csv, ldap}
+	Filter string `json:"filter"` // LDAP search filter, e.g. "(memberOf=cn=cs101,ou=groups,dc=example,dc=com)"
+}
+
+// ImportDiff - struct for changes which import will make
+type ImportDiff struct {
+	Create        []ImportUser  `json:"create"`         // users which are missing in DB and will be created
+	Existing      []string      `json:"existing"`       // users which have already existed
+	Enroll        []string      `json:"enroll"`         // users which will be added into pool's members
+	AlreadyMember []string      `json:"already_member"` // users which have already been pool's members
+	Invalid       []ImportError `json:"invalid"`        // rows which are skipped
+}
+
+// ImportError - struct for user which could not be imported
+type ImportError struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
@@ -0,0 +1,22 @@
+// Package model - structs
+package model
+
+// MigrateBody - struct for request migrating VM or draining node
+type MigrateBody struct {
+	Target      string `json:"target"`      // empty : allocate node by cluster's resources
+	Concurrency int    `json:"concurrency"` // amount of migrating at the same time for draining node, default : 1
+}
+
+// ProxmoxTask - struct for Proxmox's task status
+type ProxmoxTask struct {
+	Info ProxmoxTaskInfo `json:"data"`
+}
+
+// ProxmoxTaskInfo - struct for Proxmox's task status info
+type ProxmoxTaskInfo struct {
+	UPID       string `json:"upid"`
+	Node       string `json:"node"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`     // {running, stopped}
+	ExitStatus string `json:"exitstatus"` // OK or error message after stopped
+}
@@ -0,0 +1,42 @@
+// Package model - structs
+package model
+
+import "time"
+
+// NodeState - struct for node's cordon state, cordoned node is never allocated for new VMs
+type NodeState struct {
+	Node       string    `gorm:"primaryKey;column:node" json:"node"`
+	Cordoned   bool      `json:"cordoned"`
+	Reason     string    `json:"reason"`
+	UpdatedBy  string    `json:"updated_by"` // username who cordoned, or maintenance window
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// CordonBody - struct for request cordoning node
+type CordonBody struct {
+	Reason      string `json:"reason"`
+	Drain       bool   `json:"drain"`       // migrating every VMs off node after cordoned
+	Concurrency int    `json:"concurrency"` // amount of migrating at the same time when draining
+}
+
+// MaintenanceWindowBody - struct for request scheduling node's maintenance window
+type MaintenanceWindowBody struct {
+	StartTime time.Time `json:"start_time"` // in RFC3339
+	EndTime   time.Time `json:"end_time"`   // in RFC3339
+	Reason    string    `json:"reason"`
+	Drain     bool      `json:"drain"`
+}
+
+// MaintenanceWindow - struct for node's maintenance window, node is cordoned from start time until end time
+type MaintenanceWindow struct {
+	ID          uint64    `gorm:"primaryKey;column:id" json:"id"`
+	Node        string    `gorm:"index" json:"node"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Reason      string    `json:"reason"`
+	Drain       bool      `json:"drain"`
+	Owner       string    `json:"owner"`  // username who scheduled
+	Status      string    `json:"status"` // {pending, running, success}
+	DrainTaskID uint64    `gorm:"column:drain_task_id" json:"drain_task_id"`
+	CreateTime  time.Time `json:"create_time"`
+}
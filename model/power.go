@@ -0,0 +1,32 @@
+// Package model - structs
+package model
+
+import "time"
+
+// PowerBatchBody - struct for request power action on many VMs
+type PowerBatchBody struct {
+	Action      string   `json:"action"` // {start, stop, shutdown, suspend, resume, reset}
+	VMID        []string `json:"vmid"`   // ignored when acting on pool
+	Concurrency int      `json:"concurrency"`
+}
+
+// PowerScheduleBody - struct for request scheduling power action on pool's VMs
+type PowerScheduleBody struct {
+	Action string    `json:"action"`
+	RunAt  time.Time `json:"run_at"` // one-time schedule in RFC3339
+	Cron   string    `json:"cron"`   // recurring schedule in cron format with seconds, e.g. "0 0 18 * * FRI"
+}
+
+// PowerSchedule - struct for scheduled power action on every pool's VMs at run time
+type PowerSchedule struct {
+	ID         uint64    `gorm:"primaryKey;column:id" json:"id"`
+	PoolID     uint64    `gorm:"column:pool_id;index" json:"pool_id"`
+	Owner      string    `json:"owner"` // username who scheduled
+	Action     string    `json:"action"`
+	RunAt      time.Time `json:"run_at"`
+	Cron       string    `json:"cron"`
+	LastRun    time.Time `json:"last_run"`
+	LastTaskID uint64    `gorm:"column:last_task_id" json:"last_task_id"`
+	Enabled    bool      `json:"enabled"`
+	CreateTime time.Time `json:"create_time"`
+}
@@ -0,0 +1,18 @@
+// Package model - structs
+package model
+
+import "time"
+
+// SchemaMigration - struct for applied database's migration in schema_migrations
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// MigrationStatus - struct for known migration and when it was applied, nil when it is pending
+type MigrationStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
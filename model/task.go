@@ -0,0 +1,48 @@
+// Package model - structs
+package model
+
+import "time"
+
+// Task - struct for long running task {provision, power, migrate}
+type Task struct {
+	ID         uint64    `gorm:"primaryKey;column:id" json:"id"`
+	Type       string    `json:"type"`
+	Owner      string    `json:"owner"`  // username who requested the task
+	Target     string    `json:"target"` // {code}/{owner} of pool, node's name or VMID
+	Status     string    `json:"status"` // {pending, running, success, failure}
+	Total      uint64    `json:"total"`
+	Done       uint64    `json:"done"`
+	Failed     uint64    `json:"failed"`
+	Params     string    `json:"-"`                                   // request's body in JSON for retrying
+	RequestID  string    `gorm:"column:request_id" json:"request_id"` // ID of request which created the task, empty for scheduled task
+	CreateTime time.Time `json:"create_time"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TaskItem - struct for per-target result of task
+type TaskItem struct {
+	ID         uint64    `gorm:"primaryKey;column:id" json:"id"`
+	TaskID     uint64    `gorm:"column:task_id;index" json:"task_id"`
+	Target     string    `json:"target"` // member's username or VMID
+	VMID       string    `gorm:"column:vmid" json:"vmid"`
+	Node       string    `json:"node"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"` // {pending, running, success, failure}
+	Message    string    `json:"message"`
+	CIUser     string    `gorm:"column:ciuser" json:"ciuser,omitempty"`
+	CIPass     string    `gorm:"column:cipassword" json:"cipassword,omitempty"` // encrypted with ENCRYPT_KEY
+	HasPass    bool      `gorm:"-" json:"has_password,omitempty"`               // password is set but masked for sender
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// ProvisionBody - struct for request provisioning pool's template for every member
+type ProvisionBody struct {
+	VMID        string   `json:"vmid"`         // pool's template
+	NamePattern string   `json:"name_pattern"` // {code}, {username}, {index} are replaced, default : "{code}-{username}"
+	Storage     string   `json:"storage"`      // only for full clone, empty : pool's default storage
+	Mode        string   `json:"mode"`         // {full, linked}, empty : pool's default clone mode
+	Concurrency int      `json:"concurrency"`  // amount of cloning at the same time, default : 5
+	Members     []string `json:"members"`      // empty : every pool's members
+	CIUser      string   `json:"ciuser"`       // empty : member's username
+	CIPass      string   `json:"cipassword"`   // empty : generate password for each member
+}
@@ -0,0 +1,45 @@
+// Package model - structs
+package model
+
+import "time"
+
+// UsageSample - struct for VM's usage which is sampled from cluster's resources on a schedule
+// owner, pool and group are recorded at sampling time, so reports are not changed by later transfers
+type UsageSample struct {
+	ID         uint64    `gorm:"primaryKey;column:id" json:"id"`
+	VMID       string    `gorm:"column:vmid;index" json:"vmid"`
+	Node       string    `json:"node"`
+	OwnerID    string    `gorm:"column:ownerid;index" json:"ownerid"`
+	PoolID     uint64    `gorm:"column:pool_id;index" json:"pool_id"`
+	UserGroup  string    `gorm:"column:user_group" json:"group"`
+	Status     string    `json:"status"`
+	MaxCPU     float64   `gorm:"column:max_cpu" json:"maxcpu"`
+	CPU        float64   `json:"cpu"` // CPU's usage in {0 ... 1} of MaxCPU
+	MaxMem     uint64    `gorm:"column:max_mem" json:"maxmem"`
+	Mem        uint64    `json:"mem"`
+	MaxDisk    uint64    `gorm:"column:max_disk" json:"maxdisk"`
+	NetIn      uint64    `gorm:"column:net_in" json:"netin"`             // bytes since previous sample
+	NetOut     uint64    `gorm:"column:net_out" json:"netout"`           // bytes since previous sample
+	UpTime     uint64    `gorm:"column:up_time" json:"uptime"`           // seconds
+	Interval   uint64    `gorm:"column:sample_interval" json:"interval"` // seconds which this sample represents
+	SampleTime time.Time `gorm:"index" json:"sample_time"`
+}
+
+// UsageReport - struct for usage's roll-up of user, pool or group in period
+/*
+	cpu_hours : allocated CPU × running hours
+	used_cpu_hours : CPU's usage × allocated CPU × running hours
+	ram_gb_hours : allocated RAM in GiB × running hours
+	disk_gb_hours : allocated Disk in GiB × hours, whether VM is running or not
+*/
+type UsageReport struct {
+	Key          string  `gorm:"column:key" json:"key"` // username, {code}/{owner} of pool or group's name
+	VMs          uint64  `gorm:"column:vms" json:"vms"`
+	RunningHours float64 `gorm:"column:running_hours" json:"running_hours"`
+	CPUHours     float64 `gorm:"column:cpu_hours" json:"cpu_hours"`
+	UsedCPUHours float64 `gorm:"column:used_cpu_hours" json:"used_cpu_hours"`
+	RAMGBHours   float64 `gorm:"column:ram_gb_hours" json:"ram_gb_hours"`
+	DiskGBHours  float64 `gorm:"column:disk_gb_hours" json:"disk_gb_hours"`
+	NetIn        uint64  `gorm:"column:net_in" json:"netin"`
+	NetOut       uint64  `gorm:"column:net_out" json:"netout"`
+}
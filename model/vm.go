@@ -108,7 +108,9 @@ type ConfigDetail struct {
 	SearchDomain string `json:"searchdomain"`
 	VMGenID      string `json:"vmgenid"`
 	OSType       string `json:"ostype"`
-	Tags         string `json:"tags"`
+	Tags         string `json:"tags"` // separated by ";"
+	Name         string `json:"name"`
+	Description  string `json:"description"`
 	BootDisk     string `json:"bootdisk"`
 	VGA          string `json:"vga"`
 	Net0         string `json:"net0"`
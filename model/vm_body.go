@@ -7,6 +7,8 @@ type CloneBody struct {
 	Storage string `json:"storage"` // Storage name - {"ceph-vm, ceph-vm2 ..."}
 	CIUser  string `json:"ciuser"`
 	CIPass  string `json:"cipassword"`
+	Flavor  uint64 `json:"flavor"` // flavor's ID, 0 : image's default flavor or template's spec
+	Mode    string `json:"mode"`   // {full, linked}, empty : pool's default clone mode or full
 }
 
 // CreateBody - struct for request Creating VM
@@ -37,9 +39,23 @@ type DeleteBody struct {
 
 // EditBody - struct for request Editing VM configuration
 type EditBody struct {
-	Memory uint64  `json:"memory"`
-	Cores  float64 `json:"cores"`
-	Disk   uint64  `json:"disk"`
+	Memory    uint64         `json:"memory"`     // in MB, 0 : unchanged
+	Cores     float64        `json:"cores"`      // 0 : unchanged
+	Disk      int64          `json:"disk"`       // in GiB to grow scsi0, negative is rejected since disk could not be shrunk
+	DataDisks []DataDiskBody `json:"data_disks"` // attached as scsi1, scsi2, ...
+}
+
+// DataDiskBody - struct for additional data disk of Editing VM configuration
+type DataDiskBody struct {
+	Size    uint64 `json:"size"`    // in GiB
+	Storage string `json:"storage"` // RBD storage of cluster, empty : storage of scsi0
+}
+
+// EditInfoBody - struct for request Editing VM's name, description and tags
+type EditInfoBody struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"` // empty : description is removed
+	Tags        []string `json:"tags"`        // empty : tags are removed
 }
 
 // StartBody - struct for request Starting VM
@@ -0,0 +1,44 @@
+// Package model - structs
+package model
+
+import "time"
+
+// RRDData - struct for VM's rrddata from Proxmox
+type RRDData struct {
+	Data []RRDPoint `json:"data"`
+}
+
+// RRDPoint - struct for one point of VM's rrddata, rates are in bytes per second
+type RRDPoint struct {
+	Time      int64   `json:"time"`
+	CPU       float64 `json:"cpu"` // in {0 ... 1} of MaxCPU
+	MaxCPU    float64 `json:"maxcpu"`
+	Mem       float64 `json:"mem"`
+	MaxMem    float64 `json:"maxmem"`
+	DiskRead  float64 `json:"diskread"`
+	DiskWrite float64 `json:"diskwrite"`
+	NetIn     float64 `json:"netin"`
+	NetOut    float64 `json:"netout"`
+}
+
+// VMMetrics - struct for VM's performance history in unified schema
+type VMMetrics struct {
+	VMID      string          `json:"vmid"`
+	Node      string          `json:"node"`
+	Timeframe string          `json:"timeframe"` // {hour, day, week}
+	Step      int64           `json:"step"`      // seconds between points
+	Points    []VMMetricPoint `json:"points"`
+}
+
+// VMMetricPoint - struct for one point of VM's performance history
+type VMMetricPoint struct {
+	Time      time.Time `json:"time"`
+	CPU       float64   `json:"cpu"`      // usage in percent of allocated CPU
+	CPUs      float64   `json:"cpus"`     // allocated CPU
+	MemUsed   float64   `json:"mem_used"` // bytes
+	MemTotal  float64   `json:"mem_total"`
+	DiskRead  float64   `json:"disk_read"` // bytes per second
+	DiskWrite float64   `json:"disk_write"`
+	NetIn     float64   `json:"net_in"` // bytes per second
+	NetOut    float64   `json:"net_out"`
+}
@@ -11,14 +11,19 @@ func SetupRoutes(app *fiber.App) {
 	// Health Check
 	app.Get("/", handler.Healthy)
 
+	// Prometheus's metrics
+	app.Get("/metrics", handler.Metrics)
+
 	// DB's User
 	user := app.Group("user")
 	user.Get("/group/:group", handler.GetUsersDB)
 	user.Get("/group/student/list", handler.GetStudentsDB)
-	user.Post("/create", handler.CreateUserDB) // create user, user's limit in DB
+	user.Post("/create", handler.CreateUserDB) // create user, user's limit in DB and Proxmox
 	user.Get(":username", handler.GetUserDB)
-	user.Delete(":username/delete", handler.DeleteUserDB) // delete user, user's limit in DB
+	user.Delete(":username/delete", handler.DeleteUserDB) // delete user, user's limit in DB and Proxmox
 	user.Put(":username/update", handler.UpdateUserDB)
+	user.Put(":username/disable", handler.DisableUserDB)
+	user.Put(":username/enable", handler.EnableUserDB)
 
 	// user's limit
 	user.Get(":username/limit", handler.GetUserLimitDB)
@@ -29,17 +34,95 @@ func SetupRoutes(app *fiber.App) {
 	pool.Get("/owner/:username", handler.GetPoolsDB)
 	pool.Get(":code/owner/:username", handler.GetPoolDB)
 	pool.Get("/list", handler.GetPoolsByMemberDB)
+	pool.Get("/managed", handler.GetPoolsByManagerDB)
 	pool.Post("/create", handler.CreatePoolDB)
 	pool.Delete(":code/owner/:username", handler.DeletePoolDB)
+	pool.Get(":code/owner/:username/members", handler.GetPoolMembersDB)
 	pool.Get(":code/owner/:username/members/remain", handler.GetRemainStudents)
 	pool.Post(":code/owner/:username/members/add", handler.AddMembersPoolDB)
+	pool.Post(":code/owner/:username/members/import", handler.ImportPoolMembers)
+	pool.Post(":code/owner/:username/members/remove", handler.RemoveMembersPoolDB)
 	pool.Post(":code/owner/:username/instances/add", handler.AddInstancesPoolDB)
 	pool.Post(":code/owner/:username/instances/remove", handler.RemoveInstancesPoolDB)
+	pool.Get(":code/owner/:username/members/vm/list", handler.GetPoolMembersVMList)
+	pool.Post(":code/owner/:username/transfer", handler.TransferPoolDB)
+	pool.Put(":code/owner/:username/clone/update", handler.UpdatePoolCloneDB)
+
+	// Pool's join codes and self-enrollment
+	pool.Post("/join", handler.JoinPoolDB)
+	pool.Get(":code/owner/:username/invites", handler.GetPoolInvitesDB)
+	pool.Post(":code/owner/:username/invites", handler.CreatePoolInviteDB)
+	pool.Delete(":code/owner/:username/invites/:id", handler.RevokePoolInviteDB)
+
+	// Pool's quota
+	pool.Get(":code/owner/:username/quota", handler.GetPoolQuotaDB)
+	pool.Put(":code/owner/:username/quota/update", handler.UpdatePoolQuotaDB)
+	pool.Get(":code/owner/:username/usage", handler.GetPoolUsageDB)
+	pool.Put(":code/owner/:username/allowance/update", handler.UpdatePoolAllowanceDB)
+
+	// Pool's managers {co-instructor, ta}
+	pool.Get(":code/owner/:username/managers", handler.GetPoolManagersDB)
+	pool.Post(":code/owner/:username/managers/add", handler.AddPoolManagerDB)
+	pool.Post(":code/owner/:username/managers/remove", handler.RemovePoolManagerDB)
+
+	// Pool's bulk provisioning
+	pool.Post(":code/owner/:username/provision", handler.ProvisionPool)
+	pool.Get(":code/owner/:username/provision/:id", handler.GetProvisionTask)
+	pool.Post(":code/owner/:username/provision/:id/retry", handler.RetryProvisionTask)
+
+	// Pool's bulk power management
+	pool.Post(":code/owner/:username/power", handler.PowerPoolDB)
+	pool.Get(":code/owner/:username/power/schedule", handler.GetPowerSchedulesDB)
+	pool.Post(":code/owner/:username/power/schedule", handler.CreatePowerScheduleDB)
+	pool.Delete(":code/owner/:username/power/schedule/:id", handler.DeletePowerScheduleDB)
+
+	// Pool's lifecycle at the end of semester
+	pool.Get(":code/owner/:username/lifecycle", handler.GetPoolLifecycleDB)
+	pool.Put(":code/owner/:username/lifecycle/update", handler.UpdatePoolLifecycleDB)
+	pool.Get(":code/owner/:username/archive/preview", handler.PreviewPoolArchive)
+	pool.Post(":code/owner/:username/archive", handler.ArchivePoolDB)
+	pool.Post(":code/owner/:username/extend", handler.ExtendPoolDB)
+
+	// Admin
+	admin := app.Group("/admin")
+	admin.Get("/user/reconcile", handler.ReconcileUsers)
+	admin.Get("/reconcile", handler.ReconcileInstances)
+	admin.Post("/config/reload", handler.ReloadSettings)
+	admin.Get("/image", handler.GetImagesDB)
+	admin.Get("/image/:vmid", handler.GetImageDB)
+	admin.Post("/image", handler.CreateImageDB)
+	admin.Put("/image/:vmid", handler.UpdateImageDB)
+	admin.Delete("/image/:vmid", handler.DeleteImageDB)
+	admin.Get("/flavor", handler.GetFlavorsDB)
+	admin.Get("/flavor/:id", handler.GetFlavorDB)
+	admin.Post("/flavor", handler.CreateFlavorDB)
+	admin.Put("/flavor/:id", handler.UpdateFlavorDB)
+	admin.Delete("/flavor/:id", handler.DeleteFlavorDB)
+	admin.Get("/sizing", handler.GetSizingsDB)            // ! deprecated by /image and /flavor
+	admin.Get("/sizing/:vmid", handler.GetSizingDB)       // ! deprecated by /image/:vmid
+	admin.Post("/sizing", handler.CreateSizingDB)         // ! deprecated by /flavor and /image
+	admin.Put("/sizing/:vmid", handler.UpdateSizingDB)    // ! deprecated by /image/:vmid
+	admin.Delete("/sizing/:vmid", handler.DeleteSizingDB) // ! deprecated by /image/:vmid
+
+	// Usage
+	usage := app.Group("/usage")
+	usage.Get("/", handler.GetUsageDB)
+	usage.Get("/user/:username", handler.GetUserUsageDB)
+	usage.Get("/pool/:code/owner/:username", handler.GetPoolUsageReportDB)
+
+	// Task
+	task := app.Group("/task")
+	task.Get("/:id", handler.GetTaskDB)
+
+	// Notification
+	notification := app.Group("/notification")
+	notification.Get("/", handler.GetNotificationsDB)
+	notification.Post("/read", handler.ReadNotificationsDB)
 
 	// Proxmox's Access
 	access := app.Group("/access")
 	access.Post("/ticket", handler.GetTicket)
-	access.Post("/user/create", handler.CreateUser) // create user in Proxmox
+	access.Post("/user/create", handler.CreateUser) // same as /user/create, ! to be deprecated by /user/create
 	access.Put("/user/:username/update", handler.UpdateUser)
 	access.Delete("/user/:username/delete", handler.DeleteUser)
 
@@ -54,6 +137,14 @@ func SetupRoutes(app *fiber.App) {
 	vm := app.Group("/vm")
 	vm.Get("/list", handler.GetVMList)
 	vm.Get("/template/list", handler.GetTemplateList)
+	vm.Get("/image/list", handler.GetImageCatalog)
+	vm.Get("/flavor/list", handler.GetFlavorCatalog)
+	vm.Get("/sizing/list", handler.GetSizingCatalog) // ! deprecated by /image/list and /flavor/list
+	vm.Get("/:vmid", handler.GetVM)
+	vm.Get("/:vmid/console", handler.GetVncConsole)
+	vm.Get("/:vmid/metrics", handler.GetVMMetrics)
+	vm.Post("/:vmid/migrate", handler.MigrateVM)
+	vm.Put("/:vmid/info", handler.EditVMInfo)
 
 	vm.Post("/create", handler.CreateVM)
 	vm.Delete("/destroy", handler.DeleteVM)
@@ -72,6 +163,7 @@ func SetupRoutes(app *fiber.App) {
 	status.Post("/suspend", handler.SuspendVM)
 	status.Post("/resume", handler.ResumeVM)
 	status.Post("/reset", handler.ResetVM)
+	status.Post("/batch", handler.PowerBatchVM)
 
 	// Cluster
 	cluster := app.Group("/cluster")
@@ -84,4 +176,10 @@ func SetupRoutes(app *fiber.App) {
 	// Node
 	clusterNode := cluster.Group("node")
 	clusterNode.Get("/:name", handler.GetNode)
+	clusterNode.Post("/:name/drain", handler.DrainNode)
+	clusterNode.Put("/:name/cordon", handler.CordonNode)
+	clusterNode.Put("/:name/uncordon", handler.UncordonNode)
+	clusterNode.Get("/:name/maintenance", handler.GetMaintenanceWindowsDB)
+	clusterNode.Post("/:name/maintenance", handler.CreateMaintenanceWindowDB)
+	clusterNode.Delete("/:name/maintenance/:id", handler.DeleteMaintenanceWindowDB)
 }
@@ -3,11 +3,22 @@ package schedule
 import (
 	"fmt"
 	"log"
+	"reflect"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/edu-cloud-api/config"
 	"github.com/edu-cloud-api/database"
+	"github.com/edu-cloud-api/internal/archive"
+	"github.com/edu-cloud-api/internal/cluster"
+	"github.com/edu-cloud-api/internal/metering"
+	"github.com/edu-cloud-api/internal/metrics"
+	"github.com/edu-cloud-api/internal/migrate"
+	"github.com/edu-cloud-api/internal/power"
 	"github.com/edu-cloud-api/internal/qemu"
+	"github.com/edu-cloud-api/internal/reconcile"
+	"github.com/edu-cloud-api/model"
 	"github.com/robfig/cron/v3"
 )
 
@@ -21,8 +32,12 @@ func SetupCron() *cron.Cron {
 // CronJob - function for retrieve any task as cron job
 // 0 0 0 * * ?
 func CronJob(cron *cron.Cron, task func() error, schedule string) error {
+	job := jobName(task)
 	_, err := cron.AddFunc(schedule, func() {
-		if taskErr := task(); taskErr != nil {
+		start := time.Now()
+		taskErr := task()
+		metrics.ObserveJob(job, start, taskErr)
+		if taskErr != nil {
 			return
 		}
 	})
@@ -32,17 +47,29 @@ func CronJob(cron *cron.Cron, task func() error, schedule string) error {
 	return nil
 }
 
+// jobName - name of scheduled job's function, e.g. RunPowerSchedules
+func jobName(task func() error) string {
+	name := runtime.FuncForPC(reflect.ValueOf(task).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
 // ExpireVM - check expire date on instance table then mark it will be deleted
+// instance without expire time never expires, days are counted in campus's timezone
 func ExpireVM() error {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	now := config.Now()
 	instances := database.GetAllInstances()
 	for _, instance := range instances {
-		expireDate, _ := time.Parse(config.TIME_FORMAT, instance.ExpireTime)
-		threeDaysAfter := expireDate.AddDate(0, 0, 2)
-		if instance.WillBeExpire && instance.Expired && today.After(threeDaysAfter) {
-			log.Printf("instance ID : %s, expire date : %s, today : %s", instance.VMID, instance.ExpireTime, today.Format(config.TIME_FORMAT))
+		if instance.ExpireTime == nil {
+			continue
+		}
+		expireTime := instance.ExpireTime.In(config.Location())
+		threeDaysAfter := expireTime.AddDate(0, 0, 3)
+		if instance.WillBeExpire && instance.Expired && !now.Before(threeDaysAfter) {
+			log.Printf("instance ID : %s, expire time : %s, now : %s", instance.VMID, expireTime.Format(time.RFC3339), now.Format(time.RFC3339))
 			log.Printf("instance ID : %s was expired and will be deleted", instance.VMID)
 
+			instance.Node = cluster.ResolveNode(instance.VMID, instance.Node)
+
 			// Get VM's info
 			vmStatusURL := config.GetURL(fmt.Sprintf("/api2/json/nodes/%s/qemu/%s/status/current", instance.Node, instance.VMID))
 			vm, err := qemu.GetVMUsingToken(vmStatusURL)
@@ -63,7 +90,7 @@ func ExpireVM() error {
 				}
 
 				// Waiting until stopping process has been completed
-				stopped := qemu.CheckStatus(instance.Node, instance.VMID, []string{"stopped"}, false, (5 * time.Minute), time.Second)
+				stopped := qemu.CheckStatus(instance.Node, instance.VMID, []string{"stopped"}, false, config.Get().PowerTimeout, time.Second)
 				if stopped {
 					log.Printf("Finished stopping VMID : %s in %s", instance.VMID, instance.Node)
 				}
@@ -93,21 +120,24 @@ func ExpireVM() error {
 
 // MarkExpireVM - check expire date on instance table then mark it will be expired
 func MarkExpireVM() error {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	now := config.Now()
 	instances := database.GetAllInstances()
 	for _, instance := range instances {
-		expireDate, _ := time.Parse(config.TIME_FORMAT, instance.ExpireTime)
-		oneWeekBefore := expireDate.AddDate(0, 0, -7)
-		if !instance.WillBeExpire && !instance.Expired && today.After(oneWeekBefore) {
-			log.Printf("instance ID : %s, expire date : %s, today : %s", instance.VMID, instance.ExpireTime, today.Format(config.TIME_FORMAT))
+		if instance.ExpireTime == nil {
+			continue
+		}
+		expireTime := instance.ExpireTime.In(config.Location())
+		oneWeekBefore := expireTime.AddDate(0, 0, -7)
+		if !instance.WillBeExpire && !instance.Expired && !now.Before(oneWeekBefore) {
+			log.Printf("instance ID : %s, expire time : %s, now : %s", instance.VMID, expireTime.Format(time.RFC3339), now.Format(time.RFC3339))
 			log.Printf("instance ID : %s will be marked and will be expired within 7 days", instance.VMID)
 			if err := database.MarkWillBeExpired(instance.VMID); err != nil {
 				return err
 			}
 		}
-		if today.Equal(expireDate) || today.After(expireDate) {
+		if !now.Before(expireTime) {
 			if instance.WillBeExpire && !instance.Expired {
-				log.Printf("instance ID : %s, expire date : %s, today : %s", instance.VMID, instance.ExpireTime, today.Format(config.TIME_FORMAT))
+				log.Printf("instance ID : %s, expire time : %s, now : %s", instance.VMID, expireTime.Format(time.RFC3339), now.Format(time.RFC3339))
 				log.Printf("instance ID : %s was expired and will be deleted within 3 days", instance.VMID)
 				if err := database.MarkInstanceExpired(instance.VMID); err != nil {
 					return err
@@ -120,17 +150,20 @@ func MarkExpireVM() error {
 
 // MarkExpireUser - check expire date on user table then mark it will be expired
 func MarkExpireUser() error {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+	now := config.Now()
 	for _, group := range []string{config.STUDENT, config.FACULTY, config.ADMIN} {
 		users, getUsersErr := database.GetAllUsersByGroup(group)
 		if getUsersErr != nil {
 			return getUsersErr
 		}
 		for _, user := range users {
-			expireDate, _ := time.Parse(config.TIME_FORMAT, user.ExpireTime)
-			oneMonthBefore := expireDate.AddDate(0, -1, 0)
-			if user.Status && today.After(oneMonthBefore) {
-				log.Printf("user ID : %s, expire date : %s, today : %s", user.Username, user.ExpireTime, today.Format(config.TIME_FORMAT))
+			if user.ExpireTime == nil {
+				continue
+			}
+			expireTime := user.ExpireTime.In(config.Location())
+			oneMonthBefore := expireTime.AddDate(0, -1, 0)
+			if user.Status && !now.Before(oneMonthBefore) {
+				log.Printf("user ID : %s, expire time : %s, now : %s", user.Username, expireTime.Format(time.RFC3339), now.Format(time.RFC3339))
 				log.Printf("user ID : %s will be marked and will be expired within 30 days", user.Username)
 				if err := database.MarkUserExpired(user.Username, group); err != nil {
 					return err
@@ -141,31 +174,198 @@ func MarkExpireUser() error {
 	return nil
 }
 
-// MarkExpirePool - check expire date on pool table then mark it will be expired
-func MarkExpirePool() error {
-	today := time.Now().UTC().Truncate(24 * time.Hour)
+// RunPoolLifecycles - warning pool's members before pool's expire time, then archiving pool at expire time
+// pool without expire time or with auto archive disabled is only warned and marked as expired
+func RunPoolLifecycles() error {
+	now := config.Now()
 	pools, getPoolsErr := database.GetAllPools()
 	if getPoolsErr != nil {
 		return getPoolsErr
 	}
 	for _, pool := range pools {
-		expireDate, _ := time.Parse(config.TIME_FORMAT, pool.ExpireTime)
-		oneMonthBefore := expireDate.AddDate(0, -1, 0)
-		// sevenDaysAfter := expireDate.AddDate(0, 0, 6)
-		if pool.Status && today.After(oneMonthBefore) {
-			log.Printf("pool ID : %d, expire date : %s, today : %s", pool.ID, pool.ExpireTime, today.Format(config.TIME_FORMAT))
-			log.Printf("pool ID : %d will be marked and will be expired within 30 days", pool.ID)
+		if pool.ExpireTime == nil {
+			continue
+		}
+		lifecycle, getLifecycleErr := database.GetPoolLifecycle(pool.ID)
+		if getLifecycleErr != nil {
+			return getLifecycleErr
+		}
+		if lifecycle.ArchivedAt != nil {
+			continue
+		}
+		expireTime := pool.ExpireTime.In(config.Location())
+		if lifecycle.WarnedAt == nil && !now.Before(*archive.WarnTime(pool, lifecycle)) {
+			log.Printf("pool ID : %d, expire time : %s, now : %s", pool.ID, expireTime.Format(time.RFC3339), now.Format(time.RFC3339))
+			log.Printf("pool ID : %d will be expired within %d days, warning members", pool.ID, lifecycle.WarnDays)
+			if err := archive.Warn(pool, lifecycle); err != nil {
+				log.Printf("Schedule job error : warning members of pool ID : %d due to %s", pool.ID, err)
+			}
+		}
+		if now.Before(expireTime) {
+			continue
+		}
+		if pool.Status {
+			log.Printf("pool ID : %d was expired", pool.ID)
 			if err := database.MarkPoolExpired(pool.ID); err != nil {
 				return err
 			}
 		}
-		// if !pool.Status && today.After(sevenDaysAfter) {
-		// 	log.Printf("pool ID : %d, expire date : %s, today : %s", pool.ID, pool.ExpireTime, today.Format(config.TIME_FORMAT))
-		// 	log.Printf("pool ID : %d was expired, deleting ...", pool.ID)
-		// 	if err := database.DeletePool(pool.Code, pool.Owner); err != nil {
-		// 		return err
-		// 	}
-		// }
+		// pool which is being archived, or whose archive task has failed, is archived again only on request
+		if !lifecycle.AutoArchive || lifecycle.ArchiveTaskID != 0 {
+			continue
+		}
+		if _, startErr := archive.Start(pool.Owner, pool, ""); startErr != nil {
+			log.Printf("Schedule job error : archiving pool ID : %d due to %s", pool.ID, startErr)
+			continue
+		}
+		log.Printf("pool ID : %d was expired and is being archived", pool.ID)
+	}
+	return nil
+}
+
+// CronParser - parser of cron format with seconds which is used by power schedules
+var CronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// RunPowerSchedules - check power schedules then start power task for every due schedules
+func RunPowerSchedules() error {
+	now := time.Now().UTC()
+	schedules, getSchedulesErr := database.GetEnabledPowerSchedules()
+	if getSchedulesErr != nil {
+		return getSchedulesErr
+	}
+	for _, powerSchedule := range schedules {
+		due := !powerSchedule.RunAt.IsZero() && !powerSchedule.RunAt.After(now)
+		if powerSchedule.Cron != "" {
+			cronSchedule, parseErr := CronParser.Parse(powerSchedule.Cron)
+			if parseErr != nil {
+				log.Printf("Schedule job error : parsing cron of power schedule ID : %d due to %s", powerSchedule.ID, parseErr)
+				continue
+			}
+			last := powerSchedule.LastRun
+			if last.IsZero() {
+				last = powerSchedule.CreateTime
+			}
+			// cron is written in campus's timezone, e.g. "Friday 18:00" on campus
+			due = !cronSchedule.Next(last.In(config.Location())).After(now)
+		}
+		if !due {
+			continue
+		}
+		pool, getPoolErr := database.GetPoolByID(powerSchedule.PoolID)
+		if getPoolErr != nil {
+			log.Printf("Schedule job error : getting pool of power schedule ID : %d due to %s", powerSchedule.ID, getPoolErr)
+			continue
+		}
+		log.Printf("power schedule ID : %d is due, %s every VMs of pool code : %s, owner : %s", powerSchedule.ID, powerSchedule.Action, pool.Code, pool.Owner)
+		vmids := database.GetAllInstancesIDByPool(pool.ID)
+		powerTask, startErr := power.Start(powerSchedule.Owner, fmt.Sprintf("%s/%s", pool.Code, pool.Owner), powerSchedule.Action, "", vmids, 0)
+		if startErr != nil {
+			log.Printf("Schedule job error : starting power schedule ID : %d due to %s", powerSchedule.ID, startErr)
+		}
+		if markErr := database.MarkPowerScheduleRun(powerSchedule, now, powerTask.ID); markErr != nil {
+			return markErr
+		}
+	}
+	return nil
+}
+
+// maintenanceUpdater - updated_by of node_state which is cordoned by maintenance window
+const maintenanceUpdater = "maintenance window"
+
+// RunMaintenanceWindows - cordon (and drain) node at start time of maintenance window, uncordon it at end time
+func RunMaintenanceWindows() error {
+	now := time.Now().UTC()
+	windows, getWindowsErr := database.GetUnfinishedMaintenanceWindows()
+	if getWindowsErr != nil {
+		return getWindowsErr
+	}
+	for _, window := range windows {
+		reason := fmt.Sprintf("maintenance window ID : %d, %s", window.ID, window.Reason)
+		switch {
+		case window.Status == config.TASK_PENDING && !window.EndTime.After(now):
+			log.Printf("maintenance window ID : %d of node : %s has been missed", window.ID, window.Node)
+			database.UpdateMaintenanceWindowStatus(window.ID, config.TASK_SUCCESS, 0)
+		case window.Status == config.TASK_PENDING && !window.StartTime.After(now):
+			log.Printf("maintenance window ID : %d is started, cordoning node : %s", window.ID, window.Node)
+			if _, cordonErr := database.SetNodeCordon(window.Node, true, reason, maintenanceUpdater); cordonErr != nil {
+				log.Printf("Schedule job error : cordoning node of maintenance window ID : %d due to %s", window.ID, cordonErr)
+				continue
+			}
+			var drainTaskID uint64
+			if window.Drain {
+				// empty cookies, migrating using api token
+				drainTask, drainErr := migrate.Drain(window.Owner, window.Node, 0, model.Cookies{})
+				if drainErr != nil {
+					log.Printf("Schedule job error : draining node of maintenance window ID : %d due to %s", window.ID, drainErr)
+				}
+				drainTaskID = drainTask.ID
+			}
+			database.UpdateMaintenanceWindowStatus(window.ID, config.TASK_RUNNING, drainTaskID)
+		case window.Status == config.TASK_RUNNING && !window.EndTime.After(now):
+			log.Printf("maintenance window ID : %d is ended, uncordoning node : %s", window.ID, window.Node)
+			// node which has been cordoned again by admin is kept cordoned
+			if state := database.GetNodeState(window.Node); state.Cordoned && state.UpdatedBy == maintenanceUpdater && state.Reason == reason {
+				if _, uncordonErr := database.SetNodeCordon(window.Node, false, "", maintenanceUpdater); uncordonErr != nil {
+					log.Printf("Schedule job error : uncordoning node of maintenance window ID : %d due to %s", window.ID, uncordonErr)
+					continue
+				}
+			}
+			database.UpdateMaintenanceWindowStatus(window.ID, config.TASK_SUCCESS, 0)
+		}
+	}
+	return nil
+}
+
+// SampleUsage - sampling usage of every VMs for accounting reports
+func SampleUsage() error {
+	if _, err := metering.Sample(); err != nil {
+		log.Println("Schedule job error : sampling usage due to", err)
+		return err
+	}
+	return nil
+}
+
+// PruneUsage - rolling up usage samples older than USAGE_RETENTION_DAYS by campus's day, keeping usage_sample bounded
+func PruneUsage() error {
+	now := config.Now().AddDate(0, 0, -config.Get().UsageRetention)
+	before := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.Location())
+	pruned, err := database.PruneUsageSamples(before, config.Location().String())
+	if err != nil {
+		log.Println("Schedule job error : pruning usage samples due to", err)
+		return err
+	}
+	log.Printf("Rolled up %d usage samples before %s", pruned, before.Format(time.RFC3339))
+	return nil
+}
+
+// ReconcileUsers - report users which are different between DB and Proxmox without fixing
+func ReconcileUsers() error {
+	report, err := reconcile.Users(false)
+	if err != nil {
+		log.Println("Schedule job error : reconciling users due to", err)
+		return err
+	}
+	if len(report.OnlyInDB) > 0 || len(report.OnlyInProxmox) > 0 || len(report.StatusMismatch) > 0 {
+		log.Printf("users only in DB : %v, only in Proxmox : %v, status mismatch : %v", report.OnlyInDB, report.OnlyInProxmox, report.StatusMismatch)
+	}
+	return nil
+}
+
+// ReconcileInstances - report differences between instance table and cluster, fixing when RECONCILE_AUTO_FIX is true
+func ReconcileInstances() error {
+	report, err := reconcile.Instances(config.Get().ReconcileAutoFix)
+	if err != nil {
+		log.Println("Schedule job error : reconciling instances due to", err)
+		return err
+	}
+	for _, orphan := range report.Orphans {
+		log.Printf("orphan VMID : %d in %s has no instance", orphan.VMID, orphan.Node)
+	}
+	if len(report.Ghosts) > 0 {
+		log.Printf("ghost instances : %v", report.Ghosts)
+	}
+	for _, drift := range report.Mismatches {
+		log.Printf("instance ID : %s %s mismatch, DB : %s, cluster : %s", drift.VMID, drift.Field, drift.DB, drift.Cluster)
 	}
 	return nil
 }